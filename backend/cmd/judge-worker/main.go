@@ -2,8 +2,11 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"os/signal"
+	"strconv"
+	"sync"
 	"syscall"
 	"time"
 
@@ -14,10 +17,11 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-const (
-	consumerGroup = "judge-workers"
-	consumerName  = "worker-1"
-)
+const consumerGroup = "judge-workers"
+
+// consumeErrorBackoff is how long a processor waits after failing to read
+// from Redis before trying again
+const consumeErrorBackoff = time.Second
 
 func main() {
 	logger := worker.NewLogger()
@@ -36,68 +40,239 @@ func main() {
 	}
 
 	// Create submission processor
-	processor := worker.NewSubmissionProcessor(logger)
+	testCaseParallelism, err := strconv.Atoi(config.Get("JUDGE_TEST_PARALLELISM"))
+	if err != nil || testCaseParallelism < 1 {
+		logger.WithField("value", config.Get("JUDGE_TEST_PARALLELISM")).Warn("Invalid JUDGE_TEST_PARALLELISM, judging test cases one at a time")
+		testCaseParallelism = 1
+	}
+	processor := worker.NewSubmissionProcessor(logger, testCaseParallelism)
 
-	// Handle graceful shutdown
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	consumerName := resolveConsumerName()
+	concurrency, err := strconv.Atoi(config.Get("JUDGE_WORKER_CONCURRENCY"))
+	if err != nil || concurrency < 1 {
+		logger.WithField("value", config.Get("JUDGE_WORKER_CONCURRENCY")).Warn("Invalid JUDGE_WORKER_CONCURRENCY, using a single processor")
+		concurrency = 1
+	}
 
-	// Main worker loop
-	logger.Info("Starting to consume jobs...")
-	ctx := context.Background()
+	// Handle graceful shutdown: the first signal stops reading new jobs and
+	// gives in-flight jobs the grace period to finish; jobs still running
+	// after that (or after a second signal) are cancelled and released
+	gracePeriod := durationConfig(logger, "JUDGE_SHUTDOWN_GRACE", 60*time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
+	jobCtx, cancelJobs := context.WithCancel(context.Background())
+	defer cancelJobs()
+
+	sigChan := make(chan os.Signal, 2)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		logger.WithField("grace_period", gracePeriod.String()).Info("Shutting down, draining in-flight submissions...")
+		cancel()
 
-	for {
 		select {
 		case <-sigChan:
-			logger.Info("Shutting down...")
-			return
-		default:
-			processJobs(ctx, logger, processor)
-			time.Sleep(1 * time.Second) // Small delay between polls
+			logger.Warn("Second signal received, cancelling in-flight submissions")
+		case <-time.After(gracePeriod):
+			logger.Warn("Grace period elapsed, cancelling in-flight submissions")
 		}
+		cancelJobs()
+	}()
+
+	// Main worker loop: each processor blocks on the stream and handles one
+	// job at a time, preferring jobs reclaimed from stalled consumers
+	logger.WithFields(logrus.Fields{
+		"consumer":    consumerName,
+		"concurrency": concurrency,
+	}).Info("Starting to consume jobs...")
+
+	reclaimed := make(chan queue.JudgeMessage)
+	go runReclaimer(ctx, logger, consumerName, int64(concurrency), reclaimed)
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				select {
+				case msg := <-reclaimed:
+					handleJob(jobCtx, logger, processor, msg)
+				default:
+					processJobs(ctx, jobCtx, logger, processor, consumerName)
+				}
+			}
+		}()
 	}
+	wg.Wait()
+	logger.Info("Judge worker stopped")
 }
 
-func processJobs(ctx context.Context, logger *logrus.Logger, processor *worker.SubmissionProcessor) {
-	// Consume jobs from Redis Stream
-	jobs, messageIDs, err := queue.ConsumeJudgeJobs(ctx, consumerGroup, consumerName, 10)
+// resolveConsumerName picks a stream consumer name that is unique per worker
+// process: an explicit JUDGE_CONSUMER_NAME, the pod name, or the hostname
+func resolveConsumerName() string {
+	if name := config.Get("JUDGE_CONSUMER_NAME"); name != "" {
+		return name
+	}
+	if name := config.Get("POD_NAME"); name != "" {
+		return name
+	}
+	if host, err := os.Hostname(); err == nil && host != "" {
+		return host
+	}
+	return fmt.Sprintf("worker-%d", os.Getpid())
+}
+
+// processJobs reads the next job with ctx and judges it with jobCtx, which
+// outlives ctx during shutdown
+func processJobs(ctx, jobCtx context.Context, logger *logrus.Logger, processor *worker.SubmissionProcessor, consumerName string) {
+	// Consume one job at a time so idle processors pick up the rest
+	messages, err := queue.ConsumeJudgeJobs(ctx, consumerGroup, consumerName, 1)
 	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
 		logger.WithError(err).Error("Error consuming jobs")
+		select {
+		case <-ctx.Done():
+		case <-time.After(consumeErrorBackoff):
+		}
 		return
 	}
 
-	if len(jobs) == 0 {
+	if len(messages) == 0 {
 		return // No jobs available
 	}
 
-	logger.WithField("count", len(jobs)).Info("Processing jobs")
+	logger.WithField("count", len(messages)).Info("Processing jobs")
+
+	for _, msg := range messages {
+		handleJob(jobCtx, logger, processor, msg)
+	}
+}
+
+// handleJob processes one job and acknowledges it on success. If ctx is
+// cancelled mid-judging the job is released back to the queue.
+func handleJob(ctx context.Context, logger *logrus.Logger, processor *worker.SubmissionProcessor, msg queue.JudgeMessage) {
+	job := msg.Job
+	logger.WithFields(logrus.Fields{
+		"submission_id": job.SubmissionID,
+		"job_version":   job.Version,
+		"stream":        msg.Stream,
+	}).Info("Processing job")
 
-	for i, job := range jobs {
-		messageID := messageIDs[i]
+	// Process the submission
+	if err := processor.Process(ctx, &job); err != nil {
+		if ctx.Err() != nil {
+			releaseJob(logger, msg)
+			return
+		}
 		logger.WithFields(logrus.Fields{
 			"submission_id": job.SubmissionID,
-			"problem_id":    job.ProblemID,
-			"language":      job.Language,
-		}).Info("Processing job")
+			"error":         err,
+		}).Error("Error processing submission")
+		// Don't acknowledge on error - the reclaimer retries it later
+		return
+	}
 
-		// Process the submission
-		if err := processor.Process(ctx, &job); err != nil {
+	// Acknowledge message; the job is done, so this must not be cancelled
+	if err := queue.AcknowledgeMessage(context.Background(), consumerGroup, msg.Stream, msg.MessageID); err != nil {
+		logger.WithFields(logrus.Fields{
+			"message_id": msg.MessageID,
+			"error":      err,
+		}).Error("Error acknowledging message")
+	} else {
+		logger.WithField("submission_id", job.SubmissionID).Info("Successfully processed and acknowledged submission")
+	}
+}
+
+// releaseJob hands an interrupted job back to the queue and resets its
+// submission so it is not left running
+func releaseJob(logger *logrus.Logger, msg queue.JudgeMessage) {
+	job := msg.Job
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := worker.ReleaseSubmission(logger, job.SubmissionID); err != nil {
+		logger.WithError(err).Error("Failed to release submission")
+	}
+	if err := queue.RequeueJudgeJob(ctx, consumerGroup, &msg); err != nil {
+		// Left pending, the reclaimer will pick it up
+		logger.WithFields(logrus.Fields{
+			"submission_id": job.SubmissionID,
+			"message_id":    msg.MessageID,
+			"error":         err,
+		}).Error("Failed to requeue interrupted job")
+		return
+	}
+
+	logger.WithField("submission_id", job.SubmissionID).Info("Requeued interrupted submission")
+}
+
+// runReclaimer periodically takes over jobs left pending by crashed or
+// failing consumers and dead-letters those that keep failing
+func runReclaimer(ctx context.Context, logger *logrus.Logger, consumerName string, batch int64, reclaimed chan<- queue.JudgeMessage) {
+	interval := durationConfig(logger, "JUDGE_RECLAIM_INTERVAL", 30*time.Second)
+	minIdle := durationConfig(logger, "JUDGE_RECLAIM_MIN_IDLE", 10*time.Minute)
+	maxAttempts, err := strconv.ParseInt(config.Get("JUDGE_MAX_ATTEMPTS"), 10, 64)
+	if err != nil || maxAttempts < 1 {
+		logger.WithField("value", config.Get("JUDGE_MAX_ATTEMPTS")).Warn("Invalid JUDGE_MAX_ATTEMPTS, using 3")
+		maxAttempts = 3
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		jobs, deadLetters, err := queue.ReclaimJudgeJobs(ctx, consumerGroup, consumerName, minIdle, maxAttempts, batch)
+		if err != nil && ctx.Err() == nil {
+			logger.WithError(err).Error("Error reclaiming pending jobs")
+		}
+
+		for _, dl := range deadLetters {
 			logger.WithFields(logrus.Fields{
-				"submission_id": job.SubmissionID,
-				"error":         err,
-			}).Error("Error processing submission")
-			// Don't acknowledge on error - let it retry later
-			continue
+				"stream":        dl.Stream,
+				"message_id":    dl.MessageID,
+				"submission_id": dl.SubmissionID,
+				"deliveries":    dl.Deliveries,
+				"reason":        dl.Reason,
+			}).Warn("Moved judge job to dead-letter stream")
+
+			if dl.SubmissionID != "" {
+				if err := worker.MarkSubmissionSystemError(logger, dl.SubmissionID, dl.Reason); err != nil {
+					logger.WithError(err).Error("Failed to mark dead-lettered submission")
+				}
+			}
 		}
 
-		// Acknowledge message
-		if err := queue.AcknowledgeMessage(ctx, consumerGroup, messageID); err != nil {
+		for _, msg := range jobs {
 			logger.WithFields(logrus.Fields{
-				"message_id": messageID,
-				"error":      err,
-			}).Error("Error acknowledging message")
-		} else {
-			logger.WithField("submission_id", job.SubmissionID).Info("Successfully processed and acknowledged submission")
+				"stream":        msg.Stream,
+				"message_id":    msg.MessageID,
+				"submission_id": msg.Job.SubmissionID,
+				"deliveries":    msg.Deliveries,
+			}).Info("Reclaimed pending judge job")
+
+			select {
+			case reclaimed <- msg:
+			case <-ctx.Done():
+				return
+			}
 		}
 	}
 }
+
+// durationConfig parses a duration setting, falling back on invalid values
+func durationConfig(logger *logrus.Logger, key string, fallback time.Duration) time.Duration {
+	d, err := time.ParseDuration(config.Get(key))
+	if err != nil || d <= 0 {
+		logger.WithField("value", config.Get(key)).Warnf("Invalid %s, using %s", key, fallback)
+		return fallback
+	}
+	return d
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 
 	_ "codehustle/backend/docs"
@@ -12,6 +13,7 @@ import (
 	"codehustle/backend/internal/config"
 	"codehustle/backend/internal/db"
 	"codehustle/backend/internal/handlers"
+	"codehustle/backend/internal/languages"
 	"codehustle/backend/internal/middleware"
 	"codehustle/backend/internal/queue"
 	"codehustle/backend/internal/routes"
@@ -48,6 +50,11 @@ func main() {
 		panic(fmt.Sprintf("failed to initialize Redis: %v", err))
 	}
 
+	// Load the languages accepted for submissions
+	if err := languages.Init(context.Background()); err != nil {
+		panic(fmt.Sprintf("failed to load languages: %v", err))
+	}
+
 	// Set Gin mode based on environment
 	if config.Get("ENV") == "production" {
 		gin.SetMode(gin.ReleaseMode)
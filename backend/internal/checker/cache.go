@@ -0,0 +1,184 @@
+package checker
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"codehustle/backend/internal/executor"
+	"codehustle/backend/internal/storage"
+
+	"github.com/sirupsen/logrus"
+)
+
+// checkerBuildVersion must change whenever the way checkers are built
+// changes, so binaries built the old way are not reused
+const checkerBuildVersion = "3"
+
+// checkerBuilds serializes lookups per cache key, so test cases judged in
+// parallel wait for one compile instead of each starting their own
+var checkerBuilds sync.Map // cache key -> *sync.Mutex
+
+// checkerCacheKey identifies a compiled checker by its source, the testlib
+// it was built with and the compiler that built it
+func checkerCacheKey(checkerCode, testlib []byte, compilerVersion string) string {
+	h := sha256.New()
+	for _, part := range [][]byte{
+		[]byte(checkerBuildVersion),
+		checkerCode,
+		testlib,
+		[]byte(compilerVersion),
+	} {
+		sum := sha256.Sum256(part)
+		h.Write(sum[:])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// runCached runs the checker from a cached binary, compiling it first if no
+// worker has built it yet
+func (s *Service) runCached(
+	ctx context.Context,
+	exec executor.Executor,
+	binaries executor.BinaryArtifacts,
+	compile executor.CompileRequest,
+	checkerCode, testlib []byte,
+	run executor.RunRequest,
+) (*executor.Result, error) {
+	artifact, err := s.compiledChecker(ctx, exec, binaries, compile, checkerCode, testlib)
+	if err != nil {
+		return nil, err
+	}
+	defer artifact.Close()
+
+	if artifact.CompileFailed() {
+		return &executor.Result{Compile: artifact.Compile}, nil
+	}
+	return exec.Run(ctx, artifact, run)
+}
+
+// buildProgram compiles a testlib program, through the binary cache when the
+// executor can restore binaries
+func (s *Service) buildProgram(
+	ctx context.Context,
+	exec executor.Executor,
+	compile executor.CompileRequest,
+	code, testlib []byte,
+) (*executor.Artifact, error) {
+	if binaries, ok := exec.(executor.BinaryArtifacts); ok {
+		return s.compiledChecker(ctx, exec, binaries, compile, code, testlib)
+	}
+	return exec.Compile(ctx, compile)
+}
+
+// compiledChecker looks for the checker binary in the worker's cache
+// directory, then in the shared cache bucket, and compiles it only if
+// neither has it. Freshly built binaries are stored in both. Compile
+// failures are returned in the artifact and not cached.
+func (s *Service) compiledChecker(
+	ctx context.Context,
+	exec executor.Executor,
+	binaries executor.BinaryArtifacts,
+	compile executor.CompileRequest,
+	checkerCode, testlib []byte,
+) (*executor.Artifact, error) {
+	compilerVersion, err := binaries.CompilerVersion(ctx, compile.Language)
+	if err != nil {
+		return nil, err
+	}
+	key := checkerCacheKey(checkerCode, testlib, compilerVersion)
+	objectKey := compile.Language + "/" + key
+
+	lock, _ := checkerBuilds.LoadOrStore(key, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	localPath := ""
+	if s.cacheDir != "" {
+		localPath = filepath.Join(s.cacheDir, compile.Language, key)
+		if binary, err := os.ReadFile(localPath); err == nil {
+			return binaries.ImportBinary(compile.Language, compile.Version, binary)
+		}
+	}
+
+	if binary, err := storage.GetFile(s.cacheBucket, objectKey); err == nil && len(binary) > 0 {
+		logrus.WithFields(logrus.Fields{
+			"bucket": s.cacheBucket,
+			"key":    objectKey,
+		}).Debug("Found compiled checker in cache bucket")
+		s.storeLocal(localPath, binary)
+		return binaries.ImportBinary(compile.Language, compile.Version, binary)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"key":      objectKey,
+		"compiler": compilerVersion,
+	}).Info("Compiling checker")
+
+	artifact, err := exec.Compile(ctx, compile)
+	if err != nil {
+		return nil, err
+	}
+	if artifact.CompileFailed() {
+		return artifact, nil
+	}
+
+	binary, err := binaries.ExportBinary(artifact)
+	if err != nil {
+		// The artifact is still usable, it just cannot be cached
+		logrus.WithError(err).Warn("Failed to export compiled checker")
+		return artifact, nil
+	}
+	s.storeLocal(localPath, binary)
+	if err := storage.UploadFile(s.cacheBucket, objectKey, bytes.NewReader(binary), int64(len(binary)), "application/octet-stream"); err != nil {
+		logrus.WithFields(logrus.Fields{
+			"bucket": s.cacheBucket,
+			"key":    objectKey,
+			"error":  err,
+		}).Warn("Failed to upload compiled checker")
+	}
+
+	return artifact, nil
+}
+
+// storeLocal saves a binary in the worker's cache directory. The file is
+// renamed into place so concurrent workers never read a partial binary.
+func (s *Service) storeLocal(path string, binary []byte) {
+	if path == "" {
+		return
+	}
+	if err := writeFileAtomic(path, binary); err != nil {
+		logrus.WithFields(logrus.Fields{
+			"path":  path,
+			"error": err,
+		}).Warn("Failed to cache compiled checker locally")
+	}
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to move %s into place: %w", path, err)
+	}
+	return nil
+}
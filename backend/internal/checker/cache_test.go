@@ -0,0 +1,66 @@
+package checker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"codehustle/backend/internal/executor"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBinaryExecutor is a fakeExecutor whose binaries can be cached
+type fakeBinaryExecutor struct {
+	*fakeExecutor
+	imported [][]byte
+}
+
+func (e *fakeBinaryExecutor) CompilerVersion(ctx context.Context, language string) (string, error) {
+	return "g++ 13.2.0", nil
+}
+
+func (e *fakeBinaryExecutor) ExportBinary(artifact *executor.Artifact) ([]byte, error) {
+	return []byte("binary"), nil
+}
+
+func (e *fakeBinaryExecutor) ImportBinary(language, version string, binary []byte) (*executor.Artifact, error) {
+	e.imported = append(e.imported, binary)
+	return &executor.Artifact{Language: language, Version: version}, nil
+}
+
+func TestCheckerCacheKey(t *testing.T) {
+	key := checkerCacheKey([]byte("checker"), []byte("testlib"), "g++ 13")
+
+	assert.Equal(t, key, checkerCacheKey([]byte("checker"), []byte("testlib"), "g++ 13"), "stable")
+	assert.NotEqual(t, key, checkerCacheKey([]byte("checker2"), []byte("testlib"), "g++ 13"), "checker source")
+	assert.NotEqual(t, key, checkerCacheKey([]byte("checker"), []byte("testlib2"), "g++ 13"), "testlib version")
+	assert.NotEqual(t, key, checkerCacheKey([]byte("checker"), []byte("testlib"), "g++ 14"), "compiler version")
+	// Parts are hashed separately, so moving bytes between them changes the key
+	assert.NotEqual(t, key, checkerCacheKey([]byte("checkert"), []byte("estlib"), "g++ 13"))
+}
+
+func TestCheckUsesLocalCache(t *testing.T) {
+	const code = "int main() {}"
+	exec := &fakeBinaryExecutor{fakeExecutor: &fakeExecutor{stagesRunFiles: true, run: executor.StageResult{Stderr: "ok"}}}
+	s := newTestService(t, exec, map[string]string{"checker.cpp": code})
+	s.cacheDir = t.TempDir()
+
+	// A binary another submission already built, keyed by what Check compiles
+	key := checkerCacheKey([]byte(code), nil, "g++ 13.2.0")
+	path := filepath.Join(s.cacheDir, "cpp", key)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte("cached binary"), 0o644))
+
+	for i := 0; i < 3; i++ {
+		resp, err := s.Check(context.Background(), CheckRequest{CheckerPath: "checker.cpp"})
+		require.NoError(t, err)
+		require.True(t, resp.Accepted, resp.Error)
+	}
+
+	assert.Empty(t, exec.compiles, "the checker is never compiled")
+	assert.Len(t, exec.runs, 3)
+	assert.Equal(t, [][]byte{[]byte("cached binary"), []byte("cached binary"), []byte("cached binary")}, exec.imported)
+}
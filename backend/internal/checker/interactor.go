@@ -0,0 +1,137 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+
+	"codehustle/backend/internal/executor"
+
+	"github.com/sirupsen/logrus"
+)
+
+// InteractRequest describes a run of a compiled submission against an
+// interactor on a single test
+type InteractRequest struct {
+	InteractorPath string
+	Program        *executor.Artifact
+	Input          string
+	Expected       string
+	RuntimeImage   string
+	Version        string
+
+	ProgramTimeLimitMs      int
+	ProgramMemoryLimitKb    int
+	InteractorTimeLimitMs   int
+	InteractorMemoryLimitKb int
+}
+
+// InteractResponse is the outcome of an interactive run. Verdict, Score and
+// Message come from the interactor like they do from a checker.
+type InteractResponse struct {
+	Program       executor.StageResult
+	Verdict       string
+	Score         float64
+	Message       string
+	Error         string
+	CompileStderr string
+	RunStderr     string
+}
+
+// Interact builds the interactor and runs the submission against it. The
+// interactor is started as testlib expects: interactor <input> <output>
+// <answer>, talking to the submission over its standard streams.
+func (s *Service) Interact(ctx context.Context, req InteractRequest) (*InteractResponse, error) {
+	interactorBytes, err := s.fetchProgram(req.InteractorPath)
+	if err != nil {
+		return &InteractResponse{
+			Error: fmt.Sprintf("failed to fetch interactor from bucket '%s' at path '%s': %v", s.bucket, req.InteractorPath, err),
+		}, nil
+	}
+	testlibBytes := s.testlib()
+
+	exec, err := defaultExecutor()
+	if err != nil {
+		return &InteractResponse{Error: fmt.Sprintf("executor unavailable: %v", err)}, nil
+	}
+	runner, ok := exec.(executor.InteractiveRunner)
+	if !ok {
+		return &InteractResponse{Error: "interactive problems need an executor that can connect programs (JUDGE_EXECUTOR=local)"}, nil
+	}
+
+	compile := executor.CompileRequest{
+		Language: "cpp",
+		Version:  s.mapVersionToPiston(req.Version, req.RuntimeImage),
+		Files:    testlibSources(interactorBytes, testlibBytes),
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"interactor_path": req.InteractorPath,
+		"version":         compile.Version,
+	}).Debug("Running submission against interactor")
+
+	interactor, err := s.buildProgram(ctx, exec, compile, interactorBytes, testlibBytes)
+	if err != nil {
+		return &InteractResponse{Error: fmt.Sprintf("interactor build error: %v", err)}, nil
+	}
+	defer interactor.Close()
+
+	if interactor.CompileFailed() {
+		return &InteractResponse{
+			Error:         fmt.Sprintf("interactor compilation failed with exit code %d", interactor.Compile.Code),
+			CompileStderr: interactor.Compile.Stderr,
+		}, nil
+	}
+
+	result, err := runner.RunInteractive(ctx,
+		req.Program, executor.RunRequest{
+			TimeLimitMs:   req.ProgramTimeLimitMs,
+			MemoryLimitKb: req.ProgramMemoryLimitKb,
+		},
+		interactor, executor.RunRequest{
+			Args: []string{"input", "output", "expected"},
+			Files: []executor.File{
+				{Name: "input", Content: req.Input},
+				{Name: "expected", Content: req.Expected},
+			},
+			TimeLimitMs:   req.InteractorTimeLimitMs,
+			MemoryLimitKb: req.InteractorMemoryLimitKb,
+		},
+	)
+	if err != nil {
+		return &InteractResponse{Error: fmt.Sprintf("interactive run error: %v", err)}, nil
+	}
+
+	if result.Interactor.Signal != "" {
+		if result.Program.TimedOut(req.ProgramTimeLimitMs) {
+			// Both sides were waiting for each other and the submission ran
+			// out of time first, so the time limit verdict stands
+			return &InteractResponse{
+				Program:   result.Program,
+				Verdict:   VerdictWrongAnswer,
+				RunStderr: result.Interactor.Stderr,
+			}, nil
+		}
+		return &InteractResponse{
+			Program:   result.Program,
+			Error:     fmt.Sprintf("interactor killed by %s", result.Interactor.Signal),
+			RunStderr: result.Interactor.Stderr,
+		}, nil
+	}
+
+	verdict, score, message, ok := parseCheckerResult(result.Interactor.Code, result.Interactor.Stderr)
+	if !ok {
+		return &InteractResponse{
+			Program:   result.Program,
+			Error:     fmt.Sprintf("interactor exited with exit code %d", result.Interactor.Code),
+			RunStderr: result.Interactor.Stderr,
+		}, nil
+	}
+
+	return &InteractResponse{
+		Program:   result.Program,
+		Verdict:   verdict,
+		Score:     score,
+		Message:   message,
+		RunStderr: result.Interactor.Stderr,
+	}, nil
+}
@@ -0,0 +1,102 @@
+package checker
+
+import (
+	"context"
+	"testing"
+
+	"codehustle/backend/internal/executor"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeInteractiveExecutor answers interactive runs with program and
+// interactor
+type fakeInteractiveExecutor struct {
+	*fakeExecutor
+	program    executor.StageResult
+	interactor executor.StageResult
+
+	programReqs    []executor.RunRequest
+	interactorReqs []executor.RunRequest
+}
+
+func (e *fakeInteractiveExecutor) RunInteractive(ctx context.Context, program *executor.Artifact, programReq executor.RunRequest, interactor *executor.Artifact, interactorReq executor.RunRequest) (*executor.InteractiveResult, error) {
+	e.programReqs = append(e.programReqs, programReq)
+	e.interactorReqs = append(e.interactorReqs, interactorReq)
+	return &executor.InteractiveResult{Program: e.program, Interactor: e.interactor}, nil
+}
+
+func interactRequest() InteractRequest {
+	return InteractRequest{
+		InteractorPath:          "interactor.cpp",
+		Program:                 &executor.Artifact{},
+		Input:                   "42\n",
+		Expected:                "found\n",
+		ProgramTimeLimitMs:      1000,
+		ProgramMemoryLimitKb:    65536,
+		InteractorTimeLimitMs:   2000,
+		InteractorMemoryLimitKb: 131072,
+	}
+}
+
+func TestInteract(t *testing.T) {
+	tests := []struct {
+		name       string
+		program    executor.StageResult
+		interactor executor.StageResult
+		verdict    string
+		message    string
+		err        string
+	}{
+		{name: "ok", interactor: executor.StageResult{Stderr: "ok found in 6 queries"}, verdict: VerdictOK, message: "found in 6 queries"},
+		{name: "wrong answer", interactor: executor.StageResult{Code: 1, Stderr: "wrong answer too many queries"}, verdict: VerdictWrongAnswer, message: "too many queries"},
+		{name: "program out of time", program: executor.StageResult{Status: executor.StatusTimeout}, interactor: executor.StageResult{Signal: "SIGKILL"}, verdict: VerdictWrongAnswer},
+		{name: "interactor killed", interactor: executor.StageResult{Signal: "SIGKILL"}, err: "interactor killed by SIGKILL"},
+		{name: "unknown exit code", interactor: executor.StageResult{Code: 5}, err: "exit code 5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exec := &fakeInteractiveExecutor{fakeExecutor: &fakeExecutor{stagesRunFiles: true}, program: tt.program, interactor: tt.interactor}
+			s := newTestService(t, exec, map[string]string{"interactor.cpp": "int main() {}"})
+
+			resp, err := s.Interact(context.Background(), interactRequest())
+			require.NoError(t, err)
+			if tt.err != "" {
+				assert.Contains(t, resp.Error, tt.err)
+				return
+			}
+			assert.Empty(t, resp.Error)
+			assert.Equal(t, tt.verdict, resp.Verdict)
+			assert.Equal(t, tt.message, resp.Message)
+			assert.Equal(t, tt.program, resp.Program)
+		})
+	}
+}
+
+func TestInteractRunsBothSidesUnderTheirLimits(t *testing.T) {
+	exec := &fakeInteractiveExecutor{fakeExecutor: &fakeExecutor{stagesRunFiles: true}}
+	s := newTestService(t, exec, map[string]string{"interactor.cpp": "int main() {}"})
+
+	_, err := s.Interact(context.Background(), interactRequest())
+	require.NoError(t, err)
+
+	require.Len(t, exec.programReqs, 1)
+	assert.Equal(t, executor.RunRequest{TimeLimitMs: 1000, MemoryLimitKb: 65536}, exec.programReqs[0])
+
+	require.Len(t, exec.interactorReqs, 1)
+	interactor := exec.interactorReqs[0]
+	assert.Equal(t, []string{"input", "output", "expected"}, interactor.Args)
+	assert.Equal(t, []executor.File{{Name: "input", Content: "42\n"}, {Name: "expected", Content: "found\n"}}, interactor.Files)
+	assert.Equal(t, 2000, interactor.TimeLimitMs)
+	assert.Equal(t, 131072, interactor.MemoryLimitKb)
+}
+
+func TestInteractNeedsInteractiveRunner(t *testing.T) {
+	s := newTestService(t, &fakeExecutor{}, map[string]string{"interactor.cpp": "int main() {}"})
+
+	resp, err := s.Interact(context.Background(), interactRequest())
+	require.NoError(t, err)
+	assert.Contains(t, resp.Error, "JUDGE_EXECUTOR=local")
+}
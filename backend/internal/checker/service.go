@@ -1,15 +1,13 @@
 package checker
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
-	"net/http"
 	"strings"
-	"time"
+	"sync"
 
 	"codehustle/backend/internal/config"
-	"codehustle/backend/internal/pistonlog"
+	"codehustle/backend/internal/executor"
 	"codehustle/backend/internal/storage"
 
 	"github.com/sirupsen/logrus"
@@ -17,11 +15,23 @@ import (
 	"github.com/minio/minio-go/v7"
 )
 
-// Service handles checker compilation and execution via Piston
+// defaultExecutor returns the executor checkers run on; tests replace it
+var defaultExecutor = executor.Default
+
+// Service handles checker compilation and execution. It keeps the checker,
+// interactor and testlib sources it downloads, so the worker builds one per
+// submission and its test cases do not fetch them again.
 type Service struct {
 	minioClient *minio.Client
-	pistonURL   string
 	bucket      string
+	cacheBucket string // compiled checkers shared by all workers
+	cacheDir    string // compiled checkers kept by this worker
+
+	mu           sync.Mutex
+	checkers     map[string][]byte // by checker and statement path
+	programs     map[string][]byte // interactors and validators by path
+	testlibOnce  sync.Once
+	testlibBytes []byte
 }
 
 // CheckRequest represents a checker execution request
@@ -38,6 +48,9 @@ type CheckRequest struct {
 // CheckResponse represents the result of checker execution
 type CheckResponse struct {
 	Accepted      bool
+	Verdict       string  // ok, wa, pe, fail, dirt or partially
+	Score         float64 // fraction of the test's points earned, 0 to 1
+	Message       string  // the checker's comment
 	Error         string
 	CompileStderr string
 	RunStderr     string
@@ -50,22 +63,142 @@ func NewService(minioClient *minio.Client) *Service {
 		bucket = "problem-checkers"
 	}
 
-	pistonURL := config.Get("PISTON_URL")
-	if pistonURL == "" {
-		pistonURL = "http://127.0.0.1:3002"
+	cacheBucket := config.Get("BUCKET_CHECKERS_CACHE")
+	if cacheBucket == "" {
+		cacheBucket = "checkers-cache"
 	}
 
 	return &Service{
 		minioClient: minioClient,
-		pistonURL:   pistonURL,
 		bucket:      bucket,
+		cacheBucket: cacheBucket,
+		cacheDir:    config.Get("JUDGE_CHECKER_CACHE_DIR"),
+		checkers:    make(map[string][]byte),
+		programs:    make(map[string][]byte),
 	}
 }
 
-// Check compiles and executes a checker using Piston
-func (s *Service) Check(req CheckRequest) (*CheckResponse, error) {
-	// Try to download checker.cpp from MinIO
-	// First try the stored checker_custom_path, then derive from statement_path if it fails
+// Check compiles and executes a checker with the configured executor
+func (s *Service) Check(ctx context.Context, req CheckRequest) (*CheckResponse, error) {
+	checkerBytes, err := s.checkerSource(req)
+	if err != nil {
+		return &CheckResponse{
+			Accepted: false,
+			Error:    err.Error(),
+		}, nil
+	}
+
+	testlibBytes := s.testlib()
+
+	// Determine Piston language and version
+	language := "cpp"
+	pistonVersion := s.mapVersionToPiston(req.Version, req.RuntimeImage)
+
+	exec, err := defaultExecutor()
+	if err != nil {
+		return &CheckResponse{
+			Accepted: false,
+			Error:    fmt.Sprintf("executor unavailable: %v", err),
+		}, nil
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"checker_path": req.CheckerPath,
+		"language":     language,
+		"version":      pistonVersion,
+	}).Debug("Compiling and running checker")
+
+	compile := executor.CompileRequest{
+		Language: language,
+		Version:  pistonVersion,
+	}
+	run := executor.RunRequest{
+		TimeLimitMs:   5000,       // 5 second timeout for checker
+		MemoryLimitKb: 256 * 1024, // 256MB
+	}
+
+	if stager, ok := exec.(executor.RunFileStager); ok && stager.StagesRunFiles() {
+		// Run the checker as testlib expects: checker <input> <output> <answer>
+		compile.Files = testlibSources(checkerBytes, testlibBytes)
+		run.Args = []string{"input", "output", "expected"}
+		run.Files = []executor.File{
+			{Name: "input", Content: req.Input},
+			{Name: "output", Content: req.Output},
+			{Name: "expected", Content: req.Expected},
+		}
+	} else {
+		// Piston compiles every file it is sent, so the test goes through stdin
+		compile.Files = []executor.File{{Name: "main.cpp", Content: s.generateCombinedCode(string(checkerBytes), testlibBytes)}}
+		run.Stdin = checkerStdin(req.Input, req.Output, req.Expected)
+	}
+
+	var result *executor.Result
+	if binaries, ok := exec.(executor.BinaryArtifacts); ok {
+		result, err = s.runCached(ctx, exec, binaries, compile, checkerBytes, testlibBytes, run)
+	} else {
+		// The executor keeps no binaries (Piston), so each check is a single
+		// request that builds the checker and runs it on the test. This is a
+		// known cost; the worker warns about it at startup.
+		result, err = exec.Execute(ctx, executor.ExecuteRequest{CompileRequest: compile, RunRequest: run})
+	}
+	if err != nil {
+		return &CheckResponse{
+			Accepted: false,
+			Error:    fmt.Sprintf("checker execution error: %v", err),
+		}, nil
+	}
+
+	// Check compilation errors
+	if result.Compile.CompileFailed() {
+		return &CheckResponse{
+			Accepted:      false,
+			Error:         fmt.Sprintf("compilation failed with exit code %d", result.Compile.Code),
+			CompileStderr: result.Compile.Stderr,
+		}, nil
+	}
+
+	// Check runtime errors
+	if result.Run.Signal != "" {
+		return &CheckResponse{
+			Accepted:  false,
+			Error:     fmt.Sprintf("checker killed by %s", result.Run.Signal),
+			RunStderr: result.Run.Stderr,
+		}, nil
+	}
+
+	// Testlib reports the verdict through the exit code and the comment on stderr
+	verdict, score, message, ok := parseCheckerResult(result.Run.Code, result.Run.Stderr)
+	if !ok {
+		return &CheckResponse{
+			Accepted:  false,
+			Error:     fmt.Sprintf("checker execution failed with exit code %d", result.Run.Code),
+			RunStderr: result.Run.Stderr,
+		}, nil
+	}
+
+	// FAIL is returned as a verdict rather than an error so the judge can
+	// flag the problem instead of failing the submission
+	return &CheckResponse{
+		Accepted:  verdict == VerdictOK,
+		Verdict:   verdict,
+		Score:     score,
+		Message:   message,
+		RunStderr: result.Run.Stderr,
+	}, nil
+}
+
+// checkerSource returns the checker's source, trying the stored
+// checker_custom_path and then a path derived from the statement path. The
+// source is downloaded once per service.
+func (s *Service) checkerSource(req CheckRequest) ([]byte, error) {
+	key := req.CheckerPath + "\x00" + req.StatementPath
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if code, ok := s.checkers[key]; ok {
+		return code, nil
+	}
+
 	checkerPath := req.CheckerPath
 
 	logrus.WithFields(logrus.Fields{
@@ -109,180 +242,93 @@ func (s *Service) Check(req CheckRequest) (*CheckResponse, error) {
 			errorMsg += fmt.Sprintf(" (also tried derived path '%s')", derivedPath)
 		}
 		errorMsg += fmt.Sprintf(": %v", err)
+		return nil, fmt.Errorf("%s", errorMsg)
+	}
 
-		return &CheckResponse{
-			Accepted: false,
-			Error:    errorMsg,
-		}, nil
+	s.checkers[key] = checkerBytes
+	return checkerBytes, nil
+}
+
+// fetchProgram returns a testlib program such as an interactor or validator
+// from the checkers bucket, downloading it once per service
+func (s *Service) fetchProgram(path string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if code, ok := s.programs[path]; ok {
+		return code, nil
 	}
 
-	// Try to download testlib.h from MinIO (judge-common bucket)
-	var testlibBytes []byte
+	code, err := storage.GetFile(s.bucket, path)
+	if err != nil {
+		return nil, err
+	}
+	s.programs[path] = code
+	return code, nil
+}
+
+// testlib returns testlib.h, downloading it once per service
+func (s *Service) testlib() []byte {
+	s.testlibOnce.Do(func() {
+		s.testlibBytes = fetchTestlib()
+	})
+	return s.testlibBytes
+}
+
+// fetchTestlib downloads testlib.h from MinIO (judge-common bucket). Checkers
+// and interactors are built without it if it is missing.
+func fetchTestlib() []byte {
 	testlibBucket := config.Get("BUCKET_JUDGE_COMMON")
 	if testlibBucket == "" {
 		testlibBucket = "judge-common" // Default bucket name
 	}
 
 	testlibPath := "common/testlib.h"
-	testlibBytes, err = storage.GetFile(testlibBucket, testlibPath)
+	testlibBytes, err := storage.GetFile(testlibBucket, testlibPath)
 	if err != nil {
 		logrus.WithFields(logrus.Fields{
 			"bucket": testlibBucket,
 			"path":   testlibPath,
 			"error":  err,
 		}).Debug("testlib.h not found in MinIO, continuing without it")
-		// Reset err to nil so we can continue without testlib.h
-		err = nil
-	} else {
-		logrus.WithFields(logrus.Fields{
-			"bucket": testlibBucket,
-			"path":   testlibPath,
-		}).Debug("Found testlib.h")
+		return nil
 	}
 
-	// Generate combined source file that includes checker and wrapper
-	combinedCode := s.generateCombinedCode(string(checkerBytes), req.Input, req.Output, req.Expected, testlibBytes)
-
-	// Prepare files for Piston - use main.cpp as single entry point
-	files := []map[string]string{
-		{"name": "main.cpp", "content": combinedCode},
-	}
-
-	// Determine Piston language and version
-	language := "cpp"
-	pistonVersion := s.mapVersionToPiston(req.Version, req.RuntimeImage)
-
-	// Prepare Piston payload
-	payload := map[string]interface{}{
-		"language":        language,
-		"version":         pistonVersion,
-		"files":           files,
-		"stdin":           "",
-		"args":            []string{},
-		"compile_timeout": 10000,
-		"run_timeout":     5000,              // 5 second timeout for checker
-		"memory_limit":    256 * 1024 * 1024, // 256MB
-	}
-
-	body, err := json.Marshal(payload)
-	if err != nil {
-		return &CheckResponse{
-			Accepted: false,
-			Error:    fmt.Sprintf("failed to marshal payload: %v", err),
-		}, nil
-	}
-
-	// Call Piston API
-	execURL := s.pistonURL + "/api/v2/execute"
 	logrus.WithFields(logrus.Fields{
-		"checker_path": req.CheckerPath,
-		"piston_url":   execURL,
-		"language":     language,
-		"version":      pistonVersion,
-	}).Debug("Calling Piston to compile and run checker")
-
-	// Log request to file
-	pistonlog.LogRequest(execURL, payload, map[string]interface{}{
-		"checker_path": req.CheckerPath,
-		"type":         "checker",
-	})
-
-	httpReq, err := http.NewRequest(http.MethodPost, execURL, strings.NewReader(string(body)))
-	if err != nil {
-		return &CheckResponse{
-			Accepted: false,
-			Error:    fmt.Sprintf("failed to create request: %v", err),
-		}, nil
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(httpReq)
-	if err != nil {
-		pistonlog.LogError(execURL, err, map[string]interface{}{
-			"checker_path": req.CheckerPath,
-			"type":         "checker",
-		})
-		return &CheckResponse{
-			Accepted: false,
-			Error:    fmt.Sprintf("piston execution error: %v", err),
-		}, nil
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return &CheckResponse{
-			Accepted: false,
-			Error:    fmt.Sprintf("failed to read response: %v", err),
-		}, nil
-	}
-
-	// Log response to file
-	pistonlog.LogResponse(execURL, resp.StatusCode, respBody, map[string]interface{}{
-		"checker_path": req.CheckerPath,
-		"type":         "checker",
-	})
+		"bucket": testlibBucket,
+		"path":   testlibPath,
+	}).Debug("Found testlib.h")
+	return testlibBytes
+}
 
-	// Parse Piston response
-	var pistonResult struct {
-		Compile struct {
-			Code   int    `json:"code"`
-			Stdout string `json:"stdout"`
-			Stderr string `json:"stderr"`
-		} `json:"compile"`
-		Run struct {
-			Code   int    `json:"code"`
-			Stdout string `json:"stdout"`
-			Stderr string `json:"stderr"`
-		} `json:"run"`
+// programSources returns the sources of a testlib program for the executor.
+// Piston compiles every file it is sent, so there testlib.h is inlined.
+func programSources(exec executor.Executor, code, testlib []byte) []executor.File {
+	if stager, ok := exec.(executor.RunFileStager); ok && stager.StagesRunFiles() {
+		return testlibSources(code, testlib)
 	}
 
-	if err := json.Unmarshal(respBody, &pistonResult); err != nil {
-		logrus.WithField("response_body", string(respBody)).Error("Failed to parse Piston response")
-		return &CheckResponse{
-			Accepted: false,
-			Error:    fmt.Sprintf("failed to parse response: %v", err),
-		}, nil
-	}
-
-	// Check compilation errors
-	if pistonResult.Compile.Code != 0 {
-		return &CheckResponse{
-			Accepted:      false,
-			Error:         fmt.Sprintf("compilation failed with exit code %d", pistonResult.Compile.Code),
-			CompileStderr: pistonResult.Compile.Stderr,
-		}, nil
+	source := testlibPCBaseDefine + string(code)
+	if len(testlib) > 0 {
+		source = fmt.Sprintf("%s// Testlib header\n%s\n\n%s", testlibPCBaseDefine, string(testlib), string(code))
 	}
+	return []executor.File{{Name: "main.cpp", Content: source}}
+}
 
-	// Check runtime errors
-	if pistonResult.Run.Code != 0 && pistonResult.Run.Code != 1 {
-		// Exit code 1 might be acceptable (wrong answer), but other codes indicate errors
-		return &CheckResponse{
-			Accepted:  false,
-			Error:     fmt.Sprintf("checker execution failed with exit code %d", pistonResult.Run.Code),
-			RunStderr: pistonResult.Run.Stderr,
-		}, nil
+// testlibSources returns the files to build a testlib program from, with
+// testlib.h next to it when available
+func testlibSources(code, testlib []byte) []executor.File {
+	files := []executor.File{{Name: "main.cpp", Content: testlibPCBaseDefine + string(code)}}
+	if len(testlib) > 0 {
+		files = append(files, executor.File{Name: "testlib.h", Content: string(testlib)})
 	}
-
-	// Checker returns 0 for accepted, non-zero (usually 1) for wrong answer
-	// Exit code 0 = accepted, non-zero = wrong answer
-	accepted := pistonResult.Run.Code == 0
-
-	return &CheckResponse{
-		Accepted:  accepted,
-		RunStderr: pistonResult.Run.Stderr,
-	}, nil
+	return files
 }
 
-// generateCombinedCode creates a combined C++ source that includes checker code and wrapper
-func (s *Service) generateCombinedCode(checkerCode, input, output, expected string, testlibBytes []byte) string {
-	// Escape input/output/expected for C++ raw string literal
-	escapedInput := escapeForRawString(input)
-	escapedOutput := escapeForRawString(output)
-	escapedExpected := escapeForRawString(expected)
-
-	result := ""
+// generateCombinedCode creates a combined C++ source that includes checker code and wrapper,
+// for executors that cannot stage run files. The wrapper reads the test from stdin
+// (see checkerStdin) and writes the files the checker expects.
+func (s *Service) generateCombinedCode(checkerCode string, testlibBytes []byte) string {
+	result := testlibPCBaseDefine
 
 	// Include testlib.h first if available
 	if len(testlibBytes) > 0 {
@@ -290,9 +336,11 @@ func (s *Service) generateCombinedCode(checkerCode, input, output, expected stri
 	}
 
 	// Include standard headers
-	result += `#include <fstream>
-#include <cstdlib>
-#include <cstring>
+	result += `#include <cstdio>
+#include <fstream>
+#include <iostream>
+#include <iterator>
+#include <string>
 
 `
 
@@ -314,38 +362,43 @@ func (s *Service) generateCombinedCode(checkerCode, input, output, expected stri
 
 `
 
-	// Add wrapper main() that writes files and calls checker_main()
-	result += fmt.Sprintf(`// Wrapper main() that writes files and calls checker
+	// Add wrapper main() that unpacks the test into files and calls checker_main()
+	result += `// Wrapper main() that writes the test from stdin to files and calls checker
 int main() {
-	// Write input file
-	std::ofstream inputFile("input");
-	inputFile << R"CHECKER_DELIM(%s)CHECKER_DELIM";
-	inputFile.close();
-	
-	// Write output file
-	std::ofstream outputFile("output");
-	outputFile << R"CHECKER_DELIM(%s)CHECKER_DELIM";
-	outputFile.close();
-	
-	// Write expected file
-	std::ofstream expectedFile("expected");
-	expectedFile << R"CHECKER_DELIM(%s)CHECKER_DELIM";
-	expectedFile.close();
-	
+	std::string data((std::istreambuf_iterator<char>(std::cin)), std::istreambuf_iterator<char>());
+
+	// Header line: sizes of input, output and expected, followed by the contents
+	size_t sizes[3];
+	size_t offset = data.find('\n');
+	if (offset == std::string::npos || sscanf(data.c_str(), "%zu %zu %zu", &sizes[0], &sizes[1], &sizes[2]) != 3) {
+		fprintf(stderr, "FAIL malformed checker input\n");
+		return 3;
+	}
+	offset++;
+
+	const char* names[] = {"input", "output", "expected"};
+	for (int i = 0; i < 3; i++) {
+		if (sizes[i] > data.size() - offset) {
+			fprintf(stderr, "FAIL truncated checker input\n");
+			return 3;
+		}
+		std::ofstream file(names[i], std::ios::binary);
+		file << data.substr(offset, sizes[i]);
+		offset += sizes[i];
+	}
+
 	// Call checker's main (renamed to checker_main) with file arguments
-	char* args[] = {"checker", "input", "output", "expected"};
+	char* args[] = {(char*)"checker", (char*)"input", (char*)"output", (char*)"expected"};
 	return checker_main(4, args);
-}`, escapedInput, escapedOutput, escapedExpected)
+}`
 
 	return result
 }
 
-// escapeForRawString escapes content for C++ raw string literal with custom delimiter
-// Uses delimiter "CHECKER_DELIM" to avoid conflicts with content containing )"
-func escapeForRawString(s string) string {
-	// If content contains the delimiter, we need to escape it, but that's unlikely
-	// For now, just return as-is since we're using a unique delimiter
-	return s
+// checkerStdin packs a test for the wrapper in generateCombinedCode: a line
+// with the byte sizes of the three parts, followed by the parts themselves
+func checkerStdin(input, output, expected string) string {
+	return fmt.Sprintf("%d %d %d\n%s%s%s", len(input), len(output), len(expected), input, output, expected)
 }
 
 // deriveCheckerPathFromStatement derives checker path from statement path
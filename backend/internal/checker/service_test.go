@@ -0,0 +1,149 @@
+package checker
+
+import (
+	"context"
+	"testing"
+
+	"codehustle/backend/internal/executor"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeExecutor records the requests it gets and answers every run with run,
+// or with runFor when set
+type fakeExecutor struct {
+	stagesRunFiles bool
+	compile        executor.StageResult
+	run            executor.StageResult
+	runFor         func(req executor.RunRequest) executor.StageResult
+
+	compiles []executor.CompileRequest
+	runs     []executor.RunRequest
+}
+
+func (e *fakeExecutor) Compile(ctx context.Context, req executor.CompileRequest) (*executor.Artifact, error) {
+	e.compiles = append(e.compiles, req)
+	return &executor.Artifact{Language: req.Language, Version: req.Version, Files: req.Files, Compile: e.compile}, nil
+}
+
+func (e *fakeExecutor) Run(ctx context.Context, artifact *executor.Artifact, req executor.RunRequest) (*executor.Result, error) {
+	e.runs = append(e.runs, req)
+	return &executor.Result{Run: e.result(req)}, nil
+}
+
+func (e *fakeExecutor) Execute(ctx context.Context, req executor.ExecuteRequest) (*executor.Result, error) {
+	e.compiles = append(e.compiles, req.CompileRequest)
+	e.runs = append(e.runs, req.RunRequest)
+	return &executor.Result{Compile: e.compile, Run: e.result(req.RunRequest)}, nil
+}
+
+func (e *fakeExecutor) result(req executor.RunRequest) executor.StageResult {
+	if e.runFor != nil {
+		return e.runFor(req)
+	}
+	return e.run
+}
+
+func (e *fakeExecutor) StagesRunFiles() bool { return e.stagesRunFiles }
+
+// newTestService returns a service that runs on exec and already holds the
+// given programs, so it never reaches MinIO
+func newTestService(t *testing.T, exec executor.Executor, programs map[string]string) *Service {
+	t.Helper()
+
+	saved := defaultExecutor
+	defaultExecutor = func() (executor.Executor, error) { return exec, nil }
+	t.Cleanup(func() { defaultExecutor = saved })
+
+	s := &Service{
+		checkers: make(map[string][]byte),
+		programs: make(map[string][]byte),
+	}
+	for path, code := range programs {
+		s.checkers[path+"\x00"] = []byte(code)
+		s.programs[path] = []byte(code)
+	}
+	s.testlibOnce.Do(func() {}) // built without testlib.h
+	return s
+}
+
+func TestCheckScores(t *testing.T) {
+	tests := []struct {
+		name    string
+		run     executor.StageResult
+		verdict string
+		score   float64
+		message string
+	}{
+		{name: "ok", run: executor.StageResult{Stderr: "ok 5 numbers"}, verdict: VerdictOK, score: 1, message: "5 numbers"},
+		{name: "points", run: executor.StageResult{Code: 7, Stderr: "points 0.4 two of five"}, verdict: VerdictPartially, score: 0.4, message: "two of five"},
+		{name: "partially correct", run: executor.StageResult{Code: 16 + 75, Stderr: "partially correct (75)"}, verdict: VerdictPartially, score: 0.75, message: "(75)"},
+		{name: "wrong answer", run: executor.StageResult{Code: 1, Stderr: "wrong answer 3rd numbers differ"}, verdict: VerdictWrongAnswer, message: "3rd numbers differ"},
+		{name: "fail", run: executor.StageResult{Code: 3, Stderr: "FAIL answer file is broken"}, verdict: VerdictFail, message: "answer file is broken"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService(t, &fakeExecutor{run: tt.run}, map[string]string{"checker.cpp": "int main() {}"})
+
+			resp, err := s.Check(context.Background(), CheckRequest{CheckerPath: "checker.cpp"})
+			require.NoError(t, err)
+			assert.Empty(t, resp.Error)
+			assert.Equal(t, tt.verdict, resp.Verdict)
+			assert.InDelta(t, tt.score, resp.Score, 1e-9)
+			assert.Equal(t, tt.message, resp.Message)
+			assert.Equal(t, tt.verdict == VerdictOK, resp.Accepted)
+		})
+	}
+}
+
+func TestCheckUnknownExitCode(t *testing.T) {
+	s := newTestService(t, &fakeExecutor{run: executor.StageResult{Code: 5, Stderr: "crashed"}}, map[string]string{"checker.cpp": "int main() {}"})
+
+	resp, err := s.Check(context.Background(), CheckRequest{CheckerPath: "checker.cpp"})
+	require.NoError(t, err)
+	assert.Contains(t, resp.Error, "exit code 5")
+	assert.False(t, resp.Accepted)
+}
+
+func TestCheckHandsTestOverAsFiles(t *testing.T) {
+	// Data that broke the raw string literals checkers used to embed
+	output := "1 2\n)CHECKER_DELIM\"\n\x00\xff"
+	exec := &fakeExecutor{stagesRunFiles: true, run: executor.StageResult{Stderr: "ok"}}
+	s := newTestService(t, exec, map[string]string{"checker.cpp": "int main() {}"})
+
+	_, err := s.Check(context.Background(), CheckRequest{CheckerPath: "checker.cpp", Input: "2\n", Output: output, Expected: "1 2\n"})
+	require.NoError(t, err)
+
+	require.Len(t, exec.runs, 1)
+	run := exec.runs[0]
+	assert.Equal(t, []string{"input", "output", "expected"}, run.Args)
+	assert.Equal(t, []executor.File{
+		{Name: "input", Content: "2\n"},
+		{Name: "output", Content: output},
+		{Name: "expected", Content: "1 2\n"},
+	}, run.Files)
+	assert.Empty(t, run.Stdin)
+
+	require.Len(t, exec.compiles, 1)
+	for _, file := range exec.compiles[0].Files {
+		assert.NotContains(t, file.Content, "CHECKER_DELIM", "test data is not compiled into the checker")
+	}
+}
+
+func TestCheckPacksTestOnStdinForPiston(t *testing.T) {
+	exec := &fakeExecutor{run: executor.StageResult{Stderr: "ok"}}
+	s := newTestService(t, exec, map[string]string{"checker.cpp": "int main() {}"})
+
+	_, err := s.Check(context.Background(), CheckRequest{CheckerPath: "checker.cpp", Input: "2\n", Output: "1 2\n", Expected: "1 2"})
+	require.NoError(t, err)
+
+	require.Len(t, exec.runs, 1)
+	assert.Equal(t, "2 4 3\n2\n1 2\n1 2", exec.runs[0].Stdin)
+	assert.Empty(t, exec.runs[0].Files)
+
+	require.Len(t, exec.compiles, 1)
+	require.Len(t, exec.compiles[0].Files, 1, "Piston compiles every file it is sent")
+	assert.Contains(t, exec.compiles[0].Files[0].Content, "checker_main(4, args)")
+}
@@ -0,0 +1,106 @@
+package checker
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Checker verdict types
+const (
+	VerdictOK                = "ok"
+	VerdictWrongAnswer       = "wa"
+	VerdictPresentationError = "pe"
+	VerdictFail              = "fail" // the checker itself is broken
+	VerdictDirt              = "dirt" // extra data after a correct answer
+	VerdictPartially         = "partially"
+)
+
+// Testlib exit codes
+const (
+	testlibExitOK                = 0
+	testlibExitWrongAnswer       = 1
+	testlibExitPresentationError = 2
+	testlibExitFail              = 3
+	testlibExitDirt              = 4
+	testlibExitPoints            = 7
+	testlibExitUnexpectedEOF     = 8
+	testlibExitPartially         = 16 // _pc(k) exits with PC_BASE_EXIT_CODE + k
+)
+
+// testlibPCBaseDefine moves _pc(k) exit codes above testlib's own, since
+// PC_BASE_EXIT_CODE defaults to 0 and _pc(1) would read as wrong answer.
+// It precedes the program's source, ahead of its #include "testlib.h".
+var testlibPCBaseDefine = "#define PC_BASE_EXIT_CODE " + strconv.Itoa(testlibExitPartially) + "\n"
+
+// testlibPrefixes are the verdict names testlib prints before the comment,
+// longest first so "wrong output format" is not taken for a shorter prefix
+var testlibPrefixes = []string{
+	"partially correct",
+	"wrong output format",
+	"wrong answer",
+	"points",
+	"FAIL",
+	"ok",
+}
+
+// parseCheckerResult interprets a testlib checker's exit code and stderr.
+// Score is the fraction of the test's points earned: 1 for ok, 0 for wa, pe,
+// dirt and fail. An unexpected end of the output is a presentation error.
+// quitp(x) reports x as the fraction (clamped to [0, 1]) and _pc(k)
+// reports k percent. ok is false for exit codes testlib does not produce.
+func parseCheckerResult(exitCode int, stderr string) (verdict string, score float64, message string, ok bool) {
+	message = checkerComment(stderr)
+
+	switch {
+	case exitCode == testlibExitOK:
+		return VerdictOK, 1, message, true
+	case exitCode == testlibExitWrongAnswer:
+		return VerdictWrongAnswer, 0, message, true
+	case exitCode == testlibExitPresentationError, exitCode == testlibExitUnexpectedEOF:
+		return VerdictPresentationError, 0, message, true
+	case exitCode == testlibExitFail:
+		return VerdictFail, 0, message, true
+	case exitCode == testlibExitDirt:
+		return VerdictDirt, 0, message, true
+	case exitCode == testlibExitPoints:
+		points, rest := leadingNumber(message)
+		return VerdictPartially, clampScore(points), rest, true
+	case exitCode >= testlibExitPartially && exitCode <= testlibExitPartially+100:
+		return VerdictPartially, float64(exitCode-testlibExitPartially) / 100, message, true
+	default:
+		return "", 0, message, false
+	}
+}
+
+// checkerComment returns the checker's message without testlib's verdict name
+func checkerComment(stderr string) string {
+	comment := strings.TrimSpace(stderr)
+	for _, prefix := range testlibPrefixes {
+		if strings.HasPrefix(comment, prefix) {
+			return strings.TrimSpace(strings.TrimPrefix(comment, prefix))
+		}
+	}
+	return comment
+}
+
+// leadingNumber splits "0.5 rest of message" into the number and the rest
+func leadingNumber(s string) (float64, string) {
+	fields := strings.SplitN(s, " ", 2)
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, s
+	}
+	if len(fields) == 1 {
+		return value, ""
+	}
+	return value, strings.TrimSpace(fields[1])
+}
+
+// clampScore keeps a reported score within [0, 1]
+func clampScore(score float64) float64 {
+	if math.IsNaN(score) {
+		return 0
+	}
+	return math.Min(1, math.Max(0, score))
+}
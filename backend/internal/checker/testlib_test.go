@@ -0,0 +1,58 @@
+package checker
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCheckerResult(t *testing.T) {
+	tests := []struct {
+		name     string
+		exitCode int
+		stderr   string
+		verdict  string
+		score    float64
+		message  string
+		ok       bool
+	}{
+		{name: "ok", exitCode: 0, stderr: "ok 3 numbers", verdict: VerdictOK, score: 1, message: "3 numbers", ok: true},
+		{name: "wrong answer", exitCode: 1, stderr: "wrong answer expected 3, found 4", verdict: VerdictWrongAnswer, message: "expected 3, found 4", ok: true},
+		{name: "presentation error", exitCode: 2, stderr: "wrong output format Unexpected end of file", verdict: VerdictPresentationError, message: "Unexpected end of file", ok: true},
+		{name: "fail", exitCode: 3, stderr: "FAIL answer is missing", verdict: VerdictFail, message: "answer is missing", ok: true},
+		{name: "dirt", exitCode: 4, stderr: "wrong output format Extra information in the output file", verdict: VerdictDirt, message: "Extra information in the output file", ok: true},
+		{name: "points", exitCode: 7, stderr: "points 0.25 one of four", verdict: VerdictPartially, score: 0.25, message: "one of four", ok: true},
+		{name: "points without message", exitCode: 7, stderr: "points 0.5", verdict: VerdictPartially, score: 0.5, ok: true},
+		{name: "points above one", exitCode: 7, stderr: "points 7", verdict: VerdictPartially, score: 1, ok: true},
+		{name: "points below zero", exitCode: 7, stderr: "points -1", verdict: VerdictPartially, score: 0, ok: true},
+		{name: "points not a number", exitCode: 7, stderr: "points half", verdict: VerdictPartially, score: 0, message: "half", ok: true},
+		{name: "unexpected eof", exitCode: 8, stderr: "wrong output format Unexpected end of file", verdict: VerdictPresentationError, message: "Unexpected end of file", ok: true},
+		{name: "partially zero", exitCode: 16, stderr: "partially correct", verdict: VerdictPartially, score: 0, ok: true},
+		{name: "partially 1", exitCode: 17, stderr: "partially correct (1) close", verdict: VerdictPartially, score: 0.01, message: "(1) close", ok: true},
+		{name: "partially 50", exitCode: 66, stderr: "partially correct (50)", verdict: VerdictPartially, score: 0.5, message: "(50)", ok: true},
+		{name: "partially 100", exitCode: 116, stderr: "partially correct (100)", verdict: VerdictPartially, score: 1, message: "(100)", ok: true},
+		{name: "above partially range", exitCode: 117, stderr: "", ok: false},
+		{name: "unknown code", exitCode: 5, stderr: "crashed", message: "crashed", ok: false},
+		{name: "negative code", exitCode: -1, stderr: "", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verdict, score, message, ok := parseCheckerResult(tt.exitCode, tt.stderr)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.verdict, verdict)
+			assert.InDelta(t, tt.score, score, 1e-9)
+			assert.Equal(t, tt.message, message)
+		})
+	}
+}
+
+func TestTestlibSourcesSetPCBase(t *testing.T) {
+	files := testlibSources([]byte("#include \"testlib.h\"\nint main() {}\n"), []byte("// testlib"))
+
+	if assert.Len(t, files, 2) {
+		assert.True(t, strings.HasPrefix(files[0].Content, "#define PC_BASE_EXIT_CODE 16\n"))
+		assert.Equal(t, "testlib.h", files[1].Name)
+	}
+}
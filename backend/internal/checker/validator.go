@@ -0,0 +1,108 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+
+	"codehustle/backend/internal/executor"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Limits for a single validator run
+const (
+	validatorTimeLimitMs   = 10000
+	validatorMemoryLimitKb = 256 * 1024
+)
+
+// ValidateRequest describes test inputs to check with a testlib validator
+type ValidateRequest struct {
+	ValidatorPath string
+	Args          []string // e.g. --group or --testset
+	RuntimeImage  string
+	Version       string
+	Inputs        []ValidationInput
+}
+
+// ValidationInput is one test input, named for the report
+type ValidationInput struct {
+	Name    string
+	Content string
+}
+
+// ValidationResult is the validator's verdict on one input
+type ValidationResult struct {
+	Name    string `json:"name"`
+	Valid   bool   `json:"valid"`
+	Message string `json:"message,omitempty"`
+}
+
+// Validate builds the validator once and runs it on every input. As testlib
+// validators expect, the input is passed on stdin and any non-zero exit
+// means it is invalid. An error is returned only if the validator itself
+// cannot be fetched, built or run.
+func (s *Service) Validate(ctx context.Context, req ValidateRequest) ([]ValidationResult, error) {
+	validatorBytes, err := s.fetchProgram(req.ValidatorPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch validator from bucket '%s' at path '%s': %w", s.bucket, req.ValidatorPath, err)
+	}
+	testlibBytes := s.testlib()
+
+	exec, err := defaultExecutor()
+	if err != nil {
+		return nil, fmt.Errorf("executor unavailable: %w", err)
+	}
+
+	compile := executor.CompileRequest{
+		Language: "cpp",
+		Version:  s.mapVersionToPiston(req.Version, req.RuntimeImage),
+		Files:    programSources(exec, validatorBytes, testlibBytes),
+	}
+
+	validator, err := s.buildProgram(ctx, exec, compile, validatorBytes, testlibBytes)
+	if err != nil {
+		return nil, fmt.Errorf("validator build error: %w", err)
+	}
+	defer validator.Close()
+
+	if validator.CompileFailed() {
+		return nil, fmt.Errorf("validator compilation failed with exit code %d: %s", validator.Compile.Code, validator.CompileLog())
+	}
+
+	results := make([]ValidationResult, 0, len(req.Inputs))
+	for _, input := range req.Inputs {
+		result, err := exec.Run(ctx, validator, executor.RunRequest{
+			Stdin:         input.Content,
+			Args:          req.Args,
+			TimeLimitMs:   validatorTimeLimitMs,
+			MemoryLimitKb: validatorMemoryLimitKb,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("validator run error on %s: %w", input.Name, err)
+		}
+		if validator.Deferred && result.Compile.CompileFailed() {
+			return nil, fmt.Errorf("validator compilation failed with exit code %d: %s", result.Compile.Code, result.Compile.Stderr)
+		}
+
+		verdict := ValidationResult{Name: input.Name, Valid: !result.Run.Failed()}
+		switch {
+		case result.Run.TimedOut(validatorTimeLimitMs):
+			return nil, fmt.Errorf("validator timed out on %s", input.Name)
+		case result.Run.Signal != "":
+			return nil, fmt.Errorf("validator killed by %s on %s", result.Run.Signal, input.Name)
+		case !verdict.Valid:
+			verdict.Message = checkerComment(result.Run.Stderr)
+			if verdict.Message == "" {
+				verdict.Message = fmt.Sprintf("validator exited with code %d", result.Run.Code)
+			}
+		}
+		results = append(results, verdict)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"validator_path": req.ValidatorPath,
+		"inputs":         len(req.Inputs),
+	}).Debug("Validated test inputs")
+
+	return results, nil
+}
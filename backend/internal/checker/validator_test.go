@@ -0,0 +1,73 @@
+package checker
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"codehustle/backend/internal/executor"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	exec := &fakeExecutor{stagesRunFiles: true, runFor: func(req executor.RunRequest) executor.StageResult {
+		switch {
+		case strings.HasPrefix(req.Stdin, "0"):
+			return executor.StageResult{Code: 3, Stderr: "FAIL Integer parameter [name=n] equals to 0, violates the range [1, 100]"}
+		case strings.HasPrefix(req.Stdin, "x"):
+			return executor.StageResult{Code: 1}
+		}
+		return executor.StageResult{}
+	}}
+	s := newTestService(t, exec, map[string]string{"validator.cpp": "int main() {}"})
+
+	results, err := s.Validate(context.Background(), ValidateRequest{
+		ValidatorPath: "validator.cpp",
+		Args:          []string{"--group", "1"},
+		Inputs: []ValidationInput{
+			{Name: "01.in", Content: "5\n"},
+			{Name: "02.in", Content: "0\n"},
+			{Name: "03.in", Content: "x\n"},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []ValidationResult{
+		{Name: "01.in", Valid: true},
+		{Name: "02.in", Valid: false, Message: "Integer parameter [name=n] equals to 0, violates the range [1, 100]"},
+		{Name: "03.in", Valid: false, Message: "validator exited with code 1"},
+	}, results)
+
+	assert.Len(t, exec.compiles, 1, "the validator is built once")
+	require.Len(t, exec.runs, 3)
+	for _, run := range exec.runs {
+		assert.Equal(t, []string{"--group", "1"}, run.Args)
+	}
+	assert.Equal(t, "0\n", exec.runs[1].Stdin)
+}
+
+func TestValidateFailures(t *testing.T) {
+	inputs := []ValidationInput{{Name: "01.in", Content: "5\n"}}
+
+	tests := []struct {
+		name string
+		exec *fakeExecutor
+		err  string
+	}{
+		{name: "compile error", exec: &fakeExecutor{compile: executor.StageResult{Code: 1, Stderr: "expected ';'"}}, err: "validator compilation failed"},
+		{name: "timeout", exec: &fakeExecutor{run: executor.StageResult{Status: executor.StatusTimeout}}, err: "validator timed out on 01.in"},
+		{name: "killed", exec: &fakeExecutor{run: executor.StageResult{Signal: "SIGSEGV"}}, err: "validator killed by SIGSEGV on 01.in"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService(t, tt.exec, map[string]string{"validator.cpp": "int main() {}"})
+
+			_, err := s.Validate(context.Background(), ValidateRequest{ValidatorPath: "validator.cpp", Inputs: inputs})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
@@ -33,8 +33,31 @@ var defaults = map[string]string{
 	"BUCKET_JUDGE_COMMON":       "judge-common",
 	"BUCKET_CHECKERS_CACHE":     "checkers-cache",
 
-	// Piston (executor)
-	"PISTON_URL": "http://127.0.0.1:3002",
+	// Code execution
+	"JUDGE_EXECUTOR":    "piston", // piston or local
+	"PISTON_URL":        "http://127.0.0.1:3002",
+	"JUDGE_SANDBOX_DIR": "/var/lib/codehustle/sandbox",
+	"JUDGE_CGROUP_ROOT": "/sys/fs/cgroup/codehustle-judge",
+
+	// Languages: a JSON file overriding or adding to the built-in ones,
+	// whether versions come from Piston (piston or static, default follows
+	// JUDGE_EXECUTOR) and how often the versions installed in Piston are
+	// looked up
+	"LANGUAGES_FILE":          "",
+	"LANGUAGES_SOURCE":        "",
+	"LANGUAGES_SYNC_INTERVAL": "10m",
+
+	// Compiled checkers, shared through BUCKET_CHECKERS_CACHE
+	"JUDGE_CHECKER_CACHE_DIR": "/var/lib/codehustle/checkers",
+
+	// Judge worker
+	"JUDGE_WORKER_CONCURRENCY": "2", // submissions judged at the same time per worker process
+	"JUDGE_TEST_PARALLELISM":   "4", // test cases of one submission judged at the same time
+	"JUDGE_CONSUMER_NAME":      "",  // defaults to POD_NAME or the hostname
+	"JUDGE_RECLAIM_INTERVAL":   "30s",
+	"JUDGE_RECLAIM_MIN_IDLE":   "10m", // pending jobs idle this long are taken over
+	"JUDGE_MAX_ATTEMPTS":       "3",   // deliveries before a job is dead-lettered
+	"JUDGE_SHUTDOWN_GRACE":     "60s", // time in-flight jobs get to finish on shutdown
 
 	// OAuth
 	"GOOGLE_CLIENT_ID":     "",
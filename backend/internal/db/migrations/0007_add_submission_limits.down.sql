@@ -0,0 +1,4 @@
+-- Remove judged limits from submissions
+
+ALTER TABLE submissions DROP COLUMN IF EXISTS memory_limit_kb;
+ALTER TABLE submissions DROP COLUMN IF EXISTS time_limit_ms;
@@ -0,0 +1,4 @@
+-- Record the effective limits a submission was judged with (contest overrides or problem defaults)
+
+ALTER TABLE submissions ADD COLUMN time_limit_ms INT NULL COMMENT 'Time limit applied when judging (ms)';
+ALTER TABLE submissions ADD COLUMN memory_limit_kb INT NULL COMMENT 'Memory limit applied when judging (KB)';
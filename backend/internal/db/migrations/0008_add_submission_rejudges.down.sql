@@ -0,0 +1,3 @@
+-- Remove rejudge audit history
+
+DROP TABLE IF EXISTS submission_rejudges;
@@ -0,0 +1,19 @@
+-- Keep the verdict a submission had before each rejudge so changes can be audited
+
+CREATE TABLE IF NOT EXISTS submission_rejudges (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    submission_id CHAR(36) NOT NULL,
+    rejudged_by CHAR(36) NULL COMMENT 'User who requested the rejudge',
+    previous_status VARCHAR(50) NOT NULL,
+    previous_score INT NULL,
+    previous_execution_time INT NULL,
+    previous_memory_usage INT NULL,
+    new_status VARCHAR(50) NULL COMMENT 'Filled in by the judge worker once the rejudge finishes',
+    new_score INT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at DATETIME NULL,
+    FOREIGN KEY (submission_id) REFERENCES submissions(id) ON DELETE CASCADE,
+    FOREIGN KEY (rejudged_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_submission_rejudges_submission ON submission_rejudges(submission_id, created_at);
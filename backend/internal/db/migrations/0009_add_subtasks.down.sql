@@ -0,0 +1,6 @@
+-- Remove subtasks
+
+DROP TABLE IF EXISTS submission_subtasks;
+DROP TABLE IF EXISTS subtask_dependencies;
+DROP TABLE IF EXISTS subtask_test_cases;
+DROP TABLE IF EXISTS subtasks;
@@ -0,0 +1,44 @@
+-- Subtasks group the test cases of a problem and score each group as a whole
+
+CREATE TABLE IF NOT EXISTS subtasks (
+    id CHAR(36) PRIMARY KEY,
+    problem_id CHAR(36) NOT NULL,
+    name VARCHAR(200) NOT NULL,
+    ordinal INT NOT NULL,
+    points INT NOT NULL DEFAULT 0,
+    scoring VARCHAR(20) NOT NULL DEFAULT 'sum' COMMENT 'sum, min or all (all-or-nothing)',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_subtask_ordinal (problem_id, ordinal),
+    FOREIGN KEY (problem_id) REFERENCES problems(id) ON DELETE CASCADE
+);
+
+-- A test case can belong to several subtasks
+CREATE TABLE IF NOT EXISTS subtask_test_cases (
+    subtask_id CHAR(36) NOT NULL,
+    test_case_id CHAR(36) NOT NULL,
+    PRIMARY KEY (subtask_id, test_case_id),
+    FOREIGN KEY (subtask_id) REFERENCES subtasks(id) ON DELETE CASCADE,
+    FOREIGN KEY (test_case_id) REFERENCES test_cases(id) ON DELETE CASCADE
+);
+
+-- A subtask only scores when every subtask it depends on is fully solved
+CREATE TABLE IF NOT EXISTS subtask_dependencies (
+    subtask_id CHAR(36) NOT NULL,
+    depends_on_id CHAR(36) NOT NULL,
+    PRIMARY KEY (subtask_id, depends_on_id),
+    FOREIGN KEY (subtask_id) REFERENCES subtasks(id) ON DELETE CASCADE,
+    FOREIGN KEY (depends_on_id) REFERENCES subtasks(id) ON DELETE CASCADE
+);
+
+-- Per-subtask results of a submission
+CREATE TABLE IF NOT EXISTS submission_subtasks (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    submission_id CHAR(36) NOT NULL,
+    subtask_id CHAR(36) NOT NULL,
+    status VARCHAR(50) NOT NULL COMMENT 'accepted, partial, failed or skipped',
+    score INT NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_submission_subtask (submission_id, subtask_id),
+    FOREIGN KEY (submission_id) REFERENCES submissions(id) ON DELETE CASCADE,
+    FOREIGN KEY (subtask_id) REFERENCES subtasks(id) ON DELETE CASCADE
+);
@@ -0,0 +1,3 @@
+-- Remove checker comments from test case results
+
+ALTER TABLE submission_testcases DROP COLUMN IF EXISTS checker_message;
@@ -0,0 +1,3 @@
+-- Keep the custom checker's comment for each test case result
+
+ALTER TABLE submission_testcases ADD COLUMN checker_message TEXT NULL COMMENT 'Comment printed by the checker (e.g. reason for partial credit)';
@@ -0,0 +1,8 @@
+-- Remove interactive judging settings
+
+ALTER TABLE problem_judges DROP COLUMN IF EXISTS interactor_memory_limit_kb;
+ALTER TABLE problem_judges DROP COLUMN IF EXISTS interactor_time_limit_ms;
+ALTER TABLE problem_judges DROP COLUMN IF EXISTS interactor_version;
+ALTER TABLE problem_judges DROP COLUMN IF EXISTS interactor_runtime_image;
+ALTER TABLE problem_judges DROP COLUMN IF EXISTS interactor_path;
+ALTER TABLE problem_judges DROP COLUMN IF EXISTS judge_mode;
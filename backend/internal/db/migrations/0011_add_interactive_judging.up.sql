@@ -0,0 +1,9 @@
+-- Interactive problems: the submission talks to an interactor over pipes and
+-- the interactor's testlib exit code decides the verdict
+
+ALTER TABLE problem_judges ADD COLUMN judge_mode VARCHAR(20) NOT NULL DEFAULT 'standard' COMMENT 'standard or interactive';
+ALTER TABLE problem_judges ADD COLUMN interactor_path TEXT NULL COMMENT 'MinIO key for the interactor source';
+ALTER TABLE problem_judges ADD COLUMN interactor_runtime_image VARCHAR(200) NULL;
+ALTER TABLE problem_judges ADD COLUMN interactor_version VARCHAR(50) NULL;
+ALTER TABLE problem_judges ADD COLUMN interactor_time_limit_ms INT NULL COMMENT 'Interactor time limit (null means twice the problem time limit)';
+ALTER TABLE problem_judges ADD COLUMN interactor_memory_limit_kb INT NULL COMMENT 'Interactor memory limit (null means use problem memory limit)';
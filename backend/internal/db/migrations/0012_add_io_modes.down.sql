@@ -0,0 +1,6 @@
+-- Remove problem I/O modes
+
+ALTER TABLE submissions DROP COLUMN IF EXISTS answers_path;
+ALTER TABLE problem_judges DROP COLUMN IF EXISTS output_file;
+ALTER TABLE problem_judges DROP COLUMN IF EXISTS input_file;
+ALTER TABLE problem_judges DROP COLUMN IF EXISTS io_mode;
@@ -0,0 +1,7 @@
+-- Problem I/O modes: standard input and output, named files in the working
+-- directory, or output-only problems where a zip of answers is submitted
+
+ALTER TABLE problem_judges ADD COLUMN io_mode VARCHAR(20) NOT NULL DEFAULT 'stdio' COMMENT 'stdio, file or output_only';
+ALTER TABLE problem_judges ADD COLUMN input_file VARCHAR(100) NULL COMMENT 'Input file name in file mode (null means input.txt)';
+ALTER TABLE problem_judges ADD COLUMN output_file VARCHAR(100) NULL COMMENT 'Output file name in file mode (null means output.txt)';
+ALTER TABLE submissions ADD COLUMN answers_path TEXT NULL COMMENT 'MinIO key of the answers zip of an output-only submission';
@@ -0,0 +1,4 @@
+-- Remove multi-file submissions and grader files
+
+ALTER TABLE submissions DROP COLUMN IF EXISTS sources_path;
+ALTER TABLE problem_judges DROP COLUMN IF EXISTS grader_files;
@@ -0,0 +1,4 @@
+-- Multi-file submissions and grader files for function-implementation tasks
+
+ALTER TABLE problem_judges ADD COLUMN grader_files JSON NULL COMMENT 'MinIO keys of grader files per language, e.g. {"cpp": ["problems/1/grader.cpp"]}';
+ALTER TABLE submissions ADD COLUMN sources_path TEXT NULL COMMENT 'MinIO key of the sources zip of a multi-file submission';
@@ -0,0 +1,4 @@
+-- Remove contest scoreboard cells
+
+ALTER TABLE contests MODIFY COLUMN rule_type VARCHAR(50) DEFAULT 'OI' NOT NULL COMMENT 'Contest scoring rule: OI (Olympiad in Informatics)';
+DROP TABLE IF EXISTS contest_scores;
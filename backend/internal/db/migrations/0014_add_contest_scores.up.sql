@@ -0,0 +1,21 @@
+-- Per-problem scoreboard cells, updated by the judge worker on each verdict
+
+CREATE TABLE IF NOT EXISTS contest_scores (
+    contest_id CHAR(36) NOT NULL,
+    user_id CHAR(36) NOT NULL,
+    problem_id CHAR(36) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0 COMMENT 'ICPC tries up to the first accepted one, compile errors excluded',
+    pending INT NOT NULL DEFAULT 0 COMMENT 'Submissions not judged yet',
+    solved BOOLEAN NOT NULL DEFAULT FALSE,
+    solved_at DATETIME NULL COMMENT 'Submission time of the first accepted submission',
+    penalty INT NOT NULL DEFAULT 0 COMMENT 'ICPC penalty minutes: time to solve plus 20 per rejected try',
+    best_score INT NOT NULL DEFAULT 0 COMMENT 'Highest score, used by IOI rules',
+    last_score INT NOT NULL DEFAULT 0 COMMENT 'Score of the last judged submission, used by OI rules',
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (contest_id, user_id, problem_id),
+    FOREIGN KEY (contest_id) REFERENCES contests(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (problem_id) REFERENCES problems(id) ON DELETE CASCADE
+);
+
+ALTER TABLE contests MODIFY COLUMN rule_type VARCHAR(50) DEFAULT 'OI' NOT NULL COMMENT 'Contest scoring rule: OI (last score), IOI (best score) or ICPC';
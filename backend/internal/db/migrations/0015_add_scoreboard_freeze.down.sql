@@ -0,0 +1,12 @@
+-- Remove scoreboard freeze
+
+ALTER TABLE contest_scores DROP COLUMN IF EXISTS revealed;
+ALTER TABLE contest_scores DROP COLUMN IF EXISTS frozen_last_score;
+ALTER TABLE contest_scores DROP COLUMN IF EXISTS frozen_best_score;
+ALTER TABLE contest_scores DROP COLUMN IF EXISTS frozen_penalty;
+ALTER TABLE contest_scores DROP COLUMN IF EXISTS frozen_solved_at;
+ALTER TABLE contest_scores DROP COLUMN IF EXISTS frozen_solved;
+ALTER TABLE contest_scores DROP COLUMN IF EXISTS frozen_pending;
+ALTER TABLE contest_scores DROP COLUMN IF EXISTS frozen_attempts;
+ALTER TABLE contests DROP COLUMN IF EXISTS unfrozen_at;
+ALTER TABLE contests DROP COLUMN IF EXISTS freeze_at;
@@ -0,0 +1,14 @@
+-- Scoreboard freeze: results submitted after freeze_at stay pending on the
+-- public scoreboard until admins reveal them
+
+ALTER TABLE contests ADD COLUMN freeze_at DATETIME NULL COMMENT 'Public scoreboard shows later submissions as pending (null means never frozen)';
+ALTER TABLE contests ADD COLUMN unfrozen_at DATETIME NULL COMMENT 'Set once every frozen result has been revealed';
+
+ALTER TABLE contest_scores ADD COLUMN frozen_attempts INT NOT NULL DEFAULT 0 COMMENT 'Result shown while frozen, from submissions before the freeze';
+ALTER TABLE contest_scores ADD COLUMN frozen_pending INT NOT NULL DEFAULT 0 COMMENT 'Includes every submission after the freeze';
+ALTER TABLE contest_scores ADD COLUMN frozen_solved BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE contest_scores ADD COLUMN frozen_solved_at DATETIME NULL;
+ALTER TABLE contest_scores ADD COLUMN frozen_penalty INT NOT NULL DEFAULT 0;
+ALTER TABLE contest_scores ADD COLUMN frozen_best_score INT NOT NULL DEFAULT 0;
+ALTER TABLE contest_scores ADD COLUMN frozen_last_score INT NOT NULL DEFAULT 0;
+ALTER TABLE contest_scores ADD COLUMN revealed BOOLEAN NOT NULL DEFAULT FALSE COMMENT 'Full result shown on the frozen scoreboard';
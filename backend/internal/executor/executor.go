@@ -0,0 +1,230 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"codehustle/backend/internal/config"
+)
+
+// Executor compiles and runs programs in an isolated environment
+type Executor interface {
+	// Compile prepares sources for repeated runs. A compile error is reported
+	// through the artifact's Compile stage, not as an error, unless the
+	// artifact is Deferred: then the sources are compiled by every run and
+	// the compile error arrives with the first one.
+	Compile(ctx context.Context, req CompileRequest) (*Artifact, error)
+	// Run executes a compiled artifact against a single input
+	Run(ctx context.Context, artifact *Artifact, req RunRequest) (*Result, error)
+	// Execute compiles and runs sources in one step
+	Execute(ctx context.Context, req ExecuteRequest) (*Result, error)
+}
+
+// BinaryArtifacts is implemented by executors whose compiled programs can be
+// saved and restored later, possibly by another worker. Piston keeps no build
+// outputs, so it cannot.
+type BinaryArtifacts interface {
+	// CompilerVersion identifies the compiler used for a language, so that
+	// cached binaries are not reused after a compiler upgrade
+	CompilerVersion(ctx context.Context, language string) (string, error)
+	// ExportBinary returns the compiled program of an artifact
+	ExportBinary(artifact *Artifact) ([]byte, error)
+	// ImportBinary turns a previously exported program back into an artifact
+	ImportBinary(language, version string, binary []byte) (*Artifact, error)
+}
+
+// RunFileStager is implemented by executors that can hand RunRequest.Files
+// to the program and collect RunRequest.OutputFiles afterwards. Piston
+// compiles every file it is sent and returns no files, so it cannot.
+type RunFileStager interface {
+	StagesRunFiles() bool
+}
+
+// InteractiveRunner is implemented by executors that can run a program
+// against an interactor, the standard output of each one feeding the
+// standard input of the other. Each side runs under its own limits.
+type InteractiveRunner interface {
+	RunInteractive(ctx context.Context, program *Artifact, programReq RunRequest, interactor *Artifact, interactorReq RunRequest) (*InteractiveResult, error)
+}
+
+// InteractiveResult is the outcome of an interactive run. The Stdout of
+// both stages is empty as it went to the other program.
+type InteractiveResult struct {
+	Program    StageResult
+	Interactor StageResult
+}
+
+// File is a source or data file handed to the program. Content may hold
+// binary data.
+type File struct {
+	Name    string
+	Content string
+}
+
+// CompileRequest describes the sources to compile
+type CompileRequest struct {
+	Language string
+	Version  string
+	Files    []File
+}
+
+// RunRequest describes a single run of a compiled artifact
+type RunRequest struct {
+	Stdin string
+	Args  []string
+	// Files are placed in the program's working directory before it starts,
+	// by executors that implement RunFileStager
+	Files []File
+	// OutputFiles name files to read back from the working directory after
+	// the run, by executors that implement RunFileStager
+	OutputFiles   []string
+	TimeLimitMs   int
+	MemoryLimitKb int
+}
+
+// ExecuteRequest describes a one-shot compile and run
+type ExecuteRequest struct {
+	CompileRequest
+	RunRequest
+}
+
+// Result is the outcome of running a program
+type Result struct {
+	Compile StageResult `json:"compile"`
+	Run     StageResult `json:"run"`
+	// OutputFiles holds the requested output files the program created
+	OutputFiles []File `json:"-"`
+}
+
+// StageResult is the outcome of a single compile or run stage
+type StageResult struct {
+	Code     int    `json:"code"`
+	Stdout   string `json:"stdout"`
+	Stderr   string `json:"stderr"`
+	Memory   int    `json:"memory"`    // bytes
+	Signal   string `json:"signal"`    // e.g. SIGKILL, empty if the process exited normally
+	Status   string `json:"status"`    // RE, SG, TO, OL, EL, XX
+	Message  string `json:"message"`   // human readable status description
+	CPUTime  int    `json:"cpu_time"`  // milliseconds
+	WallTime int    `json:"wall_time"` // milliseconds
+}
+
+// Stage statuses, shared with the Piston API
+const (
+	StatusRuntimeError  = "RE"
+	StatusSignal        = "SG"
+	StatusTimeout       = "TO"
+	StatusOutputLimit   = "OL"
+	StatusErrorLimit    = "EL"
+	StatusInternalError = "XX"
+)
+
+// TimeMs returns the time the stage took, preferring CPU time over wall time
+func (s StageResult) TimeMs() int {
+	if s.CPUTime > 0 {
+		return s.CPUTime
+	}
+	return s.WallTime
+}
+
+// MemoryKb returns the peak memory of the stage in kilobytes
+func (s StageResult) MemoryKb() int {
+	return s.Memory / 1024
+}
+
+// TimedOut reports whether the stage was stopped or ran past the time limit
+func (s StageResult) TimedOut(timeLimitMs int) bool {
+	if s.Status == StatusTimeout {
+		return true
+	}
+	return timeLimitMs > 0 && s.TimeMs() > timeLimitMs
+}
+
+// MemoryExceeded reports whether the stage used more memory than allowed or
+// was killed while at the memory limit
+func (s StageResult) MemoryExceeded(memoryLimitKb int) bool {
+	if memoryLimitKb <= 0 {
+		return false
+	}
+	if s.MemoryKb() > memoryLimitKb {
+		return true
+	}
+	return s.Signal == "SIGKILL" && s.MemoryKb() >= memoryLimitKb*95/100
+}
+
+// Failed reports whether the stage exited abnormally
+func (s StageResult) Failed() bool {
+	return s.Code != 0 || s.Signal != "" || s.Status == StatusRuntimeError || s.Status == StatusSignal
+}
+
+// CompileFailed reports whether a compile stage rejected the sources. A
+// compiler that timed out or was killed fails as well.
+func (s StageResult) CompileFailed() bool {
+	return s.Failed() || s.Status == StatusTimeout
+}
+
+// Artifact is a compiled program that can be run many times
+type Artifact struct {
+	Language string
+	Version  string
+	Files    []File
+	Compile  StageResult
+	// Deferred artifacts are compiled again by every run, so Compile is
+	// empty and each run's result carries the compile stage instead
+	Deferred bool
+
+	// dir holds build outputs for executors that keep them on disk
+	dir string
+}
+
+// CompileFailed reports whether the compile step rejected the sources
+func (a *Artifact) CompileFailed() bool {
+	return a.Compile.CompileFailed()
+}
+
+// CompileLog returns the compiler diagnostics, preferring stderr
+func (a *Artifact) CompileLog() string {
+	if a.Compile.Stderr != "" {
+		return a.Compile.Stderr
+	}
+	return a.Compile.Stdout
+}
+
+// Close removes build outputs kept on disk for the artifact
+func (a *Artifact) Close() error {
+	if a == nil || a.dir == "" {
+		return nil
+	}
+	err := os.RemoveAll(a.dir)
+	a.dir = ""
+	return err
+}
+
+var (
+	defaultExecutor Executor
+	defaultErr      error
+	defaultOnce     sync.Once
+)
+
+// New creates an executor of the given kind ("piston" or "local")
+func New(kind string) (Executor, error) {
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case "", "piston":
+		return NewPistonExecutor(config.Get("PISTON_URL")), nil
+	case "local":
+		return NewLocalExecutor(config.Get("JUDGE_SANDBOX_DIR"), config.Get("JUDGE_CGROUP_ROOT"))
+	default:
+		return nil, fmt.Errorf("unknown executor %q", kind)
+	}
+}
+
+// Default returns the executor selected by JUDGE_EXECUTOR
+func Default() (Executor, error) {
+	defaultOnce.Do(func() {
+		defaultExecutor, defaultErr = New(config.Get("JUDGE_EXECUTOR"))
+	})
+	return defaultExecutor, defaultErr
+}
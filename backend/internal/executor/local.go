@@ -0,0 +1,433 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"codehustle/backend/internal/languages"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	localCompileTimeLimitMs   = 10000
+	localCompileMemoryLimitKb = 1024 * 1024
+	localMaxProcesses         = 64
+	localMaxOutputBytes       = 64 << 20
+	localMaxStderrBytes       = 1 << 20
+	// localBinaryName is the program compiled languages build and run
+	localBinaryName = "main"
+	// sandboxUID and sandboxGID are the unprivileged ids programs run as
+	sandboxUID = 65534
+	sandboxGID = 65534
+)
+
+// LocalExecutor runs programs on the judge host inside a sandbox built from
+// Linux namespaces, a cgroup v2 per run and a seccomp filter
+type LocalExecutor struct {
+	workDir    string
+	cgroupRoot string
+
+	// compilerVersions caches CompilerVersion per language
+	compilerVersions sync.Map
+}
+
+// sandboxRun describes one sandboxed process
+type sandboxRun struct {
+	Args          []string
+	Env           []string
+	Dir           string
+	CgroupRoot    string
+	Stdin         string
+	TimeLimitMs   int
+	MemoryLimitKb int
+
+	// StdinPipe and StdoutPipe connect the process to another one instead of
+	// Stdin and the captured stdout. They are closed once the process starts.
+	StdinPipe  *os.File
+	StdoutPipe *os.File
+}
+
+// NewLocalExecutor creates a sandboxed executor that keeps per-run
+// directories under workDir and per-run cgroups under cgroupRoot
+func NewLocalExecutor(workDir, cgroupRoot string) (*LocalExecutor, error) {
+	if workDir == "" {
+		workDir = "/var/lib/codehustle/sandbox"
+	}
+	if cgroupRoot == "" {
+		cgroupRoot = "/sys/fs/cgroup/codehustle-judge"
+	}
+
+	if err := os.MkdirAll(workDir, 0o711); err != nil {
+		return nil, fmt.Errorf("failed to create sandbox directory: %w", err)
+	}
+	if err := setupCgroupRoot(cgroupRoot); err != nil {
+		return nil, err
+	}
+
+	return &LocalExecutor{
+		workDir:    workDir,
+		cgroupRoot: cgroupRoot,
+	}, nil
+}
+
+// Compile writes the sources to a fresh directory and runs the compiler in
+// the sandbox. Interpreted languages only have their sources staged.
+func (e *LocalExecutor) Compile(ctx context.Context, req CompileRequest) (*Artifact, error) {
+	lang, ok := languages.Get(req.Language)
+	if !ok {
+		return nil, fmt.Errorf("language %q is not supported by the local executor", req.Language)
+	}
+
+	dir, err := os.MkdirTemp(e.workDir, "build-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create build directory: %w", err)
+	}
+
+	artifact := &Artifact{
+		Language: req.Language,
+		Version:  req.Version,
+		Files:    req.Files,
+		dir:      dir,
+	}
+
+	names, err := writeSources(dir, lang, req.Files)
+	if err != nil {
+		artifact.Close()
+		return nil, err
+	}
+
+	if lang.Compile == nil {
+		return artifact, nil
+	}
+
+	stage, err := runSandboxed(ctx, sandboxRun{
+		Args:          lang.CompileCommand(names),
+		Env:           lang.Env,
+		Dir:           dir,
+		CgroupRoot:    e.cgroupRoot,
+		TimeLimitMs:   localCompileTimeLimitMs,
+		MemoryLimitKb: localCompileMemoryLimitKb,
+	})
+	if err != nil {
+		artifact.Close()
+		return nil, fmt.Errorf("failed to run compiler: %w", err)
+	}
+	artifact.Compile = *stage
+
+	logrus.WithFields(logrus.Fields{
+		"language":     req.Language,
+		"compile_code": stage.Code,
+		"cpu_time_ms":  stage.CPUTime,
+		"memory_bytes": stage.Memory,
+	}).Debug("Local compile completed")
+
+	return artifact, nil
+}
+
+// Run executes the artifact in a fresh copy of its build directory so that
+// one run cannot leave files behind for the next
+func (e *LocalExecutor) Run(ctx context.Context, artifact *Artifact, req RunRequest) (*Result, error) {
+	run, cleanup, err := e.prepareRun(artifact, req)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	stage, err := runSandboxed(ctx, run)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run program: %w", err)
+	}
+
+	outputFiles, err := readOutputFiles(run.Dir, req.OutputFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Result{Compile: artifact.Compile, Run: *stage, OutputFiles: outputFiles}, nil
+}
+
+// RunInteractive runs the program and the interactor at the same time, each
+// in its own sandbox, with a pipe in each direction between them
+func (e *LocalExecutor) RunInteractive(ctx context.Context, program *Artifact, programReq RunRequest, interactor *Artifact, interactorReq RunRequest) (*InteractiveResult, error) {
+	programRun, cleanupProgram, err := e.prepareRun(program, programReq)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanupProgram()
+
+	interactorRun, cleanupInteractor, err := e.prepareRun(interactor, interactorReq)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanupInteractor()
+
+	toInteractorR, toInteractorW, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	toProgramR, toProgramW, err := os.Pipe()
+	if err != nil {
+		toInteractorR.Close()
+		toInteractorW.Close()
+		return nil, err
+	}
+	programRun.StdinPipe, programRun.StdoutPipe = toProgramR, toInteractorW
+	interactorRun.StdinPipe, interactorRun.StdoutPipe = toInteractorR, toProgramW
+
+	var (
+		wg                            sync.WaitGroup
+		programStage, interactorStage *StageResult
+		programErr, interactorErr     error
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		programStage, programErr = runSandboxed(ctx, programRun)
+	}()
+	go func() {
+		defer wg.Done()
+		interactorStage, interactorErr = runSandboxed(ctx, interactorRun)
+	}()
+	wg.Wait()
+
+	if programErr != nil {
+		return nil, fmt.Errorf("failed to run program: %w", programErr)
+	}
+	if interactorErr != nil {
+		return nil, fmt.Errorf("failed to run interactor: %w", interactorErr)
+	}
+
+	return &InteractiveResult{Program: *programStage, Interactor: *interactorStage}, nil
+}
+
+// prepareRun copies the artifact into a fresh run directory together with
+// the run files. cleanup removes the directory.
+func (e *LocalExecutor) prepareRun(artifact *Artifact, req RunRequest) (run sandboxRun, cleanup func(), err error) {
+	if artifact.dir == "" {
+		return run, nil, fmt.Errorf("artifact was not built by the local executor")
+	}
+	lang, ok := languages.Get(artifact.Language)
+	if !ok {
+		return run, nil, fmt.Errorf("language %q is not supported by the local executor", artifact.Language)
+	}
+
+	runDir, err := os.MkdirTemp(e.workDir, "run-")
+	if err != nil {
+		return run, nil, fmt.Errorf("failed to create run directory: %w", err)
+	}
+	cleanup = func() { os.RemoveAll(runDir) }
+
+	if err := copyDir(artifact.dir, runDir); err != nil {
+		cleanup()
+		return run, nil, fmt.Errorf("failed to prepare run directory: %w", err)
+	}
+	if err := writeFiles(runDir, req.Files); err != nil {
+		cleanup()
+		return run, nil, fmt.Errorf("failed to prepare run directory: %w", err)
+	}
+
+	return sandboxRun{
+		Args:          append(append([]string{}, lang.Run...), req.Args...),
+		Env:           lang.Env,
+		Dir:           runDir,
+		CgroupRoot:    e.cgroupRoot,
+		Stdin:         req.Stdin,
+		TimeLimitMs:   req.TimeLimitMs,
+		MemoryLimitKb: req.MemoryLimitKb,
+	}, cleanup, nil
+}
+
+// Execute compiles and runs sources, discarding the build afterwards
+func (e *LocalExecutor) Execute(ctx context.Context, req ExecuteRequest) (*Result, error) {
+	artifact, err := e.Compile(ctx, req.CompileRequest)
+	if err != nil {
+		return nil, err
+	}
+	defer artifact.Close()
+
+	if artifact.CompileFailed() {
+		return &Result{Compile: artifact.Compile}, nil
+	}
+	return e.Run(ctx, artifact, req.RunRequest)
+}
+
+// CompilerVersion returns the first line of the compiler's --version output
+func (e *LocalExecutor) CompilerVersion(ctx context.Context, language string) (string, error) {
+	lang, ok := languages.Get(language)
+	if !ok || lang.Compile == nil {
+		return "", fmt.Errorf("language %q is not compiled by the local executor", language)
+	}
+	if v, ok := e.compilerVersions.Load(lang.Compile[0]); ok {
+		return v.(string), nil
+	}
+
+	out, err := exec.CommandContext(ctx, lang.Compile[0], "--version").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get %s version: %w", lang.Compile[0], err)
+	}
+	version := strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0])
+	e.compilerVersions.Store(lang.Compile[0], version)
+	return version, nil
+}
+
+// ExportBinary returns the program built for the artifact. Only languages
+// compiled to a single native binary can be exported.
+func (e *LocalExecutor) ExportBinary(artifact *Artifact) ([]byte, error) {
+	if artifact.dir == "" {
+		return nil, fmt.Errorf("artifact was not built by the local executor")
+	}
+	if !exportable(artifact.Language) {
+		return nil, fmt.Errorf("language %q does not build a single binary", artifact.Language)
+	}
+	return os.ReadFile(filepath.Join(artifact.dir, localBinaryName))
+}
+
+// ImportBinary stages a previously exported program in a fresh build
+// directory
+func (e *LocalExecutor) ImportBinary(language, version string, binary []byte) (*Artifact, error) {
+	if !exportable(language) {
+		return nil, fmt.Errorf("language %q does not build a single binary", language)
+	}
+
+	dir, err := os.MkdirTemp(e.workDir, "build-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create build directory: %w", err)
+	}
+	artifact := &Artifact{Language: language, Version: version, dir: dir}
+
+	if err := os.WriteFile(filepath.Join(dir, localBinaryName), binary, 0o755); err != nil {
+		artifact.Close()
+		return nil, fmt.Errorf("failed to write binary: %w", err)
+	}
+	if err := chownTree(dir); err != nil {
+		artifact.Close()
+		return nil, err
+	}
+	return artifact, nil
+}
+
+// exportable reports whether a language builds a single native binary
+func exportable(language string) bool {
+	lang, ok := languages.Get(language)
+	return ok && lang.Compile != nil && len(lang.Run) == 1 && lang.Run[0] == "./"+localBinaryName
+}
+
+// StagesRunFiles reports that run files are written into the run directory
+// and output files are read back from it
+func (e *LocalExecutor) StagesRunFiles() bool {
+	return true
+}
+
+// writeSources writes the request files into dir and returns their names.
+// A single source file is stored under the language's entrypoint name, as
+// callers may name files the way Piston expects.
+func writeSources(dir string, lang languages.Language, files []File) ([]string, error) {
+	if len(files) == 1 {
+		files = []File{{Name: lang.Entrypoint, Content: files[0].Content}}
+	}
+	names := make([]string, len(files))
+	for i, f := range files {
+		names[i] = strings.TrimPrefix(filepath.ToSlash(filepath.Clean("/"+f.Name)), "/")
+	}
+	return names, writeFiles(dir, files)
+}
+
+// writeFiles writes files into dir, keeping their names inside it
+func writeFiles(dir string, files []File) error {
+	for _, f := range files {
+		path := filepath.Join(dir, filepath.Clean("/"+f.Name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", f.Name, err)
+		}
+		if err := os.WriteFile(path, []byte(f.Content), 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", f.Name, err)
+		}
+	}
+	return chownTree(dir)
+}
+
+// readOutputFiles reads the named files the program left in dir. Missing
+// files are left out. Anything but a regular file is ignored too, so a
+// program cannot make the judge read outside its directory through a
+// symlink.
+func readOutputFiles(dir string, names []string) ([]File, error) {
+	var files []File
+	for _, name := range names {
+		path := filepath.Join(dir, filepath.Clean("/"+name))
+		info, err := os.Lstat(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		if !info.Mode().IsRegular() {
+			continue
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		files = append(files, File{Name: name, Content: string(content)})
+	}
+	return files, nil
+}
+
+// copyDir copies regular files and directories from src into dst
+func copyDir(src, dst string) error {
+	err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		if info.IsDir() {
+			return os.MkdirAll(target, info.Mode().Perm())
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+
+		out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			out.Close()
+			return err
+		}
+		return out.Close()
+	})
+	if err != nil {
+		return err
+	}
+	return chownTree(dst)
+}
+
+// chownTree hands a directory tree to the sandbox user
+func chownTree(dir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		return os.Lchown(path, sandboxUID, sandboxGID)
+	})
+}
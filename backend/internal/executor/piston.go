@@ -0,0 +1,171 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"codehustle/backend/internal/pistonlog"
+
+	"github.com/sirupsen/logrus"
+)
+
+const pistonCompileTimeoutMs = 10000
+
+// PistonExecutor runs programs through the Piston API. Piston keeps no build
+// outputs between requests, so its artifacts are Deferred and each run
+// compiles the sources again; only LocalExecutor compiles once per submission.
+type PistonExecutor struct {
+	url    string
+	client *http.Client
+}
+
+// NewPistonExecutor creates an executor backed by the Piston instance at url
+func NewPistonExecutor(url string) *PistonExecutor {
+	if url == "" {
+		url = "http://127.0.0.1:3002"
+	}
+	return &PistonExecutor{
+		url:    strings.TrimRight(url, "/"),
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Compile only records the sources. Piston has no way to keep build
+// artifacts between requests, so every run compiles them again and the
+// compile result arrives with the first run.
+func (e *PistonExecutor) Compile(ctx context.Context, req CompileRequest) (*Artifact, error) {
+	return &Artifact{
+		Language: req.Language,
+		Version:  req.Version,
+		Files:    req.Files,
+		Deferred: true,
+	}, nil
+}
+
+// Run executes the artifact's sources against a single input
+func (e *PistonExecutor) Run(ctx context.Context, artifact *Artifact, req RunRequest) (*Result, error) {
+	return e.execute(ctx, CompileRequest{
+		Language: artifact.Language,
+		Version:  artifact.Version,
+		Files:    artifact.Files,
+	}, req, "code_execution")
+}
+
+// Execute compiles and runs sources in a single Piston request
+func (e *PistonExecutor) Execute(ctx context.Context, req ExecuteRequest) (*Result, error) {
+	return e.execute(ctx, req.CompileRequest, req.RunRequest, "execute")
+}
+
+func (e *PistonExecutor) execute(ctx context.Context, compile CompileRequest, run RunRequest, kind string) (*Result, error) {
+	if len(run.Files) > 0 {
+		return nil, fmt.Errorf("piston cannot stage run files")
+	}
+	if len(run.OutputFiles) > 0 {
+		return nil, fmt.Errorf("piston cannot collect output files")
+	}
+
+	files := make([]map[string]string, 0, len(compile.Files))
+	for _, f := range compile.Files {
+		files = append(files, map[string]string{"name": f.Name, "content": f.Content})
+	}
+
+	args := run.Args
+	if args == nil {
+		args = []string{}
+	}
+
+	payload := map[string]interface{}{
+		"language":        compile.Language,
+		"version":         compile.Version,
+		"files":           files,
+		"stdin":           run.Stdin,
+		"args":            args,
+		"compile_timeout": pistonCompileTimeoutMs,
+		"run_timeout":     run.TimeLimitMs,
+	}
+	if run.MemoryLimitKb > 0 {
+		payload["run_memory_limit"] = run.MemoryLimitKb * 1024
+	}
+
+	logContext := map[string]interface{}{
+		"language":        compile.Language,
+		"version":         compile.Version,
+		"time_limit_ms":   run.TimeLimitMs,
+		"memory_limit_kb": run.MemoryLimitKb,
+		"stdin_length":    len(run.Stdin),
+		"type":            kind,
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	execURL := e.url + "/api/v2/execute"
+	logrus.WithField("payload", string(body)).Debug("Piston request payload")
+
+	// Log request to file
+	pistonlog.LogRequest(execURL, payload, logContext)
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, execURL, strings.NewReader(string(body)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(httpReq)
+	if err != nil {
+		logrus.WithError(err).Error("Piston HTTP request error")
+		pistonlog.LogError(execURL, err, logContext)
+		return nil, fmt.Errorf("piston execution error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	logrus.WithFields(logrus.Fields{
+		"status_code": resp.StatusCode,
+		"status":      resp.Status,
+	}).Debug("Piston response status")
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		logrus.WithError(err).Error("Error reading Piston response body")
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	// Log response to file
+	pistonlog.LogResponse(execURL, resp.StatusCode, b, logContext)
+
+	logrus.WithField("response_body", string(b)).Debug("Piston response body")
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("piston returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(b)))
+	}
+
+	var result Result
+	if err := json.Unmarshal(b, &result); err != nil {
+		logrus.WithError(err).Error("Error parsing Piston JSON response")
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"compile_code":          result.Compile.Code,
+		"compile_stdout_length": len(result.Compile.Stdout),
+		"compile_stderr_length": len(result.Compile.Stderr),
+		"compile_memory":        result.Compile.Memory,
+		"run_code":              result.Run.Code,
+		"run_stdout_length":     len(result.Run.Stdout),
+		"run_stderr_length":     len(result.Run.Stderr),
+		"run_memory":            result.Run.Memory,
+		"run_signal":            result.Run.Signal,
+		"run_status":            result.Run.Status,
+		"run_cpu_time_ms":       result.Run.CPUTime,
+		"run_wall_time_ms":      result.Run.WallTime,
+	}).Debug("Piston response parsed")
+
+	return &result, nil
+}
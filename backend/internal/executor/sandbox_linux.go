@@ -0,0 +1,515 @@
+//go:build linux
+
+package executor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// sandboxInitArg makes the worker binary act as the sandbox init process
+const sandboxInitArg = "__codehustle_sandbox_init"
+
+// sandboxReadOnlyPaths are host paths made visible read-only inside the sandbox
+var sandboxReadOnlyPaths = []string{
+	"/bin", "/sbin", "/lib", "/lib32", "/lib64", "/libx32", "/usr",
+	"/etc/alternatives", "/etc/ld.so.cache", "/etc/java-17-openjdk",
+}
+
+// sandboxDevices are device nodes bound into the sandbox
+var sandboxDevices = []string{"/dev/null", "/dev/zero", "/dev/random", "/dev/urandom"}
+
+// sandboxInitConfig is sent from the worker to the sandbox init process
+type sandboxInitConfig struct {
+	Args []string `json:"args"`
+	Env  []string `json:"env"`
+	Root string   `json:"root"`
+	Box  string   `json:"box"`
+}
+
+func init() {
+	if len(os.Args) > 1 && os.Args[1] == sandboxInitArg {
+		runSandboxInit()
+	}
+}
+
+// setupCgroupRoot creates the parent cgroup for runs and enables the
+// controllers they need
+func setupCgroupRoot(root string) error {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return fmt.Errorf("failed to create cgroup %s: %w", root, err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "cgroup.controllers")); err != nil {
+		return fmt.Errorf("%s is not on a cgroup v2 hierarchy: %w", root, err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "cgroup.subtree_control"), []byte("+cpu +memory +pids"), 0o644); err != nil {
+		return fmt.Errorf("failed to enable cgroup controllers in %s (is cgroup v2 delegated?): %w", root, err)
+	}
+	return nil
+}
+
+// runSandboxed runs a process isolated in fresh mount, PID, network, IPC and
+// UTS namespaces, accounted and limited by its own cgroup
+func runSandboxed(ctx context.Context, run sandboxRun) (*StageResult, error) {
+	// The pipe ends belong to the child; closing our copies lets the other
+	// side see EOF when the child exits, or right away if it never starts
+	closePipes := func() {
+		if run.StdinPipe != nil {
+			run.StdinPipe.Close()
+		}
+		if run.StdoutPipe != nil {
+			run.StdoutPipe.Close()
+		}
+	}
+	defer closePipes()
+
+	cgroupDir, err := os.MkdirTemp(run.CgroupRoot, "run-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cgroup: %w", err)
+	}
+	defer removeCgroup(cgroupDir)
+
+	if run.MemoryLimitKb > 0 {
+		if err := writeCgroupFile(cgroupDir, "memory.max", strconv.Itoa(run.MemoryLimitKb*1024)); err != nil {
+			return nil, err
+		}
+		if err := writeCgroupFile(cgroupDir, "memory.swap.max", "0"); err != nil {
+			return nil, err
+		}
+	}
+	if err := writeCgroupFile(cgroupDir, "pids.max", strconv.Itoa(localMaxProcesses)); err != nil {
+		return nil, err
+	}
+
+	cgroupFD, err := unix.Open(cgroupDir, unix.O_DIRECTORY|unix.O_RDONLY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cgroup: %w", err)
+	}
+	defer unix.Close(cgroupFD)
+
+	rootDir, err := os.MkdirTemp(filepath.Dir(run.Dir), "root-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sandbox root: %w", err)
+	}
+	defer os.RemoveAll(rootDir)
+
+	configR, configW, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	defer configR.Close()
+	defer configW.Close()
+
+	errR, errW, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	defer errR.Close()
+	defer errW.Close()
+
+	stdout := &limitedBuffer{limit: localMaxOutputBytes}
+	stderr := &limitedBuffer{limit: localMaxStderrBytes}
+
+	cmd := exec.Command("/proc/self/exe", sandboxInitArg)
+	cmd.Env = []string{}
+	cmd.Stdin = strings.NewReader(run.Stdin)
+	cmd.Stdout = stdout
+	if run.StdinPipe != nil {
+		cmd.Stdin = run.StdinPipe
+	}
+	if run.StdoutPipe != nil {
+		cmd.Stdout = run.StdoutPipe
+	}
+	cmd.Stderr = stderr
+	cmd.ExtraFiles = []*os.File{configR, errW}
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags: syscall.CLONE_NEWNS | syscall.CLONE_NEWPID | syscall.CLONE_NEWNET |
+			syscall.CLONE_NEWIPC | syscall.CLONE_NEWUTS,
+		UseCgroupFD: true,
+		CgroupFD:    cgroupFD,
+		Pdeathsig:   syscall.SIGKILL,
+	}
+
+	started := time.Now()
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start sandbox: %w", err)
+	}
+	configR.Close()
+	errW.Close()
+	closePipes()
+
+	cfg := sandboxInitConfig{
+		Args: run.Args,
+		Env:  append([]string{"PATH=/usr/local/bin:/usr/bin:/bin", "HOME=/tmp", "LANG=C.UTF-8"}, run.Env...),
+		Root: rootDir,
+		Box:  run.Dir,
+	}
+	if err := json.NewEncoder(configW).Encode(cfg); err != nil {
+		killCgroup(cgroupDir)
+		cmd.Wait()
+		return nil, fmt.Errorf("failed to configure sandbox: %w", err)
+	}
+	configW.Close()
+
+	// Enforce the CPU limit precisely from cgroup accounting and the wall
+	// limit as a backstop for programs that sleep or block
+	var mu sync.Mutex
+	timedOut := false
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+		wallLimit := time.Duration(run.TimeLimitMs)*2*time.Millisecond + time.Second
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				killCgroup(cgroupDir)
+				return
+			case <-ticker.C:
+				cpuMs := readCgroupCPUTimeMs(cgroupDir)
+				if run.TimeLimitMs > 0 && (cpuMs > run.TimeLimitMs || time.Since(started) > wallLimit) {
+					mu.Lock()
+					timedOut = true
+					mu.Unlock()
+					killCgroup(cgroupDir)
+					return
+				}
+			}
+		}
+	}()
+
+	waitErr := cmd.Wait()
+	close(done)
+	wall := time.Since(started)
+
+	if msg, _ := io.ReadAll(errR); len(msg) > 0 {
+		return nil, fmt.Errorf("sandbox setup failed: %s", strings.TrimSpace(string(msg)))
+	}
+	if waitErr != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(waitErr, &exitErr) {
+			return nil, fmt.Errorf("failed to wait for sandbox: %w", waitErr)
+		}
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	result := &StageResult{
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+		CPUTime:  readCgroupCPUTimeMs(cgroupDir),
+		WallTime: int(wall.Milliseconds()),
+		Memory:   readCgroupInt(cgroupDir, "memory.peak"),
+	}
+
+	status := cmd.ProcessState.Sys().(syscall.WaitStatus)
+	switch {
+	case status.Signaled():
+		result.Signal = unix.SignalName(status.Signal())
+		result.Status = StatusSignal
+	case status.Exited():
+		result.Code = status.ExitStatus()
+		if result.Code != 0 {
+			result.Status = StatusRuntimeError
+		}
+	}
+
+	mu.Lock()
+	if timedOut {
+		result.Status = StatusTimeout
+		result.Signal = "SIGKILL"
+		result.Message = "Time limit exceeded"
+	}
+	mu.Unlock()
+
+	if run.MemoryLimitKb > 0 && readCgroupEvent(cgroupDir, "memory.events", "oom_kill") > 0 {
+		result.Signal = "SIGKILL"
+		result.Message = "Memory limit exceeded"
+		if result.Memory < run.MemoryLimitKb*1024 {
+			result.Memory = run.MemoryLimitKb * 1024
+		}
+	}
+
+	if stdout.truncated {
+		result.Status = StatusOutputLimit
+		result.Message = "Output limit exceeded"
+	} else if stderr.truncated && result.Status == "" {
+		result.Status = StatusErrorLimit
+		result.Message = "Error output limit exceeded"
+	}
+
+	return result, nil
+}
+
+// runSandboxInit runs inside the new namespaces: it builds the root
+// filesystem, drops privileges, installs the seccomp filter and execs the
+// program. It never returns.
+func runSandboxInit() {
+	runtime.LockOSThread()
+
+	errPipe := os.NewFile(4, "sandbox-errors")
+	fail := func(format string, args ...interface{}) {
+		fmt.Fprintf(errPipe, format, args...)
+		os.Exit(127)
+	}
+	unix.CloseOnExec(4)
+
+	var cfg sandboxInitConfig
+	configPipe := os.NewFile(3, "sandbox-config")
+	if err := json.NewDecoder(configPipe).Decode(&cfg); err != nil {
+		fail("read config: %v", err)
+	}
+	configPipe.Close()
+
+	if err := setupSandboxRoot(cfg.Root, cfg.Box); err != nil {
+		fail("%v", err)
+	}
+	if err := unix.Sethostname([]byte("sandbox")); err != nil {
+		fail("sethostname: %v", err)
+	}
+
+	limits := []struct {
+		resource int
+		value    uint64
+	}{
+		{unix.RLIMIT_CORE, 0},
+		{unix.RLIMIT_NOFILE, 256},
+		{unix.RLIMIT_FSIZE, localMaxOutputBytes},
+		{unix.RLIMIT_STACK, unix.RLIM_INFINITY},
+	}
+	for _, l := range limits {
+		if err := unix.Setrlimit(l.resource, &unix.Rlimit{Cur: l.value, Max: l.value}); err != nil {
+			fail("setrlimit %d: %v", l.resource, err)
+		}
+	}
+
+	if err := unix.Setgroups([]int{}); err != nil {
+		fail("setgroups: %v", err)
+	}
+	if err := unix.Setresgid(sandboxGID, sandboxGID, sandboxGID); err != nil {
+		fail("setresgid: %v", err)
+	}
+	if err := unix.Setresuid(sandboxUID, sandboxUID, sandboxUID); err != nil {
+		fail("setresuid: %v", err)
+	}
+
+	if len(cfg.Args) == 0 {
+		fail("no command given")
+	}
+	for _, kv := range cfg.Env {
+		if strings.HasPrefix(kv, "PATH=") {
+			os.Setenv("PATH", strings.TrimPrefix(kv, "PATH="))
+		}
+	}
+	path := cfg.Args[0]
+	if !strings.Contains(path, "/") {
+		resolved, err := exec.LookPath(path)
+		if err != nil {
+			fail("%s: %v", path, err)
+		}
+		path = resolved
+	}
+
+	if err := installSeccompFilter(); err != nil {
+		fail("seccomp: %v", err)
+	}
+
+	err := unix.Exec(path, cfg.Args, cfg.Env)
+	fail("exec %s: %v", path, err)
+}
+
+// setupSandboxRoot builds a minimal root filesystem on a tmpfs: read-only
+// toolchain directories, the writable box directory and a private /proc and
+// /tmp, then pivots into it
+func setupSandboxRoot(root, box string) error {
+	if err := unix.Mount("", "/", "", unix.MS_PRIVATE|unix.MS_REC, ""); err != nil {
+		return fmt.Errorf("make mounts private: %w", err)
+	}
+	if err := unix.Mount("tmpfs", root, "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, "size=16m,mode=755"); err != nil {
+		return fmt.Errorf("mount root: %w", err)
+	}
+
+	for _, p := range sandboxReadOnlyPaths {
+		if err := bindMount(p, filepath.Join(root, p), true); err != nil {
+			return err
+		}
+	}
+	for _, dev := range sandboxDevices {
+		if err := bindMount(dev, filepath.Join(root, dev), false); err != nil {
+			return err
+		}
+	}
+	if err := bindMount(box, filepath.Join(root, "box"), false); err != nil {
+		return err
+	}
+
+	tmp := filepath.Join(root, "tmp")
+	if err := os.MkdirAll(tmp, 0o777); err != nil {
+		return err
+	}
+	if err := unix.Mount("tmpfs", tmp, "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, "size=64m,mode=1777"); err != nil {
+		return fmt.Errorf("mount /tmp: %w", err)
+	}
+
+	proc := filepath.Join(root, "proc")
+	if err := os.MkdirAll(proc, 0o555); err != nil {
+		return err
+	}
+	if err := unix.Mount("proc", proc, "proc", unix.MS_NOSUID|unix.MS_NODEV|unix.MS_NOEXEC, ""); err != nil {
+		return fmt.Errorf("mount /proc: %w", err)
+	}
+
+	oldRoot := filepath.Join(root, ".old")
+	if err := os.MkdirAll(oldRoot, 0o700); err != nil {
+		return err
+	}
+	if err := unix.PivotRoot(root, oldRoot); err != nil {
+		return fmt.Errorf("pivot_root: %w", err)
+	}
+	if err := unix.Chdir("/"); err != nil {
+		return err
+	}
+	if err := unix.Unmount("/.old", unix.MNT_DETACH); err != nil {
+		return fmt.Errorf("unmount old root: %w", err)
+	}
+	if err := os.Remove("/.old"); err != nil {
+		return err
+	}
+	if err := unix.Mount("", "/", "", unix.MS_REMOUNT|unix.MS_RDONLY|unix.MS_NOSUID|unix.MS_NODEV, ""); err != nil {
+		return fmt.Errorf("remount root read-only: %w", err)
+	}
+	return unix.Chdir("/box")
+}
+
+// bindMount binds source onto target, skipping sources missing on the host
+func bindMount(source, target string, readOnly bool) error {
+	info, err := os.Stat(source)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	if info.IsDir() {
+		if err := os.MkdirAll(target, 0o755); err != nil {
+			return err
+		}
+	} else {
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			return err
+		}
+		f.Close()
+	}
+
+	if err := unix.Mount(source, target, "", unix.MS_BIND|unix.MS_REC, ""); err != nil {
+		return fmt.Errorf("bind %s: %w", source, err)
+	}
+	flags := uintptr(unix.MS_BIND | unix.MS_REMOUNT | unix.MS_NOSUID)
+	if readOnly {
+		flags |= unix.MS_RDONLY
+	}
+	if err := unix.Mount("", target, "", flags, ""); err != nil {
+		return fmt.Errorf("remount %s: %w", source, err)
+	}
+	return nil
+}
+
+func writeCgroupFile(dir, name, value string) error {
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(value), 0o644); err != nil {
+		return fmt.Errorf("failed to set %s: %w", name, err)
+	}
+	return nil
+}
+
+// readCgroupCPUTimeMs returns the CPU time used by all processes in the cgroup
+func readCgroupCPUTimeMs(dir string) int {
+	return readCgroupEvent(dir, "cpu.stat", "usage_usec") / 1000
+}
+
+func readCgroupInt(dir, name string) int {
+	b, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return 0
+	}
+	v, _ := strconv.Atoi(strings.TrimSpace(string(b)))
+	return v
+}
+
+// readCgroupEvent reads a "key value" entry from a flat-keyed cgroup file
+func readCgroupEvent(dir, name, key string) int {
+	b, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return 0
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == key {
+			v, _ := strconv.Atoi(fields[1])
+			return v
+		}
+	}
+	return 0
+}
+
+func killCgroup(dir string) {
+	_ = os.WriteFile(filepath.Join(dir, "cgroup.kill"), []byte("1"), 0o644)
+}
+
+// removeCgroup kills anything left in the cgroup and removes it, retrying
+// briefly while the kernel reaps the processes
+func removeCgroup(dir string) {
+	killCgroup(dir)
+	for i := 0; i < 50; i++ {
+		if err := os.Remove(dir); err == nil || os.IsNotExist(err) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// limitedBuffer collects output up to a limit and drops the rest
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.buf.Len(); room < len(p) {
+		b.truncated = true
+		if room > 0 {
+			b.buf.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
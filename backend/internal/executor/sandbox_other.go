@@ -0,0 +1,16 @@
+//go:build !linux
+
+package executor
+
+import (
+	"context"
+	"fmt"
+)
+
+func setupCgroupRoot(root string) error {
+	return fmt.Errorf("the local executor requires Linux with cgroup v2")
+}
+
+func runSandboxed(ctx context.Context, run sandboxRun) (*StageResult, error) {
+	return nil, fmt.Errorf("the local executor requires Linux with cgroup v2")
+}
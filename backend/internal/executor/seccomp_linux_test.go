@@ -0,0 +1,107 @@
+//go:build linux
+
+package executor
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+// seccompHelperEnv makes the test binary load the filter and make the named
+// syscall instead of running the tests
+const seccompHelperEnv = "CODEHUSTLE_SECCOMP_HELPER"
+
+// TestSeccompHelper runs in a child process, since a filter cannot be removed
+// once loaded. It exits with the errno of the filtered syscall.
+func TestSeccompHelper(t *testing.T) {
+	call := os.Getenv(seccompHelperEnv)
+	if call == "" {
+		t.Skip("only runs as a child of TestSeccompFilter")
+	}
+
+	runtime.LockOSThread()
+	if err := installSeccompFilter(); err != nil {
+		os.Exit(100)
+	}
+
+	var errno syscall.Errno
+	switch call {
+	case "ptrace":
+		_, _, errno = unix.RawSyscall(unix.SYS_PTRACE, unix.PTRACE_TRACEME, 0, 0)
+	case "unshare":
+		_, _, errno = unix.RawSyscall(unix.SYS_UNSHARE, unix.CLONE_NEWUSER, 0, 0)
+	case "clone_newuser":
+		// CLONE_FS with CLONE_NEWUSER is EINVAL for the kernel, so no child
+		// is created even if the filter lets the call through
+		_, _, errno = unix.RawSyscall(unix.SYS_CLONE, unix.CLONE_NEWUSER|unix.CLONE_FS, 0, 0)
+	case "clone_plain":
+		// CLONE_SIGHAND without CLONE_VM is EINVAL for the kernel
+		_, _, errno = unix.RawSyscall(unix.SYS_CLONE, unix.CLONE_SIGHAND, 0, 0)
+	case "clone3":
+		_, _, errno = unix.RawSyscall(unix.SYS_CLONE3, 0, 0, 0)
+	case "x32_getpid":
+		_, _, errno = unix.RawSyscall(seccompX32SyscallBit|unix.SYS_GETPID, 0, 0, 0)
+	case "getpid":
+		_, _, errno = unix.RawSyscall(unix.SYS_GETPID, 0, 0, 0)
+	}
+	os.Exit(int(errno))
+}
+
+func TestSeccompFilter(t *testing.T) {
+	if os.Getenv(seccompHelperEnv) != "" {
+		t.Skip("running as the seccomp helper")
+	}
+
+	tests := []struct {
+		call  string
+		errno syscall.Errno
+	}{
+		{call: "getpid", errno: 0},
+		{call: "ptrace", errno: unix.EPERM},
+		{call: "unshare", errno: unix.EPERM},
+		{call: "clone_newuser", errno: unix.EPERM},
+		{call: "clone_plain", errno: unix.EINVAL},
+		{call: "clone3", errno: unix.ENOSYS},
+	}
+	for _, tt := range tests {
+		t.Run(tt.call, func(t *testing.T) {
+			err := runSeccompHelper(tt.call)
+			if tt.errno == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			var exitErr *exec.ExitError
+			require.ErrorAs(t, err, &exitErr)
+			require.NotEqual(t, 100, exitErr.ExitCode(), "filter failed to load")
+			assert.Equal(t, int(tt.errno), exitErr.ExitCode())
+		})
+	}
+
+	t.Run("x32_getpid", func(t *testing.T) {
+		if runtime.GOARCH != "amd64" {
+			t.Skip("x32 exists only on amd64")
+		}
+
+		err := runSeccompHelper("x32_getpid")
+		var exitErr *exec.ExitError
+		require.ErrorAs(t, err, &exitErr)
+		status, ok := exitErr.Sys().(syscall.WaitStatus)
+		require.True(t, ok)
+		assert.True(t, status.Signaled())
+		assert.Equal(t, syscall.SIGSYS, status.Signal())
+	})
+}
+
+func runSeccompHelper(call string) error {
+	cmd := exec.Command(os.Args[0], "-test.run=^TestSeccompHelper$")
+	cmd.Env = append(os.Environ(), seccompHelperEnv+"="+call)
+	return cmd.Run()
+}
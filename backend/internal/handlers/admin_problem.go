@@ -7,10 +7,12 @@ import (
 	"mime/multipart"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
+	"codehustle/backend/internal/checker"
 	"codehustle/backend/internal/middleware"
 	"codehustle/backend/internal/models"
 	"codehustle/backend/internal/repository"
@@ -433,6 +435,9 @@ type ProblemExport struct {
 	Tags      []string          `json:"tags"`
 	TestCases []models.TestCase `json:"test_cases"`
 	Statement string            `json:"statement"`
+	// Judge references checker, interactor and validator objects by path,
+	// so it only carries over between deployments sharing the bucket
+	Judge *models.ProblemJudge `json:"judge,omitempty"`
 }
 
 // AdminExportProblem exports a problem as JSON (Admin only)
@@ -498,6 +503,9 @@ func AdminExportProblem(c *gin.Context) {
 		TestCases: testCases,
 		Statement: string(statementContent),
 	}
+	if judge, err := repository.GetProblemJudgeByProblemID(problemID); err == nil {
+		export.Judge = judge
+	}
 
 	c.Header("Content-Type", "application/json")
 	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=problem_%s.json", problemID))
@@ -554,6 +562,18 @@ func AdminImportProblem(c *gin.Context) {
 		return
 	}
 
+	// Check the inputs before anything is created
+	if export.Judge != nil && export.Judge.ValidatorPath != nil && *export.Judge.ValidatorPath != "" {
+		inputs := make([]checker.ValidationInput, 0, len(export.TestCases))
+		for _, tc := range export.TestCases {
+			// Content was stored in InputPath during export
+			inputs = append(inputs, checker.ValidationInput{Name: tc.Name, Content: tc.InputPath})
+		}
+		if !validateInputs(c, export.Judge, inputs) {
+			return
+		}
+	}
+
 	// Generate new problem ID
 	newProblemID := uuid.NewString()
 
@@ -635,6 +655,17 @@ func AdminImportProblem(c *gin.Context) {
 		}
 	}
 
+	// Copy the judge configuration
+	if export.Judge != nil {
+		judge := *export.Judge
+		judge.ID = uuid.NewString()
+		judge.ProblemID = newProblemID
+		judge.CreatedAt = time.Time{}
+		if err := repository.NewProblemJudgeRepository().Upsert(c.Request.Context(), &judge); err != nil {
+			log.Printf("[ADMIN_PROBLEM] Failed to save judge configuration: %v", err)
+		}
+	}
+
 	// Add tags
 	for _, tagName := range export.Tags {
 		if err := repository.AddTagToProblem(newProblemID, tagName); err != nil {
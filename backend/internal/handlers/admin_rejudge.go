@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"codehustle/backend/internal/constants"
+	"codehustle/backend/internal/middleware"
+	"codehustle/backend/internal/models"
+	"codehustle/backend/internal/queue"
+	"codehustle/backend/internal/repository"
+)
+
+// RejudgeRequest narrows down the submissions of a problem or contest to rejudge
+type RejudgeRequest struct {
+	Statuses  []string `json:"statuses"`             // only rejudge submissions with these verdicts
+	From      string   `json:"from"`                 // RFC3339, submitted at or after
+	To        string   `json:"to"`                   // RFC3339, submitted at or before
+	ProblemID string   `json:"problem_id,omitempty"` // contest rejudge only
+}
+
+// RejudgeResponse reports which submissions were queued for rejudging
+type RejudgeResponse struct {
+	Requested     int      `json:"requested"`
+	Rejudged      int      `json:"rejudged"`
+	SubmissionIDs []string `json:"submission_ids"`
+	Failed        []string `json:"failed,omitempty"`
+	InProgress    []string `json:"in_progress,omitempty"` // already being judged, left alone
+}
+
+// AdminRejudgeSubmission rejudges a single submission (Admin only)
+func AdminRejudgeSubmission(c *gin.Context) {
+	submissionID := c.Param("id")
+	userID := rejudgeUserID(c)
+
+	submission, err := repository.GetSubmissionByID(submissionID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "submission_not_found",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if submission.Status == "pending" || submission.Status == "running" {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "submission_in_progress",
+			"message": "Submission is still being judged",
+		})
+		return
+	}
+
+	log.Printf("[REJUDGE] AdminRejudgeSubmission: submissionID=%s, by=%s", submissionID, userID)
+
+	queued, err := rejudgeSubmission(submission, userID)
+	if err != nil {
+		log.Printf("[REJUDGE] Failed to rejudge submission %s: %v", submissionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "failed_to_rejudge",
+			"message": err.Error(),
+		})
+		return
+	}
+	if !queued {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "submission_in_progress",
+			"message": "Submission is still being judged",
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, RejudgeResponse{
+		Requested:     1,
+		Rejudged:      1,
+		SubmissionIDs: []string{submissionID},
+	})
+}
+
+// AdminRejudgeProblem rejudges the submissions of a problem, optionally
+// filtered by verdict and submission time (Admin only)
+func AdminRejudgeProblem(c *gin.Context) {
+	problem, err := repository.GetProblem(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "problem_not_found",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	filter, ok := bindRejudgeFilter(c)
+	if !ok {
+		return
+	}
+	filter.ProblemID = problem.ID
+
+	log.Printf("[REJUDGE] AdminRejudgeProblem: problemID=%s, statuses=%v", problem.ID, filter.Statuses)
+	rejudgeMatching(c, filter)
+}
+
+// AdminRejudgeContest rejudges the submissions of a contest, optionally
+// limited to one problem (Admin only)
+func AdminRejudgeContest(c *gin.Context) {
+	contestID := c.Param("id")
+	if _, _, err := repository.GetContest(contestID, "", constants.RoleAdmin); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "contest_not_found",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	filter, ok := bindRejudgeFilter(c)
+	if !ok {
+		return
+	}
+	filter.ContestID = contestID
+
+	log.Printf("[REJUDGE] AdminRejudgeContest: contestID=%s, problemID=%s, statuses=%v", contestID, filter.ProblemID, filter.Statuses)
+	rejudgeMatching(c, filter)
+}
+
+// AdminListSubmissionRejudges returns the rejudge history of a submission,
+// pairing each previous verdict with the one it was rejudged to (Admin only)
+func AdminListSubmissionRejudges(c *gin.Context) {
+	submissionID := c.Param("id")
+
+	rejudges, err := repository.ListSubmissionRejudges(submissionID)
+	if err != nil {
+		log.Printf("[REJUDGE] Failed to list rejudges for submission %s: %v", submissionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "failed_to_list_rejudges",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"submission_id": submissionID,
+		"items":         rejudges,
+	})
+}
+
+// bindRejudgeFilter parses the optional rejudge filter body
+func bindRejudgeFilter(c *gin.Context) (repository.RejudgeFilter, bool) {
+	var req RejudgeRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_request",
+				"message": err.Error(),
+			})
+			return repository.RejudgeFilter{}, false
+		}
+	}
+
+	from, errFrom := parseOptionalTime(req.From)
+	to, errTo := parseOptionalTime(req.To)
+	if errFrom != nil || errTo != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_time_range",
+			"message": "from and to must be RFC3339 timestamps",
+		})
+		return repository.RejudgeFilter{}, false
+	}
+
+	filter := repository.RejudgeFilter{
+		ProblemID: req.ProblemID,
+		Statuses:  req.Statuses,
+		From:      from,
+		To:        to,
+	}
+	return filter, true
+}
+
+// parseOptionalTime parses an RFC3339 timestamp, returning nil for an empty string
+func parseOptionalTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// rejudgeMatching rejudges every submission matching the filter
+func rejudgeMatching(c *gin.Context, filter repository.RejudgeFilter) {
+	userID := rejudgeUserID(c)
+
+	submissions, err := repository.ListSubmissionsForRejudge(filter)
+	if err != nil {
+		log.Printf("[REJUDGE] Failed to list submissions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "failed_to_list_submissions",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	resp := RejudgeResponse{
+		Requested:     len(submissions),
+		SubmissionIDs: []string{},
+	}
+	for i := range submissions {
+		queued, err := rejudgeSubmission(&submissions[i], userID)
+		if err != nil {
+			log.Printf("[REJUDGE] Failed to rejudge submission %s: %v", submissions[i].ID, err)
+			resp.Failed = append(resp.Failed, submissions[i].ID)
+			continue
+		}
+		if !queued {
+			resp.InProgress = append(resp.InProgress, submissions[i].ID)
+			continue
+		}
+		resp.SubmissionIDs = append(resp.SubmissionIDs, submissions[i].ID)
+	}
+	resp.Rejudged = len(resp.SubmissionIDs)
+
+	log.Printf("[REJUDGE] Queued %d of %d submissions for rejudging", resp.Rejudged, resp.Requested)
+	c.JSON(http.StatusAccepted, resp)
+}
+
+// rejudgeSubmission resets a submission, keeping its verdict in the audit
+// log, and queues it on the rejudge lane. It returns false if the
+// submission was already being judged, in which case nothing is queued.
+func rejudgeSubmission(s *models.Submission, userID string) (bool, error) {
+	reset, err := repository.ResetSubmissionForRejudge(s, userID)
+	if err != nil || !reset {
+		return false, err
+	}
+	if err := queue.PublishSubmissionEvent(context.Background(), queue.SubmissionEvent{
+		SubmissionID: s.ID,
+		Type:         queue.SubmissionEventStatus,
+		Status:       "pending",
+	}); err != nil {
+		log.Printf("[REJUDGE] Failed to publish status for submission %s: %v", s.ID, err)
+	}
+
+	job := &queue.JudgeJob{
+		SubmissionID: s.ID,
+		Priority:     queue.PriorityRejudge,
+	}
+
+	streamID, err := queue.EnqueueJudgeJob(context.Background(), job)
+	if err != nil {
+		return false, err
+	}
+	log.Printf("[REJUDGE] Judge job enqueued: submissionID=%s, streamID=%s", s.ID, streamID)
+	return true, nil
+}
+
+// rejudgeUserID returns the ID of the admin requesting the rejudge
+func rejudgeUserID(c *gin.Context) string {
+	userCtx, exists := c.Get("user")
+	if !exists {
+		return ""
+	}
+	user, ok := userCtx.(middleware.UserContext)
+	if !ok {
+		return ""
+	}
+	return user.ID
+}
@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"context"
+	"io"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -11,8 +14,11 @@ import (
 	"golang.org/x/crypto/bcrypt"
 
 	"codehustle/backend/internal/constants"
+	"codehustle/backend/internal/judge"
+	"codehustle/backend/internal/languages"
 	"codehustle/backend/internal/middleware"
 	"codehustle/backend/internal/models"
+	"codehustle/backend/internal/queue"
 	"codehustle/backend/internal/repository"
 )
 
@@ -119,6 +125,7 @@ func GetContest(c *gin.Context) {
 		"allowed_languages":           contest.AllowedLanguages,
 		"submission_limit_per_problem": contest.SubmissionLimitPerProblem,
 		"rule_type":                   contest.RuleType,
+		"freeze_at":                   contest.FreezeAt,
 		"status":                      contest.Status(),
 		"created_by":                  contest.CreatedBy,
 		"created_at":                  contest.CreatedAt,
@@ -153,6 +160,8 @@ func CreateContest(c *gin.Context) {
 		Password                 *string  `json:"password"`
 		AllowedLanguages         []string `json:"allowed_languages"`
 		SubmissionLimitPerProblem int     `json:"submission_limit_per_problem"`
+		RuleType                 string   `json:"rule_type"` // OI (default), IOI or ICPC
+		FreezeAt                 string   `json:"freeze_at"` // optional, RFC3339
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -178,6 +187,29 @@ func CreateContest(c *gin.Context) {
 		return
 	}
 
+	var freezeAt *time.Time
+	if req.FreezeAt != "" {
+		t, err := time.Parse(time.RFC3339, req.FreezeAt)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid freeze_at format"})
+			return
+		}
+		freezeAt = &t
+	}
+	if !validFreezeTime(freezeAt, startAt, endAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "freeze_at must be between start_at and end_at"})
+		return
+	}
+
+	ruleType := models.RuleTypeOI
+	if req.RuleType != "" {
+		ruleType = strings.ToUpper(req.RuleType)
+		if !models.IsValidRuleType(ruleType) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "rule_type must be OI, IOI or ICPC"})
+			return
+		}
+	}
+
 	// Hash password if provided
 	var hashedPassword *string
 	if req.Password != nil && *req.Password != "" {
@@ -201,7 +233,8 @@ func CreateContest(c *gin.Context) {
 		Password:                 hashedPassword,
 		AllowedLanguages:         req.AllowedLanguages,
 		SubmissionLimitPerProblem: req.SubmissionLimitPerProblem,
-		RuleType:                 "OI",
+		RuleType:                 ruleType,
+		FreezeAt:                 freezeAt,
 		CreatedBy:                user.ID,
 	}
 
@@ -223,6 +256,7 @@ func CreateContest(c *gin.Context) {
 		"allowed_languages":           contest.AllowedLanguages,
 		"submission_limit_per_problem": contest.SubmissionLimitPerProblem,
 		"rule_type":                   contest.RuleType,
+		"freeze_at":                   contest.FreezeAt,
 		"created_by":                  contest.CreatedBy,
 		"created_at":                  contest.CreatedAt,
 	}
@@ -255,6 +289,8 @@ func UpdateContest(c *gin.Context) {
 		Password                 *string  `json:"password"`
 		AllowedLanguages         []string `json:"allowed_languages"`
 		SubmissionLimitPerProblem *int    `json:"submission_limit_per_problem"`
+		RuleType                 *string  `json:"rule_type"`
+		FreezeAt                 *string  `json:"freeze_at"` // RFC3339, empty to never freeze
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -279,6 +315,7 @@ func UpdateContest(c *gin.Context) {
 	}
 
 	// Apply updates
+	previous := *contest
 	if req.Title != nil {
 		contest.Title = *req.Title
 	}
@@ -324,11 +361,39 @@ func UpdateContest(c *gin.Context) {
 	if req.SubmissionLimitPerProblem != nil {
 		contest.SubmissionLimitPerProblem = *req.SubmissionLimitPerProblem
 	}
+	if req.RuleType != nil {
+		ruleType := strings.ToUpper(*req.RuleType)
+		if !models.IsValidRuleType(ruleType) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "rule_type must be OI, IOI or ICPC"})
+			return
+		}
+		contest.RuleType = ruleType
+	}
+	if req.FreezeAt != nil {
+		if *req.FreezeAt == "" {
+			contest.FreezeAt = nil
+		} else {
+			freezeAt, err := time.Parse(time.RFC3339, *req.FreezeAt)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid freeze_at format"})
+				return
+			}
+			contest.FreezeAt = &freezeAt
+		}
+		// A new freeze time hides results again until they are revealed
+		if !sameTime(previous.FreezeAt, contest.FreezeAt) {
+			contest.UnfrozenAt = nil
+		}
+	}
 
 	if contest.EndAt.Before(contest.StartAt) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "end_at must be after start_at"})
 		return
 	}
+	if !validFreezeTime(contest.FreezeAt, contest.StartAt, contest.EndAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "freeze_at must be between start_at and end_at"})
+		return
+	}
 
 	now := time.Now()
 	contest.UpdatedAt = &now
@@ -339,6 +404,15 @@ func UpdateContest(c *gin.Context) {
 		return
 	}
 
+	// Scoreboard cells depend on the contest times and rule
+	if scoreboardChanged(&previous, contest) {
+		if cells, err := repository.RebuildContestScores(contest.ID); err != nil {
+			log.Printf("[CONTEST] Failed to rebuild scoreboard: %v", err)
+		} else {
+			log.Printf("[CONTEST] Rebuilt scoreboard: %d cells", cells)
+		}
+	}
+
 	response := gin.H{
 		"id":                          contest.ID,
 		"title":                       contest.Title,
@@ -349,6 +423,7 @@ func UpdateContest(c *gin.Context) {
 		"allowed_languages":           contest.AllowedLanguages,
 		"submission_limit_per_problem": contest.SubmissionLimitPerProblem,
 		"rule_type":                   contest.RuleType,
+		"freeze_at":                   contest.FreezeAt,
 		"updated_at":                  contest.UpdatedAt,
 	}
 
@@ -939,19 +1014,8 @@ func SubmitContestProblem(c *gin.Context) {
 		return
 	}
 
-	var req struct {
-		Language        string `json:"language" binding:"required"`
-		SourceCode      string `json:"source_code" binding:"required"`
-		LanguageVersion string `json:"language_version"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	log.Printf("[CONTEST] SubmitContestProblem: contestID=%s, problemID=%s, userID=%s, language=%s",
-		contestID, problemID, user.ID, req.Language)
+	log.Printf("[CONTEST] SubmitContestProblem: contestID=%s, problemID=%s, userID=%s",
+		contestID, problemID, user.ID)
 
 	userRole := getPrimaryRole(user.Roles)
 
@@ -981,28 +1045,80 @@ func SubmitContestProblem(c *gin.Context) {
 		return
 	}
 
-	// Check language is allowed
-	allowedLanguages := contestProblem.AllowedLanguages
-	if len(allowedLanguages) == 0 {
-		allowedLanguages = contest.AllowedLanguages
+	// Answers of output-only problems are uploaded as a zip in the code_file
+	// form field; other problems send their source code as JSON
+	judgeConfig, _ := repository.GetProblemJudgeByProblemID(contestProblem.ProblemID)
+	outputOnly := judgeConfig != nil && judgeConfig.IsOutputOnly()
+
+	var req struct {
+		Language        string `json:"language" binding:"required"`
+		SourceCode      string `json:"source_code" binding:"required"`
+		LanguageVersion string `json:"language_version"`
 	}
+	var answers []byte
+	language := models.LanguageAnswers
 
-	if len(allowedLanguages) > 0 {
-		languageAllowed := false
-		for _, lang := range allowedLanguages {
-			if lang == req.Language {
-				languageAllowed = true
-				break
-			}
+	if outputOnly {
+		fileHeader, err := c.FormFile("code_file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Output-only problem: upload a zip of answers as code_file"})
+			return
+		}
+		if fileHeader.Size > maxAnswersZipSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Answers zip exceeds maximum size of 16MB"})
+			return
+		}
+
+		file, err := fileHeader.Open()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read answers"})
+			return
+		}
+		answers, err = io.ReadAll(file)
+		file.Close()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read answers"})
+			return
+		}
+
+		// Unpack the answers once so a broken zip is rejected before judging
+		if _, err := judge.ReadAnswers(answers); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	} else {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		language, ok = supportedLanguage(req.Language, req.LanguageVersion)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Language or language version not supported"})
+			return
 		}
-		if !languageAllowed {
+
+		// Check language is allowed; the problem's list overrides the contest's
+		allowedLanguages := contestProblem.AllowedLanguages
+		if len(allowedLanguages) == 0 {
+			allowedLanguages = contest.AllowedLanguages
+		}
+
+		if !languages.Allowed(allowedLanguages, language) {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error":             "Language not allowed for this contest",
 				"allowed_languages": allowedLanguages,
 			})
 			return
 		}
+
+		// Validate code size (max 1MB, same as regular submissions)
+		if len(req.SourceCode) > 1024*1024 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Source code exceeds maximum size of 1MB"})
+			return
+		}
 	}
+	log.Printf("[CONTEST] Submission language: %s", language)
 
 	// Check submission limit
 	if contest.SubmissionLimitPerProblem > 0 {
@@ -1022,21 +1138,71 @@ func SubmitContestProblem(c *gin.Context) {
 		}
 	}
 
-	// Create submission (this would integrate with your existing submission system)
+	languageVersion := req.LanguageVersion
+	if languageVersion == "" {
+		languageVersion = "latest"
+	}
+
+	// Create submission record
 	submissionID := uuid.New().String()
+	codeSizeBytes := len(req.SourceCode)
+	if outputOnly {
+		codeSizeBytes = len(answers)
+	}
+	submission := models.Submission{
+		ID:              submissionID,
+		ProblemID:       contestProblem.ProblemID,
+		UserID:          user.ID,
+		ContestID:       &contest.ID,
+		Code:            req.SourceCode,
+		Language:        language,
+		LanguageVersion: &languageVersion,
+		Status:          "pending",
+		CodeSizeBytes:   &codeSizeBytes,
+	}
+
+	// Keep the answers zip next to the submission's other judging files
+	if outputOnly {
+		answersKey, err := uploadAnswers(submissionID, answers)
+		if err != nil {
+			log.Printf("[CONTEST] Failed to upload answers for submission %s: %v", submissionID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload answers"})
+			return
+		}
+		submission.AnswersPath = &answersKey
+	}
+
+	if err := repository.CreateSubmission(&submission); err != nil {
+		log.Printf("[CONTEST] Failed to create submission: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create submission"})
+		return
+	}
+
+	// Show the submission as pending on the scoreboard
+	if err := repository.RefreshContestScore(contestID, user.ID, contestProblem.ProblemID); err != nil {
+		log.Printf("[CONTEST] Failed to update scoreboard for submission %s: %v", submissionID, err)
+	}
+
+	// Enqueue judge job; the worker resolves the contest overrides from the submission
+	judgeJob := &queue.JudgeJob{
+		SubmissionID: submissionID,
+		Priority:     queue.PriorityContest,
+	}
 
-	// Note: This is a simplified version. In production, you would:
-	// 1. Store the code in MinIO or another storage
-	// 2. Create a submission record in the database
-	// 3. Queue the submission for judging
-	// For now, we'll return a placeholder response
+	streamID, err := queue.EnqueueJudgeJob(context.Background(), judgeJob)
+	if err != nil {
+		// The submission is saved, so still report success
+		log.Printf("[CONTEST] Failed to enqueue judge job for submission %s: %v", submissionID, err)
+	} else {
+		log.Printf("[CONTEST] Judge job enqueued: submissionID=%s, streamID=%s", submissionID, streamID)
+	}
 
 	c.JSON(http.StatusCreated, gin.H{
 		"id":           submissionID,
 		"contest_id":   contestID,
-		"problem_id":   problemID,
+		"problem_id":   contestProblem.ProblemID,
 		"status":       "pending",
-		"submitted_at": time.Now(),
+		"submitted_at": submission.SubmittedAt,
 		"message":      "Submission received and will be judged shortly",
 	})
 }
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"codehustle/backend/internal/constants"
+	"codehustle/backend/internal/middleware"
+	"codehustle/backend/internal/models"
+	"codehustle/backend/internal/repository"
+)
+
+// GetContestScoreboard returns the ranked scoreboard of a contest. While
+// the contest is frozen only admins and the contest creator see results
+// submitted after the freeze.
+func GetContestScoreboard(c *gin.Context) {
+	contest, user, ok := scoreboardContest(c)
+	if !ok {
+		return
+	}
+
+	canViewAll := constants.HasRole(user.Roles, constants.RoleAdmin) || contest.CreatedBy == user.ID
+	writeScoreboard(c, contest, contest.IsFrozen() && !canViewAll)
+}
+
+// GetFrozenContestScoreboard returns the scoreboard as the public sees it,
+// e.g. for showing the board while admins reveal results
+func GetFrozenContestScoreboard(c *gin.Context) {
+	contest, _, ok := scoreboardContest(c)
+	if !ok {
+		return
+	}
+
+	writeScoreboard(c, contest, contest.IsFrozen())
+}
+
+// scoreboardContest loads the contest of a scoreboard request the user can see
+func scoreboardContest(c *gin.Context) (*models.Contest, middleware.UserContext, bool) {
+	contestID := c.Param("id")
+
+	userCtx, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return nil, middleware.UserContext{}, false
+	}
+
+	user, ok := userCtx.(middleware.UserContext)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid user context"})
+		return nil, middleware.UserContext{}, false
+	}
+
+	log.Printf("[CONTEST] Scoreboard: contestID=%s, userID=%s", contestID, user.ID)
+
+	contest, _, err := repository.GetContest(contestID, user.ID, getPrimaryRole(user.Roles))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Contest not found"})
+		return nil, middleware.UserContext{}, false
+	}
+	return contest, user, true
+}
+
+// validFreezeTime reports whether a scoreboard freeze time, if set, falls
+// within the contest
+func validFreezeTime(freezeAt *time.Time, startAt, endAt time.Time) bool {
+	return freezeAt == nil || (!freezeAt.Before(startAt) && !freezeAt.After(endAt))
+}
+
+// scoreboardChanged reports whether a contest update invalidates its
+// scoreboard cells
+func scoreboardChanged(before, after *models.Contest) bool {
+	return !before.StartAt.Equal(after.StartAt) ||
+		!before.EndAt.Equal(after.EndAt) ||
+		before.RuleType != after.RuleType ||
+		!sameTime(before.FreezeAt, after.FreezeAt)
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func writeScoreboard(c *gin.Context, contest *models.Contest, frozen bool) {
+	board, err := repository.GetContestScoreboard(contest, frozen)
+	if err != nil {
+		log.Printf("[CONTEST] Failed to build scoreboard: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load scoreboard"})
+		return
+	}
+
+	c.JSON(http.StatusOK, board)
+}
+
+// AdminRebuildContestScoreboard recomputes every scoreboard cell of a
+// contest from its submissions (Admin only)
+func AdminRebuildContestScoreboard(c *gin.Context) {
+	contestID := c.Param("id")
+	if _, _, err := repository.GetContest(contestID, "", constants.RoleAdmin); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "contest_not_found",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	cells, err := repository.RebuildContestScores(contestID)
+	if err != nil {
+		log.Printf("[CONTEST] Failed to rebuild scoreboard for contest %s: %v", contestID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "failed_to_rebuild_scoreboard",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	log.Printf("[CONTEST] Rebuilt scoreboard for contest %s: %d cells", contestID, cells)
+	c.JSON(http.StatusOK, gin.H{
+		"contest_id": contestID,
+		"cells":      cells,
+	})
+}
+
+// AdminRevealNextContestScore reveals the next frozen result of an ended
+// contest and returns the updated public scoreboard (Admin only). Once
+// nothing is hidden the contest is unfrozen and done is true.
+func AdminRevealNextContestScore(c *gin.Context) {
+	contest, ok := frozenContestForReveal(c)
+	if !ok {
+		return
+	}
+
+	cell, err := repository.RevealNextContestScore(contest)
+	if err != nil {
+		log.Printf("[CONTEST] Failed to reveal next result for contest %s: %v", contest.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "failed_to_reveal",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	board, err := repository.GetContestScoreboard(contest, contest.IsFrozen())
+	if err != nil {
+		log.Printf("[CONTEST] Failed to build scoreboard: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "failed_to_load_scoreboard",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if cell != nil {
+		log.Printf("[CONTEST] Revealed contest %s: user=%s, problem=%s", contest.ID, cell.UserID, cell.ProblemID)
+	} else {
+		log.Printf("[CONTEST] All results of contest %s revealed", contest.ID)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"revealed":   cell,
+		"done":       cell == nil,
+		"scoreboard": board,
+	})
+}
+
+// AdminUnfreezeContestScoreboard reveals every frozen result of an ended
+// contest at once (Admin only)
+func AdminUnfreezeContestScoreboard(c *gin.Context) {
+	contest, ok := frozenContestForReveal(c)
+	if !ok {
+		return
+	}
+
+	if err := repository.UnfreezeContest(contest); err != nil {
+		log.Printf("[CONTEST] Failed to unfreeze contest %s: %v", contest.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "failed_to_unfreeze",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	log.Printf("[CONTEST] Unfroze scoreboard of contest %s", contest.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"contest_id":  contest.ID,
+		"unfrozen_at": contest.UnfrozenAt,
+	})
+}
+
+// frozenContestForReveal loads a contest whose frozen results may be
+// revealed: it has ended and is still frozen
+func frozenContestForReveal(c *gin.Context) (*models.Contest, bool) {
+	contestID := c.Param("id")
+	contest, _, err := repository.GetContest(contestID, "", constants.RoleAdmin)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "contest_not_found",
+			"message": err.Error(),
+		})
+		return nil, false
+	}
+
+	if contest.Status() != "ended" {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "contest_not_ended",
+			"message": "Results can only be revealed after the contest ends",
+		})
+		return nil, false
+	}
+	if !contest.IsFrozen() {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "scoreboard_not_frozen",
+			"message": "The scoreboard of this contest is not frozen",
+		})
+		return nil, false
+	}
+	return contest, true
+}
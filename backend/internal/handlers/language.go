@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"codehustle/backend/internal/languages"
+)
+
+// LanguageResponse describes a language accepted for submissions
+type LanguageResponse struct {
+	ID             string   `json:"id"`
+	Name           string   `json:"name"`
+	DefaultVersion string   `json:"default_version"`
+	Versions       []string `json:"versions"`
+	Extensions     []string `json:"extensions"`
+	Entrypoint     string   `json:"entrypoint"`
+}
+
+// ListLanguages returns the languages and versions submissions may use.
+// Versions is empty while the installed versions are unknown.
+func ListLanguages(c *gin.Context) {
+	response := []LanguageResponse{}
+	for _, lang := range languages.All() {
+		if lang.Disabled {
+			continue
+		}
+		versions := lang.Versions
+		if versions == nil {
+			versions = []string{}
+		}
+		response = append(response, LanguageResponse{
+			ID:             lang.ID,
+			Name:           lang.Name,
+			DefaultVersion: lang.DefaultVersion,
+			Versions:       versions,
+			Extensions:     lang.Extensions,
+			Entrypoint:     lang.Entrypoint,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"languages": response})
+}
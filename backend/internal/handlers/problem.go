@@ -81,6 +81,9 @@ type GetProblemResponse struct {
 	Points                 *float64                `json:"points,omitempty"`                   // optional (null if not used)
 	Organizations          []string                `json:"organizations,omitempty"`            // optional
 	Body                   string                  `json:"body"`                               // problem statement content
+	IOMode                 string                  `json:"io_mode"`                            // stdio, file or output_only
+	InputFile              string                  `json:"input_file,omitempty"`               // file mode only
+	OutputFile             string                  `json:"output_file,omitempty"`              // file mode only
 }
 
 type LanguageResourceLimit struct {
@@ -128,6 +131,16 @@ func GetProblem(c *gin.Context) {
 		TimeLimit:   problem.TimeLimitMs / 1000,          // Convert ms to seconds (int)
 		MemoryLimit: int64(problem.MemoryLimitKb) * 1024, // Convert kb to bytes
 		Body:        string(statementContent),
+		IOMode:      models.IOModeStdio,
+	}
+
+	// Tell participants where to read and write
+	if judge, err := repository.GetProblemJudgeByProblemID(problem.ID); err == nil && judge.IOMode != "" {
+		response.IOMode = judge.IOMode
+		if judge.UsesFiles() {
+			response.InputFile = judge.InputFileName()
+			response.OutputFile = judge.OutputFileName()
+		}
 	}
 
 	c.JSON(http.StatusOK, response)
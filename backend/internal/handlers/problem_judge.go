@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"codehustle/backend/internal/languages"
+	"codehustle/backend/internal/models"
+	"codehustle/backend/internal/repository"
+)
+
+// ProblemJudgeRequest configures how submissions to a problem are judged.
+// Paths are MinIO keys in the problem checkers bucket.
+type ProblemJudgeRequest struct {
+	CheckerKind         string          `json:"checker_kind" binding:"required"` // diff, token, float_abs, float_rel or custom
+	CheckerCustomPath   *string         `json:"checker_custom_path"`
+	CheckerArgs         json.RawMessage `json:"checker_args"` // e.g. {"epsilon": 1e-6}
+	CheckerRuntimeImage *string         `json:"checker_runtime_image"`
+	CheckerVersion      *string         `json:"checker_version"`
+
+	JudgeMode               string  `json:"judge_mode"` // standard (default) or interactive
+	InteractorPath          *string `json:"interactor_path"`
+	InteractorRuntimeImage  *string `json:"interactor_runtime_image"`
+	InteractorVersion       *string `json:"interactor_version"`
+	InteractorTimeLimitMs   *int    `json:"interactor_time_limit_ms"`
+	InteractorMemoryLimitKb *int    `json:"interactor_memory_limit_kb"`
+
+	IOMode     string  `json:"io_mode"`     // stdio (default), file or output_only
+	InputFile  *string `json:"input_file"`  // file mode, defaults to input.txt
+	OutputFile *string `json:"output_file"` // file mode, defaults to output.txt
+
+	// GraderFiles lists the files compiled with each submission, per
+	// language, e.g. {"cpp": ["problems/1/grader.cpp", "problems/1/grader.h"]}
+	GraderFiles json.RawMessage `json:"grader_files"`
+}
+
+// AdminGetProblemJudge returns the judge configuration of a problem (Admin only)
+func AdminGetProblemJudge(c *gin.Context) {
+	problem, err := repository.GetProblem(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "problem_not_found",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	judge, err := repository.NewProblemJudgeRepository().GetByProblemID(c.Request.Context(), problem.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// Problems without a configuration are judged with the diff checker
+		c.JSON(http.StatusOK, models.ProblemJudge{
+			ProblemID:   problem.ID,
+			CheckerKind: "diff",
+			JudgeMode:   models.JudgeModeStandard,
+			IOMode:      models.IOModeStdio,
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("[PROBLEM_JUDGE] Failed to load judge config for problem %s: %v", problem.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "failed_to_load_judge_config",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, judge)
+}
+
+// AdminUpdateProblemJudge sets the judge configuration of a problem.
+// Interactive problems need an interactor; their checker settings are kept
+// but unused. (Admin only)
+func AdminUpdateProblemJudge(c *gin.Context) {
+	problem, err := repository.GetProblem(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "problem_not_found",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	var req ProblemJudgeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := validateProblemJudgeRequest(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_judge_config",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	repo := repository.NewProblemJudgeRepository()
+	judge, err := repo.GetByProblemID(c.Request.Context(), problem.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		judge = &models.ProblemJudge{ID: uuid.NewString(), ProblemID: problem.ID}
+	} else if err != nil {
+		log.Printf("[PROBLEM_JUDGE] Failed to load judge config for problem %s: %v", problem.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "failed_to_load_judge_config",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	judge.CheckerKind = req.CheckerKind
+	judge.CheckerCustomPath = req.CheckerCustomPath
+	judge.CheckerArgs = nil
+	if len(req.CheckerArgs) > 0 && string(req.CheckerArgs) != "null" {
+		args := string(req.CheckerArgs)
+		judge.CheckerArgs = &args
+	}
+	judge.CheckerRuntimeImage = req.CheckerRuntimeImage
+	judge.CheckerVersion = req.CheckerVersion
+	judge.JudgeMode = req.JudgeMode
+	judge.InteractorPath = req.InteractorPath
+	judge.InteractorRuntimeImage = req.InteractorRuntimeImage
+	judge.InteractorVersion = req.InteractorVersion
+	judge.InteractorTimeLimitMs = req.InteractorTimeLimitMs
+	judge.InteractorMemoryLimitKb = req.InteractorMemoryLimitKb
+	judge.IOMode = req.IOMode
+	judge.InputFile = req.InputFile
+	judge.OutputFile = req.OutputFile
+	judge.GraderFiles = nil
+	if len(req.GraderFiles) > 0 && string(req.GraderFiles) != "null" {
+		graders := string(req.GraderFiles)
+		judge.GraderFiles = &graders
+	}
+
+	if err := repo.Upsert(c.Request.Context(), judge); err != nil {
+		log.Printf("[PROBLEM_JUDGE] Failed to save judge config for problem %s: %v", problem.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "failed_to_save_judge_config",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	log.Printf("[PROBLEM_JUDGE] Updated judge config for problem %s: checker=%s, mode=%s, io=%s", problem.ID, judge.CheckerKind, judge.JudgeMode, judge.IOMode)
+	c.JSON(http.StatusOK, judge)
+}
+
+// validateProblemJudgeRequest checks a judge configuration and fills in the
+// default judge and I/O modes
+func validateProblemJudgeRequest(req *ProblemJudgeRequest) error {
+	switch req.CheckerKind {
+	case "diff", "token", "float_abs", "float_rel":
+	case "custom":
+		if req.CheckerCustomPath == nil || *req.CheckerCustomPath == "" {
+			return fmt.Errorf("checker_custom_path is required for a custom checker")
+		}
+	default:
+		return fmt.Errorf("unknown checker_kind %q", req.CheckerKind)
+	}
+
+	if len(req.CheckerArgs) > 0 && !json.Valid(req.CheckerArgs) {
+		return fmt.Errorf("checker_args must be valid JSON")
+	}
+
+	switch req.JudgeMode {
+	case "":
+		req.JudgeMode = models.JudgeModeStandard
+	case models.JudgeModeStandard:
+	case models.JudgeModeInteractive:
+		if req.InteractorPath == nil || *req.InteractorPath == "" {
+			return fmt.Errorf("interactor_path is required for interactive problems")
+		}
+	default:
+		return fmt.Errorf("unknown judge_mode %q (use standard or interactive)", req.JudgeMode)
+	}
+
+	if req.InteractorTimeLimitMs != nil && *req.InteractorTimeLimitMs <= 0 {
+		return fmt.Errorf("interactor_time_limit_ms must be positive")
+	}
+	if req.InteractorMemoryLimitKb != nil && *req.InteractorMemoryLimitKb <= 0 {
+		return fmt.Errorf("interactor_memory_limit_kb must be positive")
+	}
+
+	switch req.IOMode {
+	case "":
+		req.IOMode = models.IOModeStdio
+	case models.IOModeStdio, models.IOModeFile, models.IOModeOutputOnly:
+	default:
+		return fmt.Errorf("unknown io_mode %q (use stdio, file or output_only)", req.IOMode)
+	}
+	if req.JudgeMode == models.JudgeModeInteractive && req.IOMode != models.IOModeStdio {
+		return fmt.Errorf("interactive problems must use stdio")
+	}
+	if req.InputFile != nil && !validIOFileName(*req.InputFile) {
+		return fmt.Errorf("input_file must be a plain file name")
+	}
+	if req.OutputFile != nil && !validIOFileName(*req.OutputFile) {
+		return fmt.Errorf("output_file must be a plain file name")
+	}
+
+	if len(req.GraderFiles) > 0 && string(req.GraderFiles) != "null" {
+		var graders map[string][]string
+		if err := json.Unmarshal(req.GraderFiles, &graders); err != nil {
+			return fmt.Errorf("grader_files must map languages to lists of paths")
+		}
+		for language, paths := range graders {
+			if lang, ok := languages.Get(language); !ok || lang.ID != language {
+				return fmt.Errorf("grader_files: unknown language %q", language)
+			}
+			names := make(map[string]bool, len(paths))
+			for _, p := range paths {
+				name := path.Base(p)
+				if p == "" || names[name] {
+					return fmt.Errorf("grader_files: %s has an empty or duplicate file name", language)
+				}
+				names[name] = true
+			}
+		}
+	}
+	return nil
+}
+
+// validIOFileName reports whether name is a file name of at most 100
+// characters without a directory part
+func validIOFileName(name string) bool {
+	return name != "" && len(name) <= 100 && name != "." && name != ".." && !strings.ContainsAny(name, "/\\\x00")
+}
@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"io"
 	"log"
 	"mime/multipart"
@@ -13,6 +15,8 @@ import (
 	"github.com/google/uuid"
 
 	"codehustle/backend/internal/constants"
+	"codehustle/backend/internal/judge"
+	"codehustle/backend/internal/languages"
 	"codehustle/backend/internal/middleware"
 	"codehustle/backend/internal/models"
 	"codehustle/backend/internal/queue"
@@ -20,10 +24,12 @@ import (
 	"codehustle/backend/internal/storage"
 )
 
-// SubmitProblemRequest represents the expected payload for submitting a problem solution
+// SubmitProblemRequest represents the expected payload for submitting a problem solution.
+// Several code_file parts or a .zip make a multi-file submission. For output-only
+// problems code_file is a zip of answer files and language is not needed.
 type SubmitProblemRequest struct {
 	CodeFile        *multipart.FileHeader `form:"code_file" binding:"required"`
-	Language        string                `form:"language" binding:"required"`
+	Language        string                `form:"language"`
 	LanguageVersion string                `form:"language_version"`
 	CourseID        string                `form:"course_id"`
 	ContestID       string                `form:"contest_id"`
@@ -38,6 +44,29 @@ type SubmitProblemResponse struct {
 	Message     string `json:"message"`
 }
 
+// maxAnswersZipSize limits the answers zip of an output-only submission
+const maxAnswersZipSize = 16 * 1024 * 1024
+
+// supportedLanguage returns the registry ID of a language and version
+// accepted for submissions, resolving aliases such as "c++"
+func supportedLanguage(language, version string) (string, bool) {
+	if !languages.Accepts(language, version) {
+		return "", false
+	}
+	lang, _ := languages.Get(language)
+	return lang.ID, true
+}
+
+// uploadAnswers stores the answers zip of an output-only submission and
+// returns its object key
+func uploadAnswers(submissionID string, data []byte) (string, error) {
+	answersKey := fmt.Sprintf("submissions/%s/answers.zip", submissionID)
+	if err := storage.UploadFile(storage.GetTestCasesBucket(), answersKey, bytes.NewReader(data), int64(len(data)), "application/zip"); err != nil {
+		return "", err
+	}
+	return answersKey, nil
+}
+
 // SubmitProblem handles code submission for a problem
 func SubmitProblem(c *gin.Context) {
 	identifier := c.Param("id")
@@ -102,8 +131,32 @@ func SubmitProblem(c *gin.Context) {
 	log.Printf("[SUBMIT] Request parsed: language=%s, language_version=%s, course_id=%s, contest_id=%s, file_size=%d",
 		req.Language, req.LanguageVersion, req.CourseID, req.ContestID, req.CodeFile.Size)
 
+	// Contest submissions must pass the contest's checks, so they only go
+	// through SubmitContestProblem
+	if req.ContestID != "" {
+		log.Printf("[SUBMIT] Error: contest_id sent to the problem endpoint: %s", req.ContestID)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "use_contest_submit",
+			"message": "Submit contest solutions to /contests/:id/problems/:problem_id/submit",
+		})
+		return
+	}
+
+	// Output-only problems take a zip of answers instead of code
+	judgeConfig, _ := repository.GetProblemJudgeByProblemID(problem.ID)
+	outputOnly := judgeConfig != nil && judgeConfig.IsOutputOnly()
+
+	if outputOnly && req.CodeFile.Size > maxAnswersZipSize {
+		log.Printf("[SUBMIT] Error: answers too large: %d bytes", req.CodeFile.Size)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "file_too_large",
+			"message": "Answers zip exceeds maximum size of 16MB",
+		})
+		return
+	}
+
 	// Validate file size (max 1MB)
-	if req.CodeFile.Size > 1024*1024 {
+	if !outputOnly && req.CodeFile.Size > 1024*1024 {
 		log.Printf("[SUBMIT] Error: file too large: %d bytes", req.CodeFile.Size)
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "file_too_large",
@@ -132,37 +185,70 @@ func SubmitProblem(c *gin.Context) {
 		})
 		return
 	}
+
 	codeContent := string(codeBytes)
-	log.Printf("[SUBMIT] Code file read: %d bytes, first 100 chars: %s", len(codeBytes),
-		func() string {
-			if len(codeContent) > 100 {
-				return codeContent[:100] + "..."
-			}
-			return codeContent
-		}())
-
-	// Validate language (basic validation)
-	supportedLanguages := []string{"cpp", "python", "java", "javascript", "go", "rust"}
-	languageValid := false
-	for _, lang := range supportedLanguages {
-		if strings.ToLower(req.Language) == lang {
-			languageValid = true
-			break
+	language := strings.ToLower(req.Language)
+	var sources []judge.SourceFile
+	if outputOnly {
+		// Unpack the answers once so a broken zip is rejected before judging
+		if _, err := judge.ReadAnswers(codeBytes); err != nil {
+			log.Printf("[SUBMIT] Error: invalid answers zip: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_answers",
+				"message": err.Error(),
+			})
+			return
+		}
+		codeContent = ""
+		language = models.LanguageAnswers
+		log.Printf("[SUBMIT] Answers zip read: %d bytes", len(codeBytes))
+	} else {
+		log.Printf("[SUBMIT] Code file read: %d bytes, first 100 chars: %s", len(codeBytes),
+			func() string {
+				if len(codeContent) > 100 {
+					return codeContent[:100] + "..."
+				}
+				return codeContent
+			}())
+
+		// Validate language and version against the registry
+		id, ok := supportedLanguage(language, req.LanguageVersion)
+		if !ok {
+			log.Printf("[SUBMIT] Error: unsupported language: %s %s", req.Language, req.LanguageVersion)
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "unsupported_language",
+				"message": "Language or language version not supported",
+			})
+			return
+		}
+		language = id
+		log.Printf("[SUBMIT] Language validated: %s", req.Language)
+
+		// Several code files or a zip make a multi-file submission
+		sources, err = submittedSources(c, req.CodeFile.Filename, codeBytes, language, judgeConfig)
+		if err != nil {
+			log.Printf("[SUBMIT] Error: invalid code files: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_code_files",
+				"message": err.Error(),
+			})
+			return
+		}
+		if sources != nil {
+			codeContent = sourcesBundle(sources)
+			log.Printf("[SUBMIT] Multi-file submission: %d files", len(sources))
 		}
 	}
-	if !languageValid {
-		log.Printf("[SUBMIT] Error: unsupported language: %s", req.Language)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "unsupported_language",
-			"message": "Language not supported",
-		})
-		return
-	}
-	log.Printf("[SUBMIT] Language validated: %s", req.Language)
 
 	// Create submission record
 	submissionID := uuid.NewString()
 	codeSizeBytes := len(codeBytes)
+	if sources != nil {
+		codeSizeBytes = 0
+		for _, f := range sources {
+			codeSizeBytes += len(f.Content)
+		}
+	}
 	languageVersion := req.LanguageVersion
 	if languageVersion == "" {
 		languageVersion = "latest" // Default version
@@ -173,19 +259,46 @@ func SubmitProblem(c *gin.Context) {
 		ProblemID:       problem.ID,
 		UserID:          userCtxVal.ID,
 		Code:            codeContent,
-		Language:        strings.ToLower(req.Language),
+		Language:        language,
 		LanguageVersion: &languageVersion,
 		Status:          "pending",
 		CodeSizeBytes:   &codeSizeBytes,
 	}
 
+	// Keep the answers zip next to the submission's other judging files
+	if outputOnly {
+		answersKey, err := uploadAnswers(submissionID, codeBytes)
+		if err != nil {
+			log.Printf("[SUBMIT] Error: failed to upload answers: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "failed_to_upload_answers",
+				"message": err.Error(),
+			})
+			return
+		}
+		submission.AnswersPath = &answersKey
+	}
+	if sources != nil {
+		sourcesKey := fmt.Sprintf("submissions/%s/sources.zip", submissionID)
+		data, err := zipSources(sources)
+		if err == nil {
+			err = storage.UploadFile(storage.GetTestCasesBucket(), sourcesKey, bytes.NewReader(data), int64(len(data)), "application/zip")
+		}
+		if err != nil {
+			log.Printf("[SUBMIT] Error: failed to upload sources: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "failed_to_upload_sources",
+				"message": err.Error(),
+			})
+			return
+		}
+		submission.SourcesPath = &sourcesKey
+	}
+
 	// Set optional fields
 	if req.CourseID != "" {
 		submission.CourseID = &req.CourseID
 	}
-	if req.ContestID != "" {
-		submission.ContestID = &req.ContestID
-	}
 
 	// Save submission to database
 	log.Printf("[SUBMIT] Creating submission record: ID=%s, ProblemID=%s, UserID=%s, Language=%s, CodeSize=%d",
@@ -200,24 +313,10 @@ func SubmitProblem(c *gin.Context) {
 	}
 	log.Printf("[SUBMIT] Submission record created successfully: %s", submissionID)
 
-	// Enqueue judge job to Redis Stream
+	// Enqueue judge job to Redis Stream; the worker reads the code from the submission
 	ctx := context.Background()
 	judgeJob := &queue.JudgeJob{
-		SubmissionID:    submissionID,
-		ProblemID:       problem.ID,
-		UserID:          userCtxVal.ID,
-		Code:            codeContent,
-		Language:        submission.Language,
-		LanguageVersion: languageVersion,
-	}
-
-	if req.CourseID != "" {
-		judgeJob.CourseID = req.CourseID
-		log.Printf("[SUBMIT] CourseID set: %s", req.CourseID)
-	}
-	if req.ContestID != "" {
-		judgeJob.ContestID = req.ContestID
-		log.Printf("[SUBMIT] ContestID set: %s", req.ContestID)
+		SubmissionID: submissionID,
 	}
 
 	log.Printf("[SUBMIT] Enqueueing judge job to Redis Stream...")
@@ -249,10 +348,13 @@ type SubmissionDetailResponse struct {
 	Language        string                 `json:"language"`
 	LanguageVersion *string                `json:"language_version,omitempty"`
 	Status          string                 `json:"status"`
+	TimeLimitMs     *int                   `json:"time_limit_ms,omitempty"`
+	MemoryLimitKb   *int                   `json:"memory_limit_kb,omitempty"`
 	CompileLog      *string                `json:"compile_log,omitempty"`
 	RunLog          *string                `json:"run_log,omitempty"`
 	SubmittedAt     string                 `json:"submitted_at"`
 	TestCaseResults []TestCaseResultDetail `json:"test_case_results"`
+	Subtasks        []SubtaskResultDetail  `json:"subtasks,omitempty"`
 	Summary         *SubmissionSummary     `json:"summary,omitempty"`
 }
 
@@ -270,12 +372,25 @@ type TestCaseResultDetail struct {
 	TimeMs    *int   `json:"time_ms,omitempty"`
 	MemoryKb  *int   `json:"memory_kb,omitempty"`
 	CreatedAt string `json:"created_at"`
+	// Comment from a custom checker, e.g. why an answer got partial credit
+	CheckerMessage *string `json:"checker_message,omitempty"`
 	// Test case details for wrong_answer cases
 	Input          *string `json:"input,omitempty"`
 	ExpectedOutput *string `json:"expected_output,omitempty"`
 	UserOutput     *string `json:"user_output,omitempty"`
 }
 
+// SubtaskResultDetail is the score of a submission on one subtask
+type SubtaskResultDetail struct {
+	SubtaskID string `json:"subtask_id"`
+	Name      string `json:"name"`
+	Ordinal   int    `json:"ordinal"`
+	Scoring   string `json:"scoring"`
+	Status    string `json:"status"`
+	Score     int    `json:"score"`
+	MaxScore  int    `json:"max_score"`
+}
+
 type SubmissionSummary struct {
 	TotalTests  int `json:"total_tests"`
 	PassedTests int `json:"passed_tests"`
@@ -331,6 +446,8 @@ func GetSubmission(c *gin.Context) {
 		Language:        submission.Language,
 		LanguageVersion: submission.LanguageVersion,
 		Status:          submission.Status,
+		TimeLimitMs:     submission.TimeLimitMs,
+		MemoryLimitKb:   submission.MemoryLimitKb,
 		SubmittedAt:     submission.SubmittedAt.Format("2006-01-02T15:04:05Z"),
 	}
 
@@ -392,13 +509,14 @@ func GetSubmission(c *gin.Context) {
 
 	for i, tc := range submission.TestCaseResults {
 		result := TestCaseResultDetail{
-			ID:        tc.ID,
-			IsSample:  tc.TestCase.IsSample,
-			Status:    tc.Status,
-			Score:     tc.Score,
-			TimeMs:    tc.TimeMs,
-			MemoryKb:  tc.MemoryKb,
-			CreatedAt: tc.CreatedAt.Format("2006-01-02T15:04:05Z"),
+			ID:             tc.ID,
+			IsSample:       tc.TestCase.IsSample,
+			Status:         tc.Status,
+			Score:          tc.Score,
+			TimeMs:         tc.TimeMs,
+			MemoryKb:       tc.MemoryKb,
+			CreatedAt:      tc.CreatedAt.Format("2006-01-02T15:04:05Z"),
+			CheckerMessage: tc.CheckerMessage,
 		}
 
 		// Add test case details for wrong_answer cases
@@ -453,6 +571,35 @@ func GetSubmission(c *gin.Context) {
 		}
 	}
 
+	// Add the per-subtask breakdown; subtask points replace the test scores
+	if isCompleted {
+		subtasks, err := repository.ListSubmissionSubtasks(submission.ID)
+		if err != nil {
+			log.Printf("[SUBMISSION] Failed to load subtask results for %s: %v", submission.ID, err)
+		}
+		if len(subtasks) > 0 {
+			response.Subtasks = make([]SubtaskResultDetail, len(subtasks))
+			totalScore, maxScore := 0, 0
+			for i, st := range subtasks {
+				response.Subtasks[i] = SubtaskResultDetail{
+					SubtaskID: st.SubtaskID,
+					Name:      st.Subtask.Name,
+					Ordinal:   st.Subtask.Ordinal,
+					Scoring:   st.Subtask.Scoring,
+					Status:    st.Status,
+					Score:     st.Score,
+					MaxScore:  st.Subtask.Points,
+				}
+				totalScore += st.Score
+				maxScore += st.Subtask.Points
+			}
+			if response.Summary != nil {
+				response.Summary.TotalScore = totalScore
+				response.Summary.MaxScore = maxScore
+			}
+		}
+	}
+
 	c.JSON(http.StatusOK, response)
 }
 
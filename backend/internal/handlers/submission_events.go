@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"codehustle/backend/internal/constants"
+	"codehustle/backend/internal/middleware"
+	"codehustle/backend/internal/models"
+	"codehustle/backend/internal/queue"
+	"codehustle/backend/internal/repository"
+)
+
+// sseHeartbeatInterval keeps idle event streams open through proxies
+const sseHeartbeatInterval = 15 * time.Second
+
+// CreateSubmissionStreamToken issues a short-lived single-use token for
+// opening the submission's event stream, passed as ?stream_token=
+func CreateSubmissionStreamToken(c *gin.Context) {
+	submissionID := c.Param("id")
+
+	userCtx, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing_user_context"})
+		return
+	}
+
+	userCtxVal, ok := userCtx.(middleware.UserContext)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_user_context"})
+		return
+	}
+
+	submission, err := repository.GetSubmissionByID(submissionID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "submission_not_found",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Same rule as GetSubmission: owners and privileged users only
+	isAdmin := constants.HasAnyRole(userCtxVal.Roles, constants.PrivilegedRoles)
+	if !isAdmin && submission.UserID != userCtxVal.ID {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "insufficient_permissions",
+			"message": "You can only view your own submissions",
+		})
+		return
+	}
+
+	token, err := middleware.IssueStreamToken(c.Request.Context(), userCtxVal, submission.ID)
+	if err != nil {
+		log.Printf("[SUBMISSION_EVENTS] Failed to issue stream token for submission %s: %v", submissionID, err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":   "events_unavailable",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":      token,
+		"expires_in": int(middleware.StreamTokenTTL.Seconds()),
+	})
+}
+
+// StreamSubmissionEvents streams judging progress of a submission as
+// Server-Sent Events. The current status is sent first, followed by status
+// transitions, per-test verdicts and the final verdict, after which the
+// stream ends. The request carries a token from CreateSubmissionStreamToken.
+func StreamSubmissionEvents(c *gin.Context) {
+	submissionID := c.Param("id")
+	if submissionID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing_submission_id"})
+		return
+	}
+
+	userCtx, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing_user_context"})
+		return
+	}
+
+	userCtxVal, ok := userCtx.(middleware.UserContext)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_user_context"})
+		return
+	}
+
+	// Subscribe before reading the submission so no transition is missed:
+	// the subscription is live once this returns, and a verdict reached
+	// before it shows in the submission read below
+	events, unsubscribe, err := queue.SubscribeSubmissionEvents(c.Request.Context(), submissionID)
+	if err != nil {
+		log.Printf("[SUBMISSION_EVENTS] Failed to subscribe to submission %s: %v", submissionID, err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":   "events_unavailable",
+			"message": err.Error(),
+		})
+		return
+	}
+	defer unsubscribe()
+
+	submission, err := repository.GetSubmissionByID(submissionID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "submission_not_found",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Same rule as GetSubmission: owners and privileged users only
+	isAdmin := constants.HasAnyRole(userCtxVal.Roles, constants.PrivilegedRoles)
+	if !isAdmin && submission.UserID != userCtxVal.ID {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "insufficient_permissions",
+			"message": "You can only view your own submissions",
+		})
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	snapshot := submissionSnapshot(submission)
+	c.SSEvent(snapshot.Type, snapshot)
+	c.Writer.Flush()
+	if snapshot.Type == queue.SubmissionEventFinal {
+		return
+	}
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-heartbeat.C:
+			// Events published while Redis reconnects are lost, so check
+			// whether judging finished in the meantime
+			if latest, err := repository.GetSubmissionByID(submissionID); err == nil {
+				if snapshot := submissionSnapshot(latest); snapshot.Type == queue.SubmissionEventFinal {
+					c.SSEvent(snapshot.Type, snapshot)
+					return false
+				}
+			}
+			_, err := io.WriteString(w, ": keepalive\n\n")
+			return err == nil
+		case event := <-events:
+			c.SSEvent(event.Type, event)
+			return event.Type != queue.SubmissionEventFinal
+		}
+	})
+}
+
+// submissionSnapshot describes a submission's current state as an event:
+// a status event while it is judged, the final event once it is done
+func submissionSnapshot(submission *models.Submission) queue.SubmissionEvent {
+	snapshot := queue.SubmissionEvent{
+		SubmissionID: submission.ID,
+		Type:         queue.SubmissionEventStatus,
+		Status:       submission.Status,
+		At:           time.Now().UTC(),
+	}
+	if submission.Status != "pending" && submission.Status != "running" {
+		snapshot.Type = queue.SubmissionEventFinal
+		snapshot.Score = submission.Score
+		snapshot.TimeMs = submission.ExecutionTime
+		snapshot.MemoryKb = submission.MemoryUsage
+	}
+	return snapshot
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"codehustle/backend/internal/judge"
+	"codehustle/backend/internal/languages"
+	"codehustle/backend/internal/models"
+)
+
+// Limits on multi-file submissions, matching the single-file limit
+const (
+	maxSourceFiles = 50
+	maxSourcesSize = 1024 * 1024
+)
+
+// submittedSources returns the files of a multi-file submission: several
+// code_file parts, or a single .zip. It returns nil for an ordinary
+// single-file submission. Unless the problem has grader files for the
+// language, the language's entrypoint must be among the files.
+func submittedSources(c *gin.Context, firstFile string, firstContent []byte, language string, judgeConfig *models.ProblemJudge) ([]judge.SourceFile, error) {
+	var files []judge.SourceFile
+
+	form, _ := c.MultipartForm()
+	switch {
+	case form != nil && len(form.File["code_file"]) > 1:
+		total := 0
+		for _, fh := range form.File["code_file"] {
+			f, err := fh.Open()
+			if err != nil {
+				return nil, fmt.Errorf("failed to open %s: %w", fh.Filename, err)
+			}
+			content, err := io.ReadAll(io.LimitReader(f, maxSourcesSize+1))
+			f.Close()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", fh.Filename, err)
+			}
+			total += len(content)
+			files = append(files, judge.SourceFile{Name: path.Base(fh.Filename), Content: string(content)})
+		}
+		if total > maxSourcesSize {
+			return nil, fmt.Errorf("code files exceed maximum size of 1MB")
+		}
+	case strings.HasSuffix(strings.ToLower(firstFile), ".zip"):
+		var err error
+		if files, err = judge.ReadSources(firstContent); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, nil
+	}
+
+	if len(files) > maxSourceFiles {
+		return nil, fmt.Errorf("too many code files (at most %d)", maxSourceFiles)
+	}
+	seen := make(map[string]bool, len(files))
+	for _, f := range files {
+		if seen[f.Name] {
+			return nil, fmt.Errorf("code file %s appears more than once", f.Name)
+		}
+		seen[f.Name] = true
+	}
+
+	lang, ok := languages.Get(language)
+	if !ok {
+		return nil, fmt.Errorf("language %q does not support multi-file submissions", language)
+	}
+	hasGraders := false
+	if judgeConfig != nil {
+		paths, _ := judgeConfig.GraderPaths(lang.ID)
+		hasGraders = len(paths) > 0
+	}
+	if !hasGraders && !seen[lang.Entrypoint] {
+		return nil, fmt.Errorf("%s submissions need a %s", lang.Name, lang.Entrypoint)
+	}
+
+	return files, nil
+}
+
+// sourcesBundle joins the files of a multi-file submission into one text
+// for display, each file under a header with its name
+func sourcesBundle(files []judge.SourceFile) string {
+	var b strings.Builder
+	for i, f := range files {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "==> %s <==\n%s", f.Name, f.Content)
+		if !strings.HasSuffix(f.Content, "\n") {
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// zipSources packs the files of a multi-file submission for storage
+func zipSources(files []judge.SourceFile) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range files {
+		w, err := zw.Create(f.Name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(w, f.Content); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
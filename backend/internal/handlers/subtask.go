@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"codehustle/backend/internal/models"
+	"codehustle/backend/internal/repository"
+)
+
+// SubtaskRequest describes one subtask of a problem
+type SubtaskRequest struct {
+	Name      string   `json:"name" binding:"required"`
+	Points    int      `json:"points"`
+	Scoring   string   `json:"scoring"`    // sum (default), min or all
+	TestCases []string `json:"test_cases"` // test case IDs or names
+	DependsOn []string `json:"depends_on"` // names of earlier subtasks
+}
+
+// ReplaceSubtasksRequest replaces all subtasks of a problem
+type ReplaceSubtasksRequest struct {
+	Subtasks []SubtaskRequest `json:"subtasks"`
+}
+
+// AdminListSubtasks returns the subtasks of a problem (Admin only)
+func AdminListSubtasks(c *gin.Context) {
+	problem, err := repository.GetProblem(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "problem_not_found",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	subtasks, err := repository.GetSubtasksByProblemID(problem.ID)
+	if err != nil {
+		log.Printf("[SUBTASK] Failed to list subtasks for problem %s: %v", problem.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "failed_to_list_subtasks",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"problem_id": problem.ID,
+		"items":      subtasks,
+	})
+}
+
+// AdminReplaceSubtasks replaces the subtasks of a problem. An empty list
+// goes back to per-test scoring. Existing submissions keep their total score
+// but lose their subtask breakdown until they are rejudged. (Admin only)
+func AdminReplaceSubtasks(c *gin.Context) {
+	problem, err := repository.GetProblem(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "problem_not_found",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	var req ReplaceSubtasksRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	testCases, err := repository.GetTestCasesByProblemID(problem.ID)
+	if err != nil {
+		log.Printf("[SUBTASK] Failed to load test cases for problem %s: %v", problem.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "failed_to_load_test_cases",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	subtasks, err := buildSubtasks(problem.ID, req.Subtasks, testCases)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_subtasks",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := repository.ReplaceProblemSubtasks(problem.ID, subtasks); err != nil {
+		log.Printf("[SUBTASK] Failed to save subtasks for problem %s: %v", problem.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "failed_to_save_subtasks",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	log.Printf("[SUBTASK] Replaced subtasks for problem %s: count=%d", problem.ID, len(subtasks))
+	c.JSON(http.StatusOK, gin.H{
+		"problem_id": problem.ID,
+		"items":      subtasks,
+	})
+}
+
+// buildSubtasks validates a subtask request against the problem's test
+// cases. Dependencies may only point to earlier subtasks, which rules out
+// cycles.
+func buildSubtasks(problemID string, reqs []SubtaskRequest, testCases []models.TestCase) ([]models.Subtask, error) {
+	testCaseIDs := make(map[string]string, 2*len(testCases))
+	for _, tc := range testCases {
+		testCaseIDs[tc.ID] = tc.ID
+		testCaseIDs[tc.Name] = tc.ID
+	}
+
+	subtaskIDs := make(map[string]string, len(reqs))
+	subtasks := make([]models.Subtask, 0, len(reqs))
+
+	for i, r := range reqs {
+		if r.Name == "" {
+			return nil, fmt.Errorf("subtask %d: name is required", i+1)
+		}
+		if _, dup := subtaskIDs[r.Name]; dup {
+			return nil, fmt.Errorf("subtask %q: duplicate name", r.Name)
+		}
+		if r.Points < 0 {
+			return nil, fmt.Errorf("subtask %q: points must not be negative", r.Name)
+		}
+
+		scoring := r.Scoring
+		switch scoring {
+		case "":
+			scoring = models.SubtaskScoringSum
+		case models.SubtaskScoringSum, models.SubtaskScoringMin, models.SubtaskScoringAll:
+		default:
+			return nil, fmt.Errorf("subtask %q: unknown scoring %q (use sum, min or all)", r.Name, r.Scoring)
+		}
+
+		st := models.Subtask{
+			ID:          uuid.NewString(),
+			ProblemID:   problemID,
+			Name:        r.Name,
+			Ordinal:     i + 1,
+			Points:      r.Points,
+			Scoring:     scoring,
+			TestCaseIDs: []string{},
+			DependsOn:   []string{},
+		}
+
+		seen := make(map[string]bool)
+		for _, ref := range r.TestCases {
+			id, ok := testCaseIDs[ref]
+			if !ok {
+				return nil, fmt.Errorf("subtask %q: unknown test case %q", r.Name, ref)
+			}
+			if !seen[id] {
+				seen[id] = true
+				st.TestCaseIDs = append(st.TestCaseIDs, id)
+			}
+		}
+
+		for _, dep := range r.DependsOn {
+			id, ok := subtaskIDs[dep]
+			if !ok {
+				return nil, fmt.Errorf("subtask %q: dependency %q must be an earlier subtask", r.Name, dep)
+			}
+			st.DependsOn = append(st.DependsOn, id)
+		}
+
+		subtaskIDs[r.Name] = st.ID
+		subtasks = append(subtasks, st)
+	}
+
+	return subtasks, nil
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
+	"codehustle/backend/internal/checker"
 	"codehustle/backend/internal/constants"
 	"codehustle/backend/internal/middleware"
 	"codehustle/backend/internal/models"
@@ -322,8 +323,18 @@ func BulkUploadTestCases(c *gin.Context) {
 	spj := false
 
 	// Process each ZIP file
+	type uploadedZip struct {
+		name      string
+		testCases []ProcessedTestCaseInfo
+	}
+	var zips []uploadedZip
 	var allTestCases []models.TestCase
 	var tempDirs []string // Track temp directories for cleanup
+	defer func() {
+		for _, tempDir := range tempDirs {
+			os.RemoveAll(tempDir)
+		}
+	}()
 
 	for _, zipFile := range req.Files {
 		// Validate file size (max 50MB per file)
@@ -339,6 +350,35 @@ func BulkUploadTestCases(c *gin.Context) {
 			continue
 		}
 		tempDirs = append(tempDirs, tempDir)
+		zips = append(zips, uploadedZip{name: zipFile.Filename, testCases: testCaseInfos})
+	}
+
+	// Run the problem's validator on every input before anything is stored
+	var inputs []checker.ValidationInput
+	for _, z := range zips {
+		for _, tcInfo := range z.testCases {
+			// An input that cannot be read cannot be validated, so nothing is stored
+			content, err := os.ReadFile(tcInfo.InputPath)
+			if err != nil {
+				log.Printf("[TEST_CASE] Failed to read input file %s: %v", tcInfo.InputPath, err)
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error":   "failed_to_read_input",
+					"message": fmt.Sprintf("failed to read %s/%s: %v", z.name, filepath.Base(tcInfo.InputPath), err),
+				})
+				return
+			}
+			inputs = append(inputs, checker.ValidationInput{
+				Name:    z.name + "/" + filepath.Base(tcInfo.InputPath),
+				Content: string(content),
+			})
+		}
+	}
+	if !validateInputs(c, problemValidator(problemID), inputs) {
+		return
+	}
+
+	for _, z := range zips {
+		testCaseInfos := z.testCases
 
 		// Upload test case files to MinIO and create database records
 		bucketName := storage.GetTestCasesBucket()
@@ -406,11 +446,6 @@ func BulkUploadTestCases(c *gin.Context) {
 		}
 	}
 
-	// Clean up temporary directories
-	for _, tempDir := range tempDirs {
-		os.RemoveAll(tempDir)
-	}
-
 	// Create test cases in database
 	if len(allTestCases) > 0 {
 		if err := repository.CreateTestCasesBatch(allTestCases); err != nil {
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"codehustle/backend/internal/checker"
+	"codehustle/backend/internal/models"
+	"codehustle/backend/internal/repository"
+	"codehustle/backend/internal/storage"
+)
+
+// AdminValidateProblemTests runs the problem's validator on the input of
+// every stored test case and reports the verdict per test (Admin only)
+func AdminValidateProblemTests(c *gin.Context) {
+	problem, err := repository.GetProblem(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "problem_not_found",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	judge := problemValidator(problem.ID)
+	if judge == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "no_validator",
+			"message": "Problem has no validator configured",
+		})
+		return
+	}
+
+	testCases, err := repository.GetTestCasesByProblemID(problem.ID)
+	if err != nil {
+		log.Printf("[TEST_CASE] Failed to get test cases: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "failed_to_fetch_test_cases",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	bucketName := storage.GetTestCasesBucket()
+	inputs := make([]checker.ValidationInput, 0, len(testCases))
+	for _, tc := range testCases {
+		content, err := storage.GetFile(bucketName, tc.InputPath)
+		if err != nil {
+			log.Printf("[TEST_CASE] Failed to download input file %s: %v", tc.InputPath, err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "failed_to_fetch_test_input",
+				"message": fmt.Sprintf("%s: %v", tc.Name, err),
+			})
+			return
+		}
+		inputs = append(inputs, checker.ValidationInput{Name: tc.Name, Content: string(content)})
+	}
+
+	results, err := runValidator(c.Request.Context(), judge, inputs)
+	if err != nil {
+		log.Printf("[TEST_CASE] Validator failed for problem %s: %v", problem.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "validator_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	invalid := len(invalidInputs(results))
+	log.Printf("[TEST_CASE] Validated tests of problem %s: total=%d, invalid=%d", problem.ID, len(results), invalid)
+	c.JSON(http.StatusOK, gin.H{
+		"problem_id": problem.ID,
+		"total":      len(results),
+		"invalid":    invalid,
+		"results":    results,
+	})
+}
+
+// problemValidator returns the judge configuration of a problem if it has a
+// validator, nil otherwise
+func problemValidator(problemID string) *models.ProblemJudge {
+	judge, err := repository.GetProblemJudgeByProblemID(problemID)
+	if err != nil || judge.ValidatorPath == nil || *judge.ValidatorPath == "" {
+		return nil
+	}
+	return judge
+}
+
+// validateInputs runs the validator of judge on the inputs, if there is
+// one. It reports false after writing an error response, listing the
+// validator's message for every rejected file.
+func validateInputs(c *gin.Context, judge *models.ProblemJudge, inputs []checker.ValidationInput) bool {
+	if judge == nil || len(inputs) == 0 {
+		return true
+	}
+
+	results, err := runValidator(c.Request.Context(), judge, inputs)
+	if err != nil {
+		log.Printf("[TEST_CASE] Validator failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "validator_failed",
+			"message": err.Error(),
+		})
+		return false
+	}
+
+	if invalid := invalidInputs(results); len(invalid) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":   "invalid_test_inputs",
+			"message": fmt.Sprintf("%d of %d inputs were rejected by the validator", len(invalid), len(results)),
+			"results": invalid,
+		})
+		return false
+	}
+	return true
+}
+
+// runValidator runs the validator of judge on the inputs. Validator args
+// are stored as a JSON array of command-line arguments.
+func runValidator(ctx context.Context, judge *models.ProblemJudge, inputs []checker.ValidationInput) ([]checker.ValidationResult, error) {
+	var args []string
+	if judge.ValidatorArgs != nil && *judge.ValidatorArgs != "" {
+		if err := json.Unmarshal([]byte(*judge.ValidatorArgs), &args); err != nil {
+			return nil, fmt.Errorf("validator_args must be a JSON array of strings: %w", err)
+		}
+	}
+
+	runtimeImage := "gcc:14"
+	version := "gnu++17"
+	if judge.ValidatorRuntimeImage != nil {
+		runtimeImage = *judge.ValidatorRuntimeImage
+	}
+	if judge.ValidatorVersion != nil {
+		version = *judge.ValidatorVersion
+	}
+
+	return checker.NewService(storage.GetMinIOClient()).Validate(ctx, checker.ValidateRequest{
+		ValidatorPath: *judge.ValidatorPath,
+		Args:          args,
+		RuntimeImage:  runtimeImage,
+		Version:       version,
+		Inputs:        inputs,
+	})
+}
+
+// invalidInputs returns the results of inputs the validator rejected
+func invalidInputs(results []checker.ValidationResult) []checker.ValidationResult {
+	invalid := []checker.ValidationResult{}
+	for _, r := range results {
+		if !r.Valid {
+			invalid = append(invalid, r)
+		}
+	}
+	return invalid
+}
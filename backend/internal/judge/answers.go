@@ -0,0 +1,107 @@
+package judge
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// Limits on the answers zip of an output-only submission and the sources
+// zip of a multi-file one
+const (
+	maxAnswerFiles      = 1000
+	maxAnswersSizeBytes = 64 << 20
+	maxSourceFiles      = 50
+	maxSourcesSizeBytes = 1 << 20
+)
+
+// answerExtensions are tried after the bare test case name when looking up
+// the answer to a test
+var answerExtensions = []string{"", ".out", ".ans", ".txt"}
+
+// ReadAnswers unpacks the answers zip of an output-only submission into a
+// map from file name to content. Directories inside the zip are ignored, so
+// "answers/01.out" is the answer file "01.out".
+func ReadAnswers(data []byte) (map[string]string, error) {
+	files, err := readZip(data, path.Base, maxAnswerFiles, maxAnswersSizeBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	answers := make(map[string]string, len(files))
+	for _, f := range files {
+		answers[f.Name] = f.Content
+	}
+	return answers, nil
+}
+
+// ReadSources unpacks the sources zip of a multi-file submission. Paths
+// inside the zip are kept, relative to its root.
+func ReadSources(data []byte) ([]SourceFile, error) {
+	return readZip(data, path.Clean, maxSourceFiles, maxSourcesSizeBytes)
+}
+
+// readZip unpacks the files of a zip, naming each with name(path in zip)
+func readZip(data []byte, name func(string) string, maxFiles int, maxSize int64) ([]SourceFile, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("not a zip file: %w", err)
+	}
+
+	var files []SourceFile
+	seen := make(map[string]bool)
+	var total int64
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || strings.HasPrefix(f.Name, "__MACOSX/") {
+			continue
+		}
+		fileName := name(f.Name)
+		if fileName == "." || strings.HasPrefix(fileName, "/") || strings.HasPrefix(fileName, "../") {
+			return nil, fmt.Errorf("invalid file name %s", f.Name)
+		}
+		if seen[fileName] {
+			return nil, fmt.Errorf("file %s appears more than once", fileName)
+		}
+		seen[fileName] = true
+		if len(files) == maxFiles {
+			return nil, fmt.Errorf("too many files (at most %d)", maxFiles)
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", f.Name, err)
+		}
+		// Read one byte past the remaining budget to detect an oversized zip
+		content, err := io.ReadAll(io.LimitReader(rc, maxSize-total+1))
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
+		}
+		total += int64(len(content))
+		if total > maxSize {
+			return nil, fmt.Errorf("zip exceeds %d MB when unpacked", maxSize>>20)
+		}
+		files = append(files, SourceFile{Name: fileName, Content: string(content)})
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("zip is empty")
+	}
+	return files, nil
+}
+
+// AnswerFor finds the answer to a test case under the first of its names
+// that has one, with or without an .out, .ans or .txt extension
+func AnswerFor(answers map[string]string, names ...string) (string, bool) {
+	for _, name := range names {
+		for _, ext := range answerExtensions {
+			if answer, ok := answers[name+ext]; ok {
+				return answer, true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,100 @@
+package judge
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// zipOf builds a zip archive holding files, in the given order
+func zipOf(t *testing.T, files ...SourceFile) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range files {
+		w, err := zw.Create(f.Name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(f.Content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestReadAnswers(t *testing.T) {
+	answers, err := ReadAnswers(zipOf(t,
+		SourceFile{Name: "answers/"},
+		SourceFile{Name: "answers/01.out", Content: "3\n"},
+		SourceFile{Name: "02.ans", Content: "5\n"},
+		SourceFile{Name: "__MACOSX/answers/._01.out", Content: "junk"},
+	))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"01.out": "3\n", "02.ans": "5\n"}, answers)
+}
+
+func TestReadAnswersErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		err  string
+	}{
+		{name: "not a zip", data: []byte("3\n"), err: "not a zip file"},
+		{name: "empty", data: zipOf(t, SourceFile{Name: "answers/"}), err: "zip is empty"},
+		{name: "same name in two folders", data: zipOf(t, SourceFile{Name: "a/01.out"}, SourceFile{Name: "b/01.out"}), err: "01.out appears more than once"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadAnswers(tt.data)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestAnswerFor(t *testing.T) {
+	answers := map[string]string{
+		"sample":     "a",
+		"test_2.out": "b",
+		"3.ans":      "c",
+		"4.txt":      "d",
+	}
+
+	tests := []struct {
+		name   string
+		names  []string
+		answer string
+		ok     bool
+	}{
+		{name: "bare name", names: []string{"sample", "1"}, answer: "a", ok: true},
+		{name: "test case name", names: []string{"test_2", "2"}, answer: "b", ok: true},
+		{name: "position", names: []string{"test_3", "3"}, answer: "c", ok: true},
+		{name: "txt extension", names: []string{"test_4", "4"}, answer: "d", ok: true},
+		{name: "missing", names: []string{"test_5", "5"}, ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			answer, ok := AnswerFor(answers, tt.names...)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.answer, answer)
+		})
+	}
+}
+
+func TestReadSources(t *testing.T) {
+	sources, err := ReadSources(zipOf(t,
+		SourceFile{Name: "main.cpp", Content: "m"},
+		SourceFile{Name: "lib/util.h", Content: "u"},
+	))
+	require.NoError(t, err)
+	assert.Equal(t, []SourceFile{{Name: "main.cpp", Content: "m"}, {Name: "lib/util.h", Content: "u"}}, sources, "paths are kept")
+
+	_, err = ReadSources(zipOf(t, SourceFile{Name: "../main.cpp", Content: "m"}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid file name")
+}
@@ -1,40 +1,45 @@
 package judge
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"math"
-	"net/http"
 	"strconv"
 	"strings"
-	"time"
 
 	"codehustle/backend/internal/checker"
-	"codehustle/backend/internal/config"
+	"codehustle/backend/internal/executor"
+	"codehustle/backend/internal/languages"
 	"codehustle/backend/internal/models"
-	"codehustle/backend/internal/pistonlog"
-	"codehustle/backend/internal/storage"
 
 	"github.com/sirupsen/logrus"
-
-	"github.com/minio/minio-go/v7"
 )
 
-// ExecutionResult represents the result from Piston API
-type ExecutionResult struct {
-	Compile struct {
-		Code   int    `json:"code"`
-		Stdout string `json:"stdout"`
-		Stderr string `json:"stderr"`
-		Memory int    `json:"memory"`
-	} `json:"compile"`
-	Run struct {
-		Code   int    `json:"code"`
-		Stdout string `json:"stdout"`
-		Stderr string `json:"stderr"`
-		Memory int    `json:"memory"`
-	} `json:"run"`
+// ExecutionResult represents the result of compiling and running a program
+type ExecutionResult = executor.Result
+
+// StageResult is the outcome of a single compile or run stage
+type StageResult = executor.StageResult
+
+// CheckResult is a checker's judgement of one output
+type CheckResult struct {
+	Verdict string  // checker.VerdictOK, VerdictWrongAnswer, ...
+	Score   float64 // fraction of the test's points earned, 0 to 1
+	Message string  // checker comment, empty for built-in checkers
+}
+
+// Accepted reports whether the output earned full points
+func (r *CheckResult) Accepted() bool {
+	return r.Verdict == checker.VerdictOK
+}
+
+// builtinResult converts a built-in checker's answer into a CheckResult
+func builtinResult(accepted bool) *CheckResult {
+	if accepted {
+		return &CheckResult{Verdict: checker.VerdictOK, Score: 1}
+	}
+	return &CheckResult{Verdict: checker.VerdictWrongAnswer}
 }
 
 // Checker functions
@@ -115,11 +120,10 @@ func FloatRelChecker(output, expected string, epsilon float64) bool {
 	return true
 }
 
-func CustomChecker(minioClient *minio.Client, checkerPath string, input, output, expected string, runtimeImage, version, statementPath string) (bool, error) {
-	// Use checker service to compile and run checker via Piston
-	checkerService := checker.NewService(minioClient)
-
-	resp, err := checkerService.Check(checker.CheckRequest{
+// CustomChecker compiles and runs a custom checker through the submission's
+// checker service
+func CustomChecker(ctx context.Context, checkers *checker.Service, checkerPath string, input, output, expected string, runtimeImage, version, statementPath string) (*CheckResult, error) {
+	resp, err := checkers.Check(ctx, checker.CheckRequest{
 		CheckerPath:   checkerPath,
 		Input:         input,
 		Output:        output,
@@ -130,151 +134,65 @@ func CustomChecker(minioClient *minio.Client, checkerPath string, input, output,
 	})
 
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
 	if resp.Error != "" {
-		return false, fmt.Errorf("%s", resp.Error)
+		return nil, fmt.Errorf("%s", resp.Error)
 	}
 
-	return resp.Accepted, nil
+	return &CheckResult{
+		Verdict: resp.Verdict,
+		Score:   resp.Score,
+		Message: resp.Message,
+	}, nil
 }
 
-// ExecuteCode executes user code via Piston API
-func ExecuteCode(code, language, version, stdin string, timeLimitMs, memoryLimitKb int) (*ExecutionResult, error) {
-	pistonURL := config.Get("PISTON_URL")
-	if pistonURL == "" {
-		pistonURL = "http://127.0.0.1:3002"
-	}
-
-	// Determine file name based on language
-	// Use base filename without extension to avoid Piston adding duplicate extensions
-	fileName := "main"
-	switch language {
-	case "python":
-		fileName = "main.py"
-	case "java":
-		fileName = "Main.java"
-	case "cpp", "c++":
-		fileName = "main" // Piston will add .cpp extension automatically
-	default:
-		fileName = "main" // Default for C++
-	}
-
-	payload := map[string]interface{}{
-		"language":        language,
-		"version":         version,
-		"files":           []map[string]string{{"name": fileName, "content": code}},
-		"stdin":           stdin,
-		"args":            []string{},
-		"compile_timeout": 10000,
-		"run_timeout":     timeLimitMs,
-		"memory_limit":    memoryLimitKb * 1024,
-	}
-
-	body, err := json.Marshal(payload)
+// ExecuteCode compiles and runs user code with the configured executor
+func ExecuteCode(ctx context.Context, code, language, version, stdin string, timeLimitMs, memoryLimitKb int) (*ExecutionResult, error) {
+	exec, err := defaultExecutor()
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+		return nil, err
 	}
 
-	execURL := pistonURL + "/api/v2/execute"
 	logrus.WithFields(logrus.Fields{
-		"url":             execURL,
 		"language":        language,
 		"version":         version,
 		"time_limit_ms":   timeLimitMs,
 		"memory_limit_kb": memoryLimitKb,
 		"stdin_length":    len(stdin),
-	}).Debug("Piston request")
-
-	logrus.WithField("payload", string(body)).Debug("Piston request payload")
-
-	// Log request to file
-	pistonlog.LogRequest(execURL, payload, map[string]interface{}{
-		"language":        language,
-		"version":         version,
-		"time_limit_ms":   timeLimitMs,
-		"memory_limit_kb": memoryLimitKb,
-		"stdin_length":    len(stdin),
-		"type":            "code_execution",
+	}).Debug("Execute request")
+
+	return exec.Execute(ctx, executor.ExecuteRequest{
+		CompileRequest: executor.CompileRequest{
+			Language: language,
+			Version:  version,
+			Files:    []executor.File{{Name: sourceFileName(language), Content: code}},
+		},
+		RunRequest: executor.RunRequest{
+			Stdin:         stdin,
+			TimeLimitMs:   timeLimitMs,
+			MemoryLimitKb: memoryLimitKb,
+		},
 	})
-
-	req, err := http.NewRequest(http.MethodPost, execURL, strings.NewReader(string(body)))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		logrus.WithError(err).Error("Piston HTTP request error")
-		pistonlog.LogError(execURL, err, map[string]interface{}{
-			"language": language,
-			"version":  version,
-			"type":     "code_execution",
-		})
-		return nil, fmt.Errorf("piston execution error: %w", err)
-	}
-	defer resp.Body.Close()
-
-	logrus.WithFields(logrus.Fields{
-		"status_code": resp.StatusCode,
-		"status":      resp.Status,
-	}).Debug("Piston response status")
-
-	b, err := io.ReadAll(resp.Body)
-	if err != nil {
-		logrus.WithError(err).Error("Error reading Piston response body")
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
-	// Log response to file
-	pistonlog.LogResponse(execURL, resp.StatusCode, b, map[string]interface{}{
-		"language": language,
-		"version":  version,
-		"type":     "code_execution",
-	})
-
-	logrus.WithField("response_body", string(b)).Debug("Piston response body")
-
-	var result ExecutionResult
-	if err := json.Unmarshal(b, &result); err != nil {
-		logrus.WithError(err).Error("Error parsing Piston JSON response")
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	logrus.WithFields(logrus.Fields{
-		"compile_code":          result.Compile.Code,
-		"compile_stdout_length": len(result.Compile.Stdout),
-		"compile_stderr_length": len(result.Compile.Stderr),
-		"compile_memory":        result.Compile.Memory,
-		"run_code":              result.Run.Code,
-		"run_stdout_length":     len(result.Run.Stdout),
-		"run_stderr_length":     len(result.Run.Stderr),
-		"run_memory":            result.Run.Memory,
-	}).Debug("Piston response parsed")
-
-	logrus.WithField("compile_stdout", result.Compile.Stdout).Debug("Piston compile stdout")
-	logrus.WithField("compile_stderr", result.Compile.Stderr).Debug("Piston compile stderr")
-	logrus.WithField("run_stdout", result.Run.Stdout).Debug("Piston run stdout")
-	logrus.WithField("run_stderr", result.Run.Stderr).Debug("Piston run stderr")
-
-	return &result, nil
 }
 
-// CheckOutput checks output using the appropriate checker
-func CheckOutput(judge *models.ProblemJudge, output, expected, input, statementPath string) (bool, error) {
-	minioClient := storage.GetMinIOClient()
-	if minioClient == nil {
-		return false, fmt.Errorf("MinIO client not initialized")
+// sourceFileName determines the file name Piston should use for a language
+func sourceFileName(language string) string {
+	if lang, ok := languages.Get(language); ok {
+		return lang.SingleFileName()
 	}
+	return "main"
+}
 
+// CheckOutput checks output using the appropriate checker. Custom checkers
+// run through checkers, which the worker builds once per submission.
+func CheckOutput(ctx context.Context, checkers *checker.Service, judge *models.ProblemJudge, output, expected, input, statementPath string) (*CheckResult, error) {
 	switch judge.CheckerKind {
 	case "diff":
-		return DiffChecker(output, expected), nil
+		return builtinResult(DiffChecker(output, expected)), nil
 	case "token":
-		return TokenChecker(output, expected), nil
+		return builtinResult(TokenChecker(output, expected)), nil
 	case "float_abs":
 		epsilon := 1e-6
 		if judge.CheckerArgs != nil && *judge.CheckerArgs != "" {
@@ -285,7 +203,7 @@ func CheckOutput(judge *models.ProblemJudge, output, expected, input, statementP
 				}
 			}
 		}
-		return FloatAbsChecker(output, expected, epsilon), nil
+		return builtinResult(FloatAbsChecker(output, expected, epsilon)), nil
 	case "float_rel":
 		epsilon := 1e-6
 		if judge.CheckerArgs != nil && *judge.CheckerArgs != "" {
@@ -296,10 +214,13 @@ func CheckOutput(judge *models.ProblemJudge, output, expected, input, statementP
 				}
 			}
 		}
-		return FloatRelChecker(output, expected, epsilon), nil
+		return builtinResult(FloatRelChecker(output, expected, epsilon)), nil
 	case "custom":
 		if judge.CheckerCustomPath == nil {
-			return false, fmt.Errorf("custom checker path not provided")
+			return nil, fmt.Errorf("custom checker path not provided")
+		}
+		if checkers == nil {
+			return nil, fmt.Errorf("checker service not provided")
 		}
 		runtimeImage := "gcc:14"
 		version := "gnu++17"
@@ -309,8 +230,8 @@ func CheckOutput(judge *models.ProblemJudge, output, expected, input, statementP
 		if judge.CheckerVersion != nil {
 			version = *judge.CheckerVersion
 		}
-		return CustomChecker(minioClient, *judge.CheckerCustomPath, input, output, expected, runtimeImage, version, statementPath)
+		return CustomChecker(ctx, checkers, *judge.CheckerCustomPath, input, output, expected, runtimeImage, version, statementPath)
 	default:
-		return DiffChecker(output, expected), nil
+		return builtinResult(DiffChecker(output, expected)), nil
 	}
 }
@@ -0,0 +1,75 @@
+package judge
+
+import (
+	"context"
+	"fmt"
+
+	"codehustle/backend/internal/checker"
+	"codehustle/backend/internal/models"
+)
+
+// InteractiveResult is the outcome of running a program against an
+// interactor: how the program ran and what the interactor decided
+type InteractiveResult struct {
+	Run   StageResult
+	Check *CheckResult
+}
+
+// RunInteractive runs a compiled program against the problem's interactor on
+// a single test. The interactor's limits default to twice the program's time
+// limit, so a program that stops talking is stopped first, and the same
+// memory limit.
+func RunInteractive(ctx context.Context, checkers *checker.Service, judge *models.ProblemJudge, p *Program, input, expected string, timeLimitMs, memoryLimitKb int) (*InteractiveResult, error) {
+	if checkers == nil {
+		return nil, fmt.Errorf("checker service not provided")
+	}
+	if judge.InteractorPath == nil || *judge.InteractorPath == "" {
+		return nil, fmt.Errorf("interactor path not provided")
+	}
+
+	runtimeImage := "gcc:14"
+	version := "gnu++17"
+	if judge.InteractorRuntimeImage != nil {
+		runtimeImage = *judge.InteractorRuntimeImage
+	}
+	if judge.InteractorVersion != nil {
+		version = *judge.InteractorVersion
+	}
+
+	interactorTimeLimitMs := 2 * timeLimitMs
+	if judge.InteractorTimeLimitMs != nil && *judge.InteractorTimeLimitMs > 0 {
+		interactorTimeLimitMs = *judge.InteractorTimeLimitMs
+	}
+	interactorMemoryLimitKb := memoryLimitKb
+	if judge.InteractorMemoryLimitKb != nil && *judge.InteractorMemoryLimitKb > 0 {
+		interactorMemoryLimitKb = *judge.InteractorMemoryLimitKb
+	}
+
+	resp, err := checkers.Interact(ctx, checker.InteractRequest{
+		InteractorPath:          *judge.InteractorPath,
+		Program:                 p,
+		Input:                   input,
+		Expected:                expected,
+		RuntimeImage:            runtimeImage,
+		Version:                 version,
+		ProgramTimeLimitMs:      timeLimitMs,
+		ProgramMemoryLimitKb:    memoryLimitKb,
+		InteractorTimeLimitMs:   interactorTimeLimitMs,
+		InteractorMemoryLimitKb: interactorMemoryLimitKb,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("%s", resp.Error)
+	}
+
+	return &InteractiveResult{
+		Run: resp.Program,
+		Check: &CheckResult{
+			Verdict: resp.Verdict,
+			Score:   resp.Score,
+			Message: resp.Message,
+		},
+	}, nil
+}
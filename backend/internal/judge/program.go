@@ -0,0 +1,125 @@
+package judge
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"codehustle/backend/internal/executor"
+	"codehustle/backend/internal/languages"
+
+	"github.com/sirupsen/logrus"
+)
+
+// defaultExecutor returns the executor programs run on; tests replace it
+var defaultExecutor = executor.Default
+
+// Program is a submission compiled once and then run against many inputs
+type Program = executor.Artifact
+
+// SourceFile is a file of a submission or of the problem's grader
+type SourceFile = executor.File
+
+// CompileSources compiles a submission once, so that a compile error can end
+// judging before any test case runs, together with the problem's grader
+// files for the language. A single unnamed file is the
+// whole program, or the part a grader calls into when there is one. Grader
+// files replace submitted files of the same name.
+func CompileSources(ctx context.Context, files, graders []SourceFile, language, version string) (*Program, error) {
+	exec, err := defaultExecutor()
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"language": language,
+		"version":  version,
+		"files":    len(files),
+		"graders":  len(graders),
+	}).Debug("Compile request")
+
+	return exec.Compile(ctx, executor.CompileRequest{
+		Language: language,
+		Version:  version,
+		Files:    programFiles(files, graders, language),
+	})
+}
+
+// programFiles names a single-file submission and adds the grader files.
+// The entrypoint goes first, since Piston runs the first file it is sent.
+func programFiles(files, graders []SourceFile, language string) []SourceFile {
+	lang, known := languages.Get(language)
+
+	if len(files) == 1 && files[0].Name == "" {
+		name := sourceFileName(language)
+		if len(graders) > 0 && known {
+			name = lang.StubSource
+		}
+		files = []SourceFile{{Name: name, Content: files[0].Content}}
+	}
+
+	replaced := make(map[string]bool, len(graders))
+	for _, g := range graders {
+		replaced[g.Name] = true
+	}
+	merged := make([]SourceFile, 0, len(files)+len(graders))
+	merged = append(merged, graders...)
+	for _, f := range files {
+		if !replaced[f.Name] {
+			merged = append(merged, f)
+		}
+	}
+
+	if known {
+		sort.SliceStable(merged, func(i, j int) bool {
+			return merged[i].Name == lang.Entrypoint && merged[j].Name != lang.Entrypoint
+		})
+	}
+	return merged
+}
+
+// RunProgram runs a compiled program against a single input
+func RunProgram(ctx context.Context, p *Program, stdin string, timeLimitMs, memoryLimitKb int) (*ExecutionResult, error) {
+	exec, err := defaultExecutor()
+	if err != nil {
+		return nil, err
+	}
+
+	return exec.Run(ctx, p, executor.RunRequest{
+		Stdin:         stdin,
+		TimeLimitMs:   timeLimitMs,
+		MemoryLimitKb: memoryLimitKb,
+	})
+}
+
+// RunProgramWithFiles runs a compiled program that reads its input from
+// inputFile and writes its answer to outputFile in its working directory.
+// The answer takes the place of stdout in the result, so it is checked the
+// same way; a program that writes no file gave an empty answer.
+func RunProgramWithFiles(ctx context.Context, p *Program, input, inputFile, outputFile string, timeLimitMs, memoryLimitKb int) (*ExecutionResult, error) {
+	exec, err := defaultExecutor()
+	if err != nil {
+		return nil, err
+	}
+	if stager, ok := exec.(executor.RunFileStager); !ok || !stager.StagesRunFiles() {
+		return nil, fmt.Errorf("file input and output need an executor that stages run files (JUDGE_EXECUTOR=local)")
+	}
+
+	result, err := exec.Run(ctx, p, executor.RunRequest{
+		Files:         []executor.File{{Name: inputFile, Content: input}},
+		OutputFiles:   []string{outputFile},
+		TimeLimitMs:   timeLimitMs,
+		MemoryLimitKb: memoryLimitKb,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result.Run.Stdout = ""
+	for _, f := range result.OutputFiles {
+		if f.Name == outputFile {
+			result.Run.Stdout = f.Content
+		}
+	}
+	return result, nil
+}
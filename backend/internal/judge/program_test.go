@@ -0,0 +1,144 @@
+package judge
+
+import (
+	"context"
+	"testing"
+
+	"codehustle/backend/internal/executor"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeExecutor records the requests it gets and answers runs with result
+type fakeExecutor struct {
+	stagesRunFiles bool
+	result         executor.Result
+
+	compiles []executor.CompileRequest
+	runs     []executor.RunRequest
+}
+
+func (e *fakeExecutor) Compile(ctx context.Context, req executor.CompileRequest) (*executor.Artifact, error) {
+	e.compiles = append(e.compiles, req)
+	return &executor.Artifact{Language: req.Language, Version: req.Version, Files: req.Files}, nil
+}
+
+func (e *fakeExecutor) Run(ctx context.Context, artifact *executor.Artifact, req executor.RunRequest) (*executor.Result, error) {
+	e.runs = append(e.runs, req)
+	result := e.result
+	return &result, nil
+}
+
+func (e *fakeExecutor) Execute(ctx context.Context, req executor.ExecuteRequest) (*executor.Result, error) {
+	e.compiles = append(e.compiles, req.CompileRequest)
+	return e.Run(ctx, nil, req.RunRequest)
+}
+
+func (e *fakeExecutor) StagesRunFiles() bool { return e.stagesRunFiles }
+
+// useExecutor makes exec the executor programs run on for the test
+func useExecutor(t *testing.T, exec executor.Executor) {
+	t.Helper()
+	saved := defaultExecutor
+	defaultExecutor = func() (executor.Executor, error) { return exec, nil }
+	t.Cleanup(func() { defaultExecutor = saved })
+}
+
+func TestRunProgramWithFiles(t *testing.T) {
+	exec := &fakeExecutor{stagesRunFiles: true, result: executor.Result{
+		Run:         executor.StageResult{Stdout: "debug print\n"},
+		OutputFiles: []executor.File{{Name: "output.txt", Content: "42\n"}},
+	}}
+	useExecutor(t, exec)
+
+	result, err := RunProgramWithFiles(context.Background(), &Program{}, "6 7\n", "input.txt", "output.txt", 1000, 65536)
+	require.NoError(t, err)
+	assert.Equal(t, "42\n", result.Run.Stdout, "the output file is the answer")
+
+	require.Len(t, exec.runs, 1)
+	assert.Equal(t, []executor.File{{Name: "input.txt", Content: "6 7\n"}}, exec.runs[0].Files)
+	assert.Equal(t, []string{"output.txt"}, exec.runs[0].OutputFiles)
+	assert.Empty(t, exec.runs[0].Stdin)
+}
+
+func TestRunProgramWithFilesWithoutOutputFile(t *testing.T) {
+	useExecutor(t, &fakeExecutor{stagesRunFiles: true, result: executor.Result{Run: executor.StageResult{Stdout: "42\n"}}})
+
+	result, err := RunProgramWithFiles(context.Background(), &Program{}, "6 7\n", "input.txt", "output.txt", 1000, 65536)
+	require.NoError(t, err)
+	assert.Empty(t, result.Run.Stdout, "stdout does not count as the answer")
+}
+
+func TestRunProgramWithFilesNeedsStagingExecutor(t *testing.T) {
+	useExecutor(t, &fakeExecutor{})
+
+	_, err := RunProgramWithFiles(context.Background(), &Program{}, "6 7\n", "input.txt", "output.txt", 1000, 65536)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "JUDGE_EXECUTOR=local")
+}
+
+func TestProgramFiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    []SourceFile
+		graders  []SourceFile
+		language string
+		want     []SourceFile
+	}{
+		{
+			name:     "single file",
+			files:    []SourceFile{{Content: "print(1)"}},
+			language: "python",
+			want:     []SourceFile{{Name: "main.py", Content: "print(1)"}},
+		},
+		{
+			name:     "single file with a grader",
+			files:    []SourceFile{{Content: "int solve() {}"}},
+			graders:  []SourceFile{{Name: "main.cpp", Content: "grader"}, {Name: "solve.h", Content: "header"}},
+			language: "cpp",
+			want: []SourceFile{
+				{Name: "main.cpp", Content: "grader"},
+				{Name: "solve.h", Content: "header"},
+				{Name: "solution.cpp", Content: "int solve() {}"},
+			},
+		},
+		{
+			name:     "entrypoint first",
+			files:    []SourceFile{{Name: "util.py", Content: "u"}, {Name: "main.py", Content: "m"}},
+			language: "python",
+			want:     []SourceFile{{Name: "main.py", Content: "m"}, {Name: "util.py", Content: "u"}},
+		},
+		{
+			name:     "grader replaces a submitted file",
+			files:    []SourceFile{{Name: "Main.java", Content: "mine"}, {Name: "Solution.java", Content: "solution"}},
+			graders:  []SourceFile{{Name: "Main.java", Content: "grader"}},
+			language: "java",
+			want:     []SourceFile{{Name: "Main.java", Content: "grader"}, {Name: "Solution.java", Content: "solution"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, programFiles(tt.files, tt.graders, tt.language))
+		})
+	}
+}
+
+func TestCompileSources(t *testing.T) {
+	exec := &fakeExecutor{}
+	useExecutor(t, exec)
+
+	_, err := CompileSources(context.Background(),
+		[]SourceFile{{Name: "solution.cpp", Content: "int solve() {}"}},
+		[]SourceFile{{Name: "main.cpp", Content: "grader"}},
+		"cpp", "10.2.0")
+	require.NoError(t, err)
+
+	require.Len(t, exec.compiles, 1)
+	assert.Equal(t, executor.CompileRequest{
+		Language: "cpp",
+		Version:  "10.2.0",
+		Files:    []executor.File{{Name: "main.cpp", Content: "grader"}, {Name: "solution.cpp", Content: "int solve() {}"}},
+	}, exec.compiles[0])
+}
@@ -0,0 +1,234 @@
+package languages
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// SourcesPlaceholder in a compile command expands to the submission's
+// source files, sorted by name
+const SourcesPlaceholder = "{sources}"
+
+// Language describes how programs in a language are named, built and run
+type Language struct {
+	ID             string   `json:"id"`
+	Name           string   `json:"name"`
+	Aliases        []string `json:"aliases,omitempty"`
+	DefaultVersion string   `json:"default_version"`       // version judged with when a submission asks for "latest"
+	Versions       []string `json:"versions,omitempty"`    // installed versions, newest first, when known
+	Disabled       bool     `json:"disabled,omitempty"`    // not accepted for new submissions
+	Extensions     []string `json:"extensions"`            // extensions of files passed to the compiler
+	Entrypoint     string   `json:"entrypoint"`            // file holding the program's entry point
+	StubSource     string   `json:"stub_source"`           // name of a single-file submission to a problem with grader files
+	PistonFile     string   `json:"piston_file,omitempty"` // name Piston is sent for single-file code, Entrypoint if empty
+	Compile        []string `json:"compile,omitempty"`     // local build command, nil for interpreted languages
+	Run            []string `json:"run"`
+	Env            []string `json:"env,omitempty"`
+}
+
+var (
+	mu       sync.RWMutex
+	registry = builtin()
+)
+
+// builtin returns the languages known without any configuration
+func builtin() []Language {
+	return []Language{
+		{
+			ID:             "cpp",
+			Name:           "C++17",
+			Aliases:        []string{"c++"},
+			DefaultVersion: "10.2.0",
+			Extensions:     []string{".cpp", ".cc", ".cxx"},
+			Entrypoint:     "main.cpp",
+			StubSource:     "solution.cpp",
+			PistonFile:     "main", // Piston adds the .cpp extension itself
+			Compile:        []string{"g++", "-O2", "-std=gnu++17", "-pipe", "-o", "main", SourcesPlaceholder},
+			Run:            []string{"./main"},
+		},
+		{
+			ID:             "c",
+			Name:           "C11",
+			DefaultVersion: "10.2.0",
+			Disabled:       true, // enabled through LANGUAGES_FILE
+			Extensions:     []string{".c"},
+			Entrypoint:     "main.c",
+			StubSource:     "solution.c",
+			PistonFile:     "main",
+			Compile:        []string{"gcc", "-O2", "-std=gnu11", "-pipe", "-o", "main", SourcesPlaceholder, "-lm"},
+			Run:            []string{"./main"},
+		},
+		{
+			ID:             "python",
+			Name:           "Python 3",
+			DefaultVersion: "3.12.0",
+			Extensions:     []string{".py"},
+			Entrypoint:     "main.py",
+			StubSource:     "solution.py",
+			Run:            []string{"python3", "main.py"},
+		},
+		{
+			ID:             "java",
+			Name:           "Java",
+			DefaultVersion: "17.0.2",
+			Extensions:     []string{".java"},
+			Entrypoint:     "Main.java",
+			StubSource:     "Solution.java",
+			Compile:        []string{"javac", "-encoding", "UTF-8", SourcesPlaceholder},
+			Run:            []string{"java", "-Xss64m", "-XX:+UseSerialGC", "-cp", ".", "Main"},
+		},
+		{
+			ID:             "javascript",
+			Name:           "JavaScript (Node.js)",
+			DefaultVersion: "18.19.0",
+			Extensions:     []string{".js"},
+			Entrypoint:     "main.js",
+			StubSource:     "solution.js",
+			PistonFile:     "main",
+			Run:            []string{"node", "main.js"},
+		},
+		{
+			ID:             "go",
+			Name:           "Go",
+			DefaultVersion: "1.22.0",
+			Extensions:     []string{".go"},
+			Entrypoint:     "main.go",
+			StubSource:     "solution.go",
+			PistonFile:     "main",
+			Compile:        []string{"go", "build", "-o", "main", SourcesPlaceholder},
+			Run:            []string{"./main"},
+			Env:            []string{"GOCACHE=/tmp/go-cache", "GOPATH=/tmp/go", "CGO_ENABLED=0"},
+		},
+		{
+			ID:             "rust",
+			Name:           "Rust",
+			DefaultVersion: "1.75.0",
+			Extensions:     []string{".rs"},
+			Entrypoint:     "main.rs",
+			StubSource:     "solution.rs",
+			PistonFile:     "main",
+			// rustc finds other modules from main.rs
+			Compile: []string{"rustc", "-O", "-o", "main", "main.rs"},
+			Run:     []string{"./main"},
+		},
+	}
+}
+
+// Get looks a language up by ID or alias, ignoring case
+func Get(id string) (Language, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	id = strings.ToLower(strings.TrimSpace(id))
+	for _, lang := range registry {
+		if lang.ID == id {
+			return lang, true
+		}
+		for _, alias := range lang.Aliases {
+			if alias == id {
+				return lang, true
+			}
+		}
+	}
+	return Language{}, false
+}
+
+// All returns every registered language
+func All() []Language {
+	mu.RLock()
+	defer mu.RUnlock()
+	return append([]Language(nil), registry...)
+}
+
+// Accepts reports whether new submissions may use a language and version.
+// Any version is accepted while the installed ones are unknown.
+func Accepts(id, version string) bool {
+	lang, ok := Get(id)
+	if !ok || lang.Disabled {
+		return false
+	}
+	if version == "" || version == "latest" || len(lang.Versions) == 0 {
+		return true
+	}
+	for _, v := range lang.Versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// Allowed reports whether language is one of allowed, comparing registry
+// IDs so aliases such as "c++" match an allowed "cpp". An empty list allows
+// every language.
+func Allowed(allowed []string, language string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	lang, ok := Get(language)
+	if !ok {
+		return false
+	}
+	for _, id := range allowed {
+		if a, ok := Get(id); ok && a.ID == lang.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveVersion returns the version to judge with: the requested one, or
+// the language's default for "latest" and empty versions
+func ResolveVersion(language, version string) string {
+	if version != "" && version != "latest" {
+		return version
+	}
+	if lang, ok := Get(language); ok && lang.DefaultVersion != "" {
+		return lang.DefaultVersion
+	}
+	return "latest"
+}
+
+// SingleFileName returns the name a single-file submission is compiled
+// under with Piston
+func (l Language) SingleFileName() string {
+	if l.PistonFile != "" {
+		return l.PistonFile
+	}
+	return l.Entrypoint
+}
+
+// IsSource reports whether a file is passed to the compiler
+func (l Language) IsSource(name string) bool {
+	ext := path.Ext(name)
+	for _, e := range l.Extensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// CompileCommand returns the build command for the given files, with the
+// sources placeholder expanded
+func (l Language) CompileCommand(files []string) []string {
+	var sources []string
+	for _, name := range files {
+		if l.IsSource(name) {
+			sources = append(sources, name)
+		}
+	}
+	sort.Strings(sources)
+
+	cmd := make([]string, 0, len(l.Compile)+len(sources))
+	for _, arg := range l.Compile {
+		if arg == SourcesPlaceholder {
+			cmd = append(cmd, sources...)
+		} else {
+			cmd = append(cmd, arg)
+		}
+	}
+	return cmd
+}
@@ -0,0 +1,40 @@
+package languages
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllowed(t *testing.T) {
+	tests := []struct {
+		name     string
+		allowed  []string
+		language string
+		want     bool
+	}{
+		{name: "no list allows everything", language: "python", want: true},
+		{name: "listed", allowed: []string{"cpp", "python"}, language: "python", want: true},
+		{name: "alias submitted", allowed: []string{"cpp"}, language: "c++", want: true},
+		{name: "alias allowed", allowed: []string{"C++"}, language: "cpp", want: true},
+		{name: "not listed", allowed: []string{"cpp"}, language: "python", want: false},
+		{name: "unknown language", allowed: []string{"cpp"}, language: "brainfuck", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Allowed(tt.allowed, tt.language))
+		})
+	}
+}
+
+func TestCompileCommand(t *testing.T) {
+	cpp, ok := Get("cpp")
+	require.True(t, ok)
+
+	assert.Equal(t,
+		[]string{"g++", "-O2", "-std=gnu++17", "-pipe", "-o", "main", "grader.cpp", "main.cpp"},
+		cpp.CompileCommand([]string{"main.cpp", "solve.h", "grader.cpp"}),
+		"headers are not compiled, sources are sorted")
+}
@@ -0,0 +1,137 @@
+package languages
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"codehustle/backend/internal/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Init loads LANGUAGES_FILE, if set, and when languages come from Piston
+// looks up the installed versions now and every LANGUAGES_SYNC_INTERVAL until
+// ctx is done. A failed sync keeps the versions known so far.
+func Init(ctx context.Context) error {
+	if path := config.Get("LANGUAGES_FILE"); path != "" {
+		if err := Load(path); err != nil {
+			return err
+		}
+	}
+
+	source, err := Source()
+	if err != nil {
+		return err
+	}
+	if source != SourcePiston {
+		return nil
+	}
+
+	url := config.Get("PISTON_URL")
+	if err := SyncPiston(ctx, url); err != nil {
+		logrus.WithError(err).Warn("Failed to sync languages with Piston")
+	}
+
+	interval, err := time.ParseDuration(config.Get("LANGUAGES_SYNC_INTERVAL"))
+	if err != nil || interval <= 0 {
+		return nil
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := SyncPiston(ctx, url); err != nil {
+					logrus.WithError(err).Warn("Failed to sync languages with Piston")
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// Language version sources
+const (
+	SourcePiston = "piston" // versions installed in Piston
+	SourceStatic = "static" // the registry and LANGUAGES_FILE as they are
+)
+
+// Source returns where accepted language versions come from: LANGUAGES_SOURCE,
+// or else Piston when JUDGE_EXECUTOR is piston. The API server and the judge
+// workers must agree, so both need the same setting.
+func Source() (string, error) {
+	switch source := strings.ToLower(strings.TrimSpace(config.Get("LANGUAGES_SOURCE"))); source {
+	case SourcePiston, SourceStatic:
+		return source, nil
+	case "":
+	default:
+		return "", fmt.Errorf("unknown LANGUAGES_SOURCE %q (use piston or static)", source)
+	}
+
+	executor := strings.ToLower(strings.TrimSpace(config.Get("JUDGE_EXECUTOR")))
+	if executor == "" || executor == "piston" {
+		return SourcePiston, nil
+	}
+	return SourceStatic, nil
+}
+
+// Load reads a JSON array of languages. An entry with the ID of a known
+// language only changes the fields it sets, e.g.
+// {"id": "python", "default_version": "3.11.0"}; other entries add
+// languages.
+func Load(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read languages file: %w", err)
+	}
+
+	var entries []json.RawMessage
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("languages file must be a JSON array: %w", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	langs := append([]Language(nil), registry...)
+	for i, raw := range entries {
+		var head struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(raw, &head); err != nil || head.ID == "" {
+			return fmt.Errorf("languages file: entry %d has no id", i+1)
+		}
+
+		pos := -1
+		for j := range langs {
+			if langs[j].ID == head.ID {
+				pos = j
+				break
+			}
+		}
+		if pos < 0 {
+			langs = append(langs, Language{})
+			pos = len(langs) - 1
+		}
+		if err := json.Unmarshal(raw, &langs[pos]); err != nil {
+			return fmt.Errorf("languages file: entry %s: %w", head.ID, err)
+		}
+		if langs[pos].Entrypoint == "" || len(langs[pos].Run) == 0 {
+			return fmt.Errorf("languages file: %s needs an entrypoint and a run command", head.ID)
+		}
+	}
+
+	registry = langs
+	logrus.WithFields(logrus.Fields{
+		"path":      path,
+		"languages": len(langs),
+	}).Info("Loaded languages")
+	return nil
+}
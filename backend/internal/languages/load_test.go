@@ -0,0 +1,39 @@
+package languages
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		executor string
+		want     string
+		wantErr  bool
+	}{
+		{name: "defaults to piston", want: SourcePiston},
+		{name: "follows the piston executor", executor: "piston", want: SourcePiston},
+		{name: "follows the local executor", executor: "local", want: SourceStatic},
+		{name: "explicit static", source: "static", executor: "piston", want: SourceStatic},
+		{name: "explicit piston", source: " Piston ", executor: "local", want: SourcePiston},
+		{name: "unknown source", source: "docker", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("LANGUAGES_SOURCE", tt.source)
+			t.Setenv("JUDGE_EXECUTOR", tt.executor)
+
+			got, err := Source()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package languages
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// pistonRuntime is an entry of Piston's /api/v2/runtimes
+type pistonRuntime struct {
+	Language string   `json:"language"`
+	Version  string   `json:"version"`
+	Aliases  []string `json:"aliases"`
+}
+
+// SyncPiston records the versions Piston has installed for each language.
+// A default version that is not installed moves to the newest one.
+func SyncPiston(ctx context.Context, url string) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(url, "/")+"/api/v2/runtimes", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to list Piston runtimes: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to list Piston runtimes: status %d", resp.StatusCode)
+	}
+
+	var runtimes []pistonRuntime
+	if err := json.NewDecoder(resp.Body).Decode(&runtimes); err != nil {
+		return fmt.Errorf("failed to decode Piston runtimes: %w", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for i := range registry {
+		lang := &registry[i]
+		var versions []string
+		for _, rt := range runtimes {
+			if lang.matches(rt) {
+				versions = append(versions, rt.Version)
+			}
+		}
+		sort.Slice(versions, func(a, b int) bool {
+			return compareVersions(versions[a], versions[b]) > 0
+		})
+		lang.Versions = versions
+
+		if len(versions) > 0 && !contains(versions, lang.DefaultVersion) {
+			logrus.WithFields(logrus.Fields{
+				"language": lang.ID,
+				"previous": lang.DefaultVersion,
+				"default":  versions[0],
+			}).Info("Default language version is not installed in Piston, using the newest one")
+			lang.DefaultVersion = versions[0]
+		}
+	}
+
+	logrus.WithField("runtimes", len(runtimes)).Debug("Synced languages with Piston")
+	return nil
+}
+
+// matches reports whether a Piston runtime provides the language
+func (l Language) matches(rt pistonRuntime) bool {
+	names := append([]string{l.ID}, l.Aliases...)
+	for _, name := range names {
+		if rt.Language == name || contains(rt.Aliases, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// compareVersions compares dotted versions numerically where possible
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		switch {
+		case aErr == nil && bErr == nil && an != bn:
+			if an > bn {
+				return 1
+			}
+			return -1
+		case (aErr != nil || bErr != nil) && as[i] != bs[i]:
+			return strings.Compare(as[i], bs[i])
+		}
+	}
+	return len(as) - len(bs)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
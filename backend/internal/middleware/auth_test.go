@@ -71,3 +71,24 @@ func TestRequireRoleMiddleware(t *testing.T) {
 	r.ServeHTTP(w, req)
 	assert.Equal(t, http.StatusOK, w.Code)
 }
+
+func TestStreamTokenAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/submissions/:id/events", middleware.StreamTokenAuth(), func(c *gin.Context) {
+		c.String(http.StatusOK, "stream")
+	})
+
+	// A JWT in the query is no longer accepted
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/submissions/s1/events?access_token=abc", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// Neither is a bearer header without a stream token
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/submissions/s1/events", nil)
+	req.Header.Set("Authorization", "Bearer xyz")
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"codehustle/backend/internal/queue"
+)
+
+// StreamTokenTTL is how long a stream token can be used to open its stream
+const StreamTokenTTL = time.Minute
+
+// streamTokenPayload is what a stream token stands for
+type streamTokenPayload struct {
+	User         UserContext `json:"user"`
+	SubmissionID string      `json:"submission_id"`
+}
+
+// IssueStreamToken returns a random single-use token that lets the user open
+// the event stream of one submission. Browser EventSource clients cannot set
+// headers, so they pass it as the stream_token query parameter instead of
+// putting the JWT in a URL.
+func IssueStreamToken(ctx context.Context, user UserContext, submissionID string) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(buf)
+
+	payload, err := json.Marshal(streamTokenPayload{User: user, SubmissionID: submissionID})
+	if err != nil {
+		return "", err
+	}
+	if err := queue.StoreStreamToken(ctx, token, payload, StreamTokenTTL); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// StreamTokenAuth authenticates an event stream request by its stream_token
+// query parameter, which is used up. The token must have been issued for the
+// submission in the :id path parameter.
+func StreamTokenAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.Query("stream_token")
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing_token"})
+			return
+		}
+
+		raw, err := queue.ConsumeStreamToken(c.Request.Context(), token)
+		if errors.Is(err, queue.ErrStreamTokenNotFound) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid_token"})
+			return
+		}
+		if err != nil {
+			log.Printf("[AUTH] Failed to check stream token: %v", err)
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+				"error":   "stream_token_unavailable",
+				"message": err.Error(),
+			})
+			return
+		}
+
+		var payload streamTokenPayload
+		if err := json.Unmarshal(raw, &payload); err != nil || payload.SubmissionID != c.Param("id") {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid_token"})
+			return
+		}
+
+		c.Set("user", payload.User)
+		c.Next()
+	}
+}
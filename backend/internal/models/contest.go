@@ -6,7 +6,22 @@ import (
 	"time"
 )
 
-// Contest represents an OI-mode contest
+// Contest scoring rules
+const (
+	RuleTypeOI   = "OI"   // sum of the last score per problem
+	RuleTypeIOI  = "IOI"  // sum of the best score per problem
+	RuleTypeICPC = "ICPC" // solved problems, then penalty minutes
+)
+
+// ICPCPenaltyMinutes is added for each rejected try before a problem is solved
+const ICPCPenaltyMinutes = 20
+
+// IsValidRuleType reports whether rule is a known scoring rule
+func IsValidRuleType(rule string) bool {
+	return rule == RuleTypeOI || rule == RuleTypeIOI || rule == RuleTypeICPC
+}
+
+// Contest represents a contest scored by its RuleType
 type Contest struct {
 	ID                       string         `gorm:"type:char(36);primaryKey" json:"id"`
 	Title                    string         `gorm:"size:200;not null" json:"title"`
@@ -18,6 +33,8 @@ type Contest struct {
 	AllowedLanguages         StringArray    `gorm:"type:json;column:allowed_languages" json:"allowed_languages,omitempty"`
 	SubmissionLimitPerProblem int           `gorm:"column:submission_limit_per_problem;default:0" json:"submission_limit_per_problem"` // 0 = unlimited
 	RuleType                 string         `gorm:"size:50;column:rule_type;default:'OI';not null" json:"rule_type"`
+	FreezeAt                 *time.Time     `gorm:"column:freeze_at" json:"freeze_at,omitempty"`     // Scoreboard shows later submissions as pending
+	UnfrozenAt               *time.Time     `gorm:"column:unfrozen_at" json:"unfrozen_at,omitempty"` // Set once every frozen result is revealed
 	CreatedBy                string         `gorm:"type:char(36);not null;column:created_by" json:"created_by"`
 	CreatedAt                time.Time      `gorm:"autoCreateTime;column:created_at" json:"created_at"`
 	UpdatedAt                *time.Time     `gorm:"column:updated_at" json:"updated_at,omitempty"`
@@ -115,6 +132,12 @@ func (c *Contest) IsActive() bool {
 	return c.DeletedAt == nil && now.After(c.StartAt) && now.Before(c.EndAt)
 }
 
+// IsFrozen returns true while the public scoreboard hides results
+// submitted after the freeze time
+func (c *Contest) IsFrozen() bool {
+	return c.FreezeAt != nil && !time.Now().Before(*c.FreezeAt) && c.UnfrozenAt == nil
+}
+
 // CanRegister returns true if registration is still open
 func (c *Contest) CanRegister() bool {
 	now := time.Now()
@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// ContestResult is a user's result on one contest problem
+type ContestResult struct {
+	Attempts  int        `gorm:"not null;default:0" json:"attempts"` // ICPC tries up to the first accepted one
+	Pending   int        `gorm:"not null;default:0" json:"pending"`
+	Solved    bool       `gorm:"not null;default:false" json:"solved"`
+	SolvedAt  *time.Time `json:"solved_at,omitempty"`
+	Penalty   int        `gorm:"not null;default:0" json:"penalty"` // ICPC penalty minutes
+	BestScore int        `gorm:"not null;default:0" json:"best_score"`
+	LastScore int        `gorm:"not null;default:0" json:"last_score"`
+}
+
+// Score returns the points the result is worth under a scoring rule
+func (r ContestResult) Score(ruleType string) int {
+	if ruleType == RuleTypeIOI {
+		return r.BestScore
+	}
+	return r.LastScore
+}
+
+// ContestScore is a user's scoreboard cell for one problem of a contest. The
+// judge worker rebuilds it from the user's submissions to the problem on
+// every verdict, so reading the scoreboard never scans submissions.
+type ContestScore struct {
+	ContestID     string `gorm:"type:char(36);primaryKey;column:contest_id" json:"contest_id"`
+	UserID        string `gorm:"type:char(36);primaryKey;column:user_id" json:"user_id"`
+	ProblemID     string `gorm:"type:char(36);primaryKey;column:problem_id" json:"problem_id"`
+	ContestResult `gorm:"embedded"`
+
+	// Frozen is the result shown while the scoreboard is frozen: submissions
+	// after the freeze count as pending. Revealed cells show the full result.
+	Frozen    ContestResult `gorm:"embedded;embeddedPrefix:frozen_" json:"frozen"`
+	Revealed  bool          `gorm:"not null;default:false" json:"revealed"`
+	UpdatedAt time.Time     `gorm:"autoUpdateTime;column:updated_at" json:"updated_at"`
+}
+
+// TableName specifies the table name for ContestScore
+func (ContestScore) TableName() string {
+	return "contest_scores"
+}
+
+// Visible returns the result shown on a frozen scoreboard
+func (s ContestScore) Visible() ContestResult {
+	if s.Revealed {
+		return s.ContestResult
+	}
+	return s.Frozen
+}
+
+// HasHiddenResult reports whether a frozen scoreboard still shows the cell's
+// submissions after the freeze as pending
+func (s ContestScore) HasHiddenResult() bool {
+	return !s.Revealed && s.Frozen.Pending > 0
+}
@@ -1,18 +1,97 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Judge modes
+const (
+	JudgeModeStandard    = "standard"    // run on the input, then check the output
+	JudgeModeInteractive = "interactive" // talk to an interactor, which gives the verdict
+)
+
+// I/O modes
+const (
+	IOModeStdio      = "stdio"       // read standard input, write standard output
+	IOModeFile       = "file"        // read and write named files in the working directory
+	IOModeOutputOnly = "output_only" // a zip of answers is submitted, no code runs
+)
+
+// LanguageAnswers is the language recorded for output-only submissions
+const LanguageAnswers = "answers"
+
+// Default file names in file mode
+const (
+	DefaultInputFile  = "input.txt"
+	DefaultOutputFile = "output.txt"
+)
 
 type ProblemJudge struct {
-	ID                    string    `gorm:"type:char(36);primaryKey" json:"id"`
-	ProblemID             string    `gorm:"type:char(36);uniqueIndex;not null" json:"problem_id"`
-	CheckerKind           string    `gorm:"size:20;not null" json:"checker_kind"`
-	CheckerCustomPath     *string   `gorm:"type:text" json:"checker_custom_path,omitempty"`
-	CheckerArgs           *string   `gorm:"type:json" json:"checker_args,omitempty"`
-	CheckerRuntimeImage   *string   `gorm:"size:200" json:"checker_runtime_image,omitempty"`
-	CheckerVersion        *string   `gorm:"size:50" json:"checker_version,omitempty"`
-	ValidatorPath         *string   `gorm:"type:text" json:"validator_path,omitempty"`
-	ValidatorArgs         *string   `gorm:"type:json" json:"validator_args,omitempty"`
-	ValidatorRuntimeImage *string   `gorm:"size:200" json:"validator_runtime_image,omitempty"`
-	ValidatorVersion      *string   `gorm:"size:50" json:"validator_version,omitempty"`
-	CreatedAt             time.Time `gorm:"autoCreateTime" json:"created_at"`
+	ID                      string    `gorm:"type:char(36);primaryKey" json:"id"`
+	ProblemID               string    `gorm:"type:char(36);uniqueIndex;not null" json:"problem_id"`
+	CheckerKind             string    `gorm:"size:20;not null" json:"checker_kind"`
+	CheckerCustomPath       *string   `gorm:"type:text" json:"checker_custom_path,omitempty"`
+	CheckerArgs             *string   `gorm:"type:json" json:"checker_args,omitempty"`
+	CheckerRuntimeImage     *string   `gorm:"size:200" json:"checker_runtime_image,omitempty"`
+	CheckerVersion          *string   `gorm:"size:50" json:"checker_version,omitempty"`
+	ValidatorPath           *string   `gorm:"type:text" json:"validator_path,omitempty"`
+	ValidatorArgs           *string   `gorm:"type:json" json:"validator_args,omitempty"`
+	ValidatorRuntimeImage   *string   `gorm:"size:200" json:"validator_runtime_image,omitempty"`
+	ValidatorVersion        *string   `gorm:"size:50" json:"validator_version,omitempty"`
+	JudgeMode               string    `gorm:"size:20;not null;default:'standard'" json:"judge_mode"` // standard or interactive
+	InteractorPath          *string   `gorm:"type:text" json:"interactor_path,omitempty"`            // MinIO key, stored like checker_custom_path
+	InteractorRuntimeImage  *string   `gorm:"size:200" json:"interactor_runtime_image,omitempty"`
+	InteractorVersion       *string   `gorm:"size:50" json:"interactor_version,omitempty"`
+	InteractorTimeLimitMs   *int      `gorm:"column:interactor_time_limit_ms" json:"interactor_time_limit_ms,omitempty"`     // defaults to twice the problem's limit
+	InteractorMemoryLimitKb *int      `gorm:"column:interactor_memory_limit_kb" json:"interactor_memory_limit_kb,omitempty"` // defaults to the problem's limit
+	IOMode                  string    `gorm:"column:io_mode;size:20;not null;default:'stdio'" json:"io_mode"`                // stdio, file or output_only
+	InputFile               *string   `gorm:"size:100" json:"input_file,omitempty"`                                          // file mode, defaults to input.txt
+	OutputFile              *string   `gorm:"size:100" json:"output_file,omitempty"`                                         // file mode, defaults to output.txt
+	GraderFiles             *string   `gorm:"type:json" json:"grader_files,omitempty"`                                       // MinIO keys per language, compiled with the submission
+	CreatedAt               time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// IsInteractive reports whether submissions talk to an interactor
+func (j *ProblemJudge) IsInteractive() bool {
+	return j.JudgeMode == JudgeModeInteractive
+}
+
+// UsesFiles reports whether the program reads and writes named files
+func (j *ProblemJudge) UsesFiles() bool {
+	return j.IOMode == IOModeFile
+}
+
+// IsOutputOnly reports whether submissions are answer files rather than code
+func (j *ProblemJudge) IsOutputOnly() bool {
+	return j.IOMode == IOModeOutputOnly
+}
+
+// InputFileName returns the file the program reads in file mode
+func (j *ProblemJudge) InputFileName() string {
+	if j.InputFile != nil && *j.InputFile != "" {
+		return *j.InputFile
+	}
+	return DefaultInputFile
+}
+
+// OutputFileName returns the file the program writes in file mode
+func (j *ProblemJudge) OutputFileName() string {
+	if j.OutputFile != nil && *j.OutputFile != "" {
+		return *j.OutputFile
+	}
+	return DefaultOutputFile
+}
+
+// GraderPaths returns the MinIO keys of the grader files for a language
+func (j *ProblemJudge) GraderPaths(language string) ([]string, error) {
+	if j.GraderFiles == nil || *j.GraderFiles == "" {
+		return nil, nil
+	}
+	var graders map[string][]string
+	if err := json.Unmarshal([]byte(*j.GraderFiles), &graders); err != nil {
+		return nil, fmt.Errorf("invalid grader_files: %w", err)
+	}
+	return graders[language], nil
 }
@@ -17,8 +17,12 @@ type Submission struct {
 	ExecutionTime   *int      `gorm:"column:execution_time" json:"execution_time,omitempty"` // milliseconds
 	MemoryUsage     *int      `gorm:"column:memory_usage" json:"memory_usage,omitempty"`     // KB
 	CodeSizeBytes   *int      `gorm:"column:code_size_bytes" json:"code_size_bytes,omitempty"`
+	TimeLimitMs     *int      `gorm:"column:time_limit_ms" json:"time_limit_ms,omitempty"`     // Limit applied when judging
+	MemoryLimitKb   *int      `gorm:"column:memory_limit_kb" json:"memory_limit_kb,omitempty"` // Limit applied when judging
 	CompileLogPath  *string   `gorm:"type:text;column:compile_log_path" json:"compile_log_path,omitempty"`
 	RunLogPath      *string   `gorm:"type:text;column:run_log_path" json:"run_log_path,omitempty"`
+	AnswersPath     *string   `gorm:"type:text;column:answers_path" json:"answers_path,omitempty"` // Answers zip of an output-only submission
+	SourcesPath     *string   `gorm:"type:text;column:sources_path" json:"sources_path,omitempty"` // Sources zip of a multi-file submission
 	SubmittedAt     time.Time `gorm:"autoCreateTime;column:submitted_at" json:"submitted_at"`
 
 	// Relations
@@ -30,12 +34,13 @@ type SubmissionTestCase struct {
 	ID             uint      `gorm:"primaryKey" json:"id"`
 	SubmissionID   string    `gorm:"type:char(36);not null;column:submission_id;index" json:"submission_id"`
 	TestCaseID     string    `gorm:"type:char(36);not null;column:test_case_id;index" json:"test_case_id"`
-	Status         string    `gorm:"size:50;not null" json:"status"` // accepted, wrong_answer, compile_error, runtime_error, time_limit_exceeded, memory_limit_exceeded, system_error
+	Status         string    `gorm:"size:50;not null" json:"status"` // accepted, wrong_answer, compile_error, runtime_error, time_limit_exceeded, memory_limit_exceeded, presentation_error, judgement_failed, system_error, partial, skipped
 	Score          *int      `json:"score,omitempty"`
 	TimeMs         *int      `gorm:"column:time_ms" json:"time_ms,omitempty"`
 	MemoryKb       *int      `gorm:"column:memory_kb" json:"memory_kb,omitempty"`
 	LogPath        *string   `gorm:"type:text;column:log_path" json:"log_path,omitempty"`
 	UserOutputPath *string   `gorm:"type:text;column:user_output_path" json:"user_output_path,omitempty"`
+	CheckerMessage *string   `gorm:"type:text;column:checker_message" json:"checker_message,omitempty"`
 	CreatedAt      time.Time `gorm:"autoCreateTime;column:created_at" json:"created_at"`
 
 	// Relations
@@ -46,3 +51,24 @@ type SubmissionTestCase struct {
 func (SubmissionTestCase) TableName() string {
 	return "submission_testcases"
 }
+
+// SubmissionRejudge records the verdict a submission had before it was
+// rejudged, and the verdict it got afterwards
+type SubmissionRejudge struct {
+	ID                    uint       `gorm:"primaryKey" json:"id"`
+	SubmissionID          string     `gorm:"type:char(36);not null;column:submission_id;index" json:"submission_id"`
+	RejudgedBy            *string    `gorm:"type:char(36);column:rejudged_by" json:"rejudged_by,omitempty"`
+	PreviousStatus        string     `gorm:"size:50;not null;column:previous_status" json:"previous_status"`
+	PreviousScore         *int       `gorm:"column:previous_score" json:"previous_score,omitempty"`
+	PreviousExecutionTime *int       `gorm:"column:previous_execution_time" json:"previous_execution_time,omitempty"`
+	PreviousMemoryUsage   *int       `gorm:"column:previous_memory_usage" json:"previous_memory_usage,omitempty"`
+	NewStatus             *string    `gorm:"size:50;column:new_status" json:"new_status,omitempty"`
+	NewScore              *int       `gorm:"column:new_score" json:"new_score,omitempty"`
+	CreatedAt             time.Time  `gorm:"autoCreateTime;column:created_at" json:"created_at"`
+	CompletedAt           *time.Time `gorm:"column:completed_at" json:"completed_at,omitempty"`
+}
+
+// TableName specifies the table name for SubmissionRejudge
+func (SubmissionRejudge) TableName() string {
+	return "submission_rejudges"
+}
@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// Subtask scoring rules
+const (
+	SubtaskScoringSum = "sum" // points in proportion to the weighted test scores
+	SubtaskScoringMin = "min" // points scaled by the lowest test score
+	SubtaskScoringAll = "all" // full points only if every test is accepted
+)
+
+// Subtask is a group of test cases of a problem scored as a whole
+type Subtask struct {
+	ID        string    `gorm:"type:char(36);primaryKey" json:"id"`
+	ProblemID string    `gorm:"type:char(36);not null;index;column:problem_id" json:"problem_id"`
+	Name      string    `gorm:"size:200;not null" json:"name"`
+	Ordinal   int       `gorm:"not null" json:"ordinal"`
+	Points    int       `gorm:"not null;default:0" json:"points"`
+	Scoring   string    `gorm:"size:20;not null;default:'sum'" json:"scoring"`
+	CreatedAt time.Time `gorm:"autoCreateTime;column:created_at" json:"created_at"`
+
+	// Filled from the join tables
+	TestCaseIDs []string `gorm:"-" json:"test_case_ids"`
+	DependsOn   []string `gorm:"-" json:"depends_on"`
+}
+
+// SubtaskTestCase assigns a test case to a subtask
+type SubtaskTestCase struct {
+	SubtaskID  string `gorm:"type:char(36);primaryKey;column:subtask_id"`
+	TestCaseID string `gorm:"type:char(36);primaryKey;column:test_case_id"`
+}
+
+// TableName specifies the table name for SubtaskTestCase
+func (SubtaskTestCase) TableName() string {
+	return "subtask_test_cases"
+}
+
+// SubtaskDependency makes a subtask depend on another one
+type SubtaskDependency struct {
+	SubtaskID   string `gorm:"type:char(36);primaryKey;column:subtask_id"`
+	DependsOnID string `gorm:"type:char(36);primaryKey;column:depends_on_id"`
+}
+
+// TableName specifies the table name for SubtaskDependency
+func (SubtaskDependency) TableName() string {
+	return "subtask_dependencies"
+}
+
+// SubmissionSubtask is the result of a submission on one subtask
+type SubmissionSubtask struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	SubmissionID string    `gorm:"type:char(36);not null;column:submission_id;index" json:"submission_id"`
+	SubtaskID    string    `gorm:"type:char(36);not null;column:subtask_id" json:"subtask_id"`
+	Status       string    `gorm:"size:50;not null" json:"status"` // accepted, partial, failed, skipped
+	Score        int       `gorm:"not null;default:0" json:"score"`
+	CreatedAt    time.Time `gorm:"autoCreateTime;column:created_at" json:"created_at"`
+
+	// Relations
+	Subtask Subtask `gorm:"foreignKey:SubtaskID" json:"subtask,omitempty"`
+}
+
+// TableName specifies the table name for SubmissionSubtask
+func (SubmissionSubtask) TableName() string {
+	return "submission_subtasks"
+}
@@ -0,0 +1,170 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Submission event types
+const (
+	SubmissionEventStatus   = "status"    // submission moved to pending or running
+	SubmissionEventTestCase = "test_case" // a test case got its verdict
+	SubmissionEventFinal    = "final"     // judging finished
+)
+
+// submissionEventsPrefix prefixes the pub/sub channel of each submission
+const submissionEventsPrefix = "submission:events:"
+
+// SubmissionEvent is a judging progress update published by the worker
+type SubmissionEvent struct {
+	SubmissionID string    `json:"submission_id"`
+	Type         string    `json:"type"`
+	Status       string    `json:"status"`
+	TestCaseID   string    `json:"test_case_id,omitempty"`
+	TestCaseNum  int       `json:"test_case_num,omitempty"` // 1-based
+	Score        *int      `json:"score,omitempty"`
+	TimeMs       *int      `json:"time_ms,omitempty"`
+	MemoryKb     *int      `json:"memory_kb,omitempty"`
+	At           time.Time `json:"at"`
+}
+
+// PublishSubmissionEvent publishes a progress update on the submission's channel
+func PublishSubmissionEvent(ctx context.Context, event SubmissionEvent) error {
+	if redisClient == nil {
+		return fmt.Errorf("Redis client not initialized")
+	}
+
+	if event.At.IsZero() {
+		event.At = time.Now().UTC()
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	return redisClient.Publish(ctx, submissionEventsPrefix+event.SubmissionID, payload).Err()
+}
+
+// submissionEventHub fans events from a single Redis subscription out to
+// every local subscriber of a submission
+type submissionEventHub struct {
+	mu          sync.Mutex
+	pubsub      *redis.PubSub // nil until the first subscriber
+	subscribers map[string]map[chan SubmissionEvent]struct{}
+}
+
+var eventHub = &submissionEventHub{
+	subscribers: make(map[string]map[chan SubmissionEvent]struct{}),
+}
+
+// SubscribeSubmissionEvents returns a channel receiving the events of a
+// submission and a function that cancels the subscription. The Redis
+// subscription is live when it returns, so events published afterwards are
+// not missed.
+func SubscribeSubmissionEvents(ctx context.Context, submissionID string) (<-chan SubmissionEvent, func(), error) {
+	if redisClient == nil {
+		return nil, nil, fmt.Errorf("Redis client not initialized")
+	}
+
+	ch := make(chan SubmissionEvent, 32)
+
+	eventHub.mu.Lock()
+	if err := eventHub.start(ctx); err != nil {
+		eventHub.mu.Unlock()
+		return nil, nil, err
+	}
+	if eventHub.subscribers[submissionID] == nil {
+		eventHub.subscribers[submissionID] = make(map[chan SubmissionEvent]struct{})
+	}
+	eventHub.subscribers[submissionID][ch] = struct{}{}
+	eventHub.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			eventHub.mu.Lock()
+			defer eventHub.mu.Unlock()
+			delete(eventHub.subscribers[submissionID], ch)
+			if len(eventHub.subscribers[submissionID]) == 0 {
+				delete(eventHub.subscribers, submissionID)
+			}
+		})
+	}
+
+	return ch, cancel, nil
+}
+
+// start opens the Redis pattern subscription unless it is already open, and
+// waits for Redis to confirm it. The caller holds h.mu.
+func (h *submissionEventHub) start(ctx context.Context) error {
+	if h.pubsub != nil {
+		return nil
+	}
+
+	// The subscription outlives ctx; ctx only bounds the wait for Redis
+	pubsub := redisClient.PSubscribe(context.Background(), submissionEventsPrefix+"*")
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return fmt.Errorf("failed to subscribe to submission events: %w", err)
+	}
+
+	h.pubsub = pubsub
+	go h.run(pubsub)
+	return nil
+}
+
+// run dispatches messages from the Redis pattern subscription; the client
+// resubscribes on its own after connection errors
+func (h *submissionEventHub) run(pubsub *redis.PubSub) {
+	defer pubsub.Close()
+
+	for msg := range pubsub.Channel() {
+		var event SubmissionEvent
+		if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+			log.Printf("[EVENTS] Dropping malformed event on %s: %v", msg.Channel, err)
+			continue
+		}
+		if event.SubmissionID == "" {
+			event.SubmissionID = strings.TrimPrefix(msg.Channel, submissionEventsPrefix)
+		}
+		h.dispatch(event)
+	}
+}
+
+// dispatch hands an event to the submission's subscribers without blocking
+// on slow readers. A full buffer drops progress events, but the final event
+// always gets through, evicting the oldest buffered one if needed, since
+// streams only end on it.
+func (h *submissionEventHub) dispatch(event SubmissionEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subscribers[event.SubmissionID] {
+		select {
+		case ch <- event:
+			continue
+		default:
+		}
+
+		if event.Type != SubmissionEventFinal {
+			log.Printf("[EVENTS] Subscriber of submission %s is too slow, dropping %s event", event.SubmissionID, event.Type)
+			continue
+		}
+
+		// Only dispatch sends, under the lock, so once an event is taken out
+		// there is room for the final one
+		select {
+		case dropped := <-ch:
+			log.Printf("[EVENTS] Subscriber of submission %s is too slow, dropping %s event", event.SubmissionID, dropped.Type)
+		default:
+		}
+		ch <- event
+	}
+}
@@ -0,0 +1,178 @@
+package queue
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDispatchNeverDropsFinalEvent(t *testing.T) {
+	h := &submissionEventHub{subscribers: make(map[string]map[chan SubmissionEvent]struct{})}
+	ch := make(chan SubmissionEvent, 2)
+	h.subscribers["s1"] = map[chan SubmissionEvent]struct{}{ch: {}}
+
+	// The subscriber reads nothing, so the third progress event is dropped
+	for i := 1; i <= 3; i++ {
+		h.dispatch(SubmissionEvent{SubmissionID: "s1", Type: SubmissionEventTestCase, TestCaseNum: i})
+	}
+	h.dispatch(SubmissionEvent{SubmissionID: "s1", Type: SubmissionEventFinal, Status: "accepted"})
+
+	first, last := <-ch, <-ch
+	assert.Equal(t, 2, first.TestCaseNum, "the oldest event makes room for the final one")
+	assert.Equal(t, SubmissionEventFinal, last.Type)
+	assert.Empty(t, ch)
+}
+
+func TestDispatchOnlyReachesSubmissionSubscribers(t *testing.T) {
+	h := &submissionEventHub{subscribers: make(map[string]map[chan SubmissionEvent]struct{})}
+	mine, other := make(chan SubmissionEvent, 1), make(chan SubmissionEvent, 1)
+	h.subscribers["s1"] = map[chan SubmissionEvent]struct{}{mine: {}}
+	h.subscribers["s2"] = map[chan SubmissionEvent]struct{}{other: {}}
+
+	h.dispatch(SubmissionEvent{SubmissionID: "s1", Type: SubmissionEventStatus, Status: "running"})
+
+	assert.Len(t, mine, 1)
+	assert.Empty(t, other)
+}
+
+// fakePubSubServer speaks just enough of the Redis protocol for a pattern
+// subscription. It confirms PSUBSCRIBE only once confirm is closed and then
+// sends every payload on publish as a message on channel.
+func fakePubSubServer(t *testing.T, channel string, confirm <-chan struct{}, publish <-chan string) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		for {
+			args, err := readCommand(r)
+			if err != nil {
+				return
+			}
+			if !strings.EqualFold(args[0], "psubscribe") {
+				fmt.Fprintf(conn, "-ERR unknown command '%s'\r\n", args[0])
+				continue
+			}
+
+			<-confirm
+			pattern := args[1]
+			fmt.Fprintf(conn, "*3\r\n$10\r\npsubscribe\r\n$%d\r\n%s\r\n:1\r\n", len(pattern), pattern)
+			for payload := range publish {
+				fmt.Fprintf(conn, "*4\r\n$8\r\npmessage\r\n$%d\r\n%s\r\n$%d\r\n%s\r\n$%d\r\n%s\r\n",
+					len(pattern), pattern, len(channel), channel, len(payload), payload)
+			}
+			return
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+// readCommand reads a command sent as an array of bulk strings
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, n)
+	for i := range args {
+		if _, err := r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		arg, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args[i] = strings.TrimSuffix(arg, "\r\n")
+	}
+	return args, nil
+}
+
+func TestStartWaitsForSubscriptionConfirmation(t *testing.T) {
+	confirm := make(chan struct{})
+	publish := make(chan string, 1)
+	defer close(publish)
+	addr := fakePubSubServer(t, submissionEventsPrefix+"s1", confirm, publish)
+
+	saved := redisClient
+	redisClient = redis.NewClient(&redis.Options{Addr: addr, MaxRetries: -1})
+	defer func() {
+		redisClient.Close()
+		redisClient = saved
+	}()
+
+	h := &submissionEventHub{subscribers: make(map[string]map[chan SubmissionEvent]struct{})}
+	ch := make(chan SubmissionEvent, 1)
+	h.subscribers["s1"] = map[chan SubmissionEvent]struct{}{ch: {}}
+
+	started := make(chan error, 1)
+	go func() { started <- h.start(context.Background()) }()
+
+	select {
+	case err := <-started:
+		t.Fatalf("start returned before Redis confirmed the subscription: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(confirm)
+	select {
+	case err := <-started:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("start did not return after the subscription was confirmed")
+	}
+
+	// Right after start returns, a published final event reaches the subscriber
+	publish <- `{"submission_id":"s1","type":"final","status":"accepted"}`
+	select {
+	case event := <-ch:
+		assert.Equal(t, SubmissionEventFinal, event.Type)
+		assert.Equal(t, "accepted", event.Status)
+	case <-time.After(5 * time.Second):
+		t.Fatal("final event was not dispatched")
+	}
+}
+
+func TestStartFailsWithoutRedis(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	saved := redisClient
+	redisClient = redis.NewClient(&redis.Options{Addr: addr, MaxRetries: -1})
+	defer func() {
+		redisClient.Close()
+		redisClient = saved
+	}()
+
+	h := &submissionEventHub{subscribers: make(map[string]map[chan SubmissionEvent]struct{})}
+	assert.Error(t, h.start(context.Background()))
+	assert.Nil(t, h.pubsub, "the next subscriber tries again")
+}
@@ -0,0 +1,104 @@
+package queue
+
+import (
+	"sync"
+)
+
+// Judge job priorities
+const (
+	PriorityContest = "contest"
+	PriorityNormal  = "normal"
+	PriorityRejudge = "rejudge"
+)
+
+// Lane is a priority level of the judge queue, backed by its own stream
+type Lane struct {
+	Priority string
+	Stream   string
+	Weight   int
+}
+
+// JudgeLanes lists the judge queue lanes from highest to lowest priority.
+// Weights control how often each lane gets the first pick when all of them
+// have work, so lower lanes are slowed down but never starved.
+var JudgeLanes = []Lane{
+	{Priority: PriorityContest, Stream: "judge:submissions:contest", Weight: 6},
+	{Priority: PriorityNormal, Stream: "judge:submissions", Weight: 3},
+	{Priority: PriorityRejudge, Stream: "judge:submissions:rejudge", Weight: 1},
+}
+
+// LaneFor picks the lane for a job: an explicit priority wins, version 1
+// contest jobs go to the contest lane and everything else is normal
+func LaneFor(job *JudgeJob) Lane {
+	priority := job.Priority
+	if priority == "" {
+		if job.ContestID != "" {
+			priority = PriorityContest
+		} else {
+			priority = PriorityNormal
+		}
+	}
+
+	if lane, ok := laneByPriority(priority); ok {
+		return lane
+	}
+	lane, _ := laneByPriority(PriorityNormal)
+	return lane
+}
+
+// LaneForStream returns the lane backed by the given stream
+func LaneForStream(stream string) (Lane, bool) {
+	for _, lane := range JudgeLanes {
+		if lane.Stream == stream {
+			return lane, true
+		}
+	}
+	return Lane{}, false
+}
+
+func laneByPriority(priority string) (Lane, bool) {
+	for _, lane := range JudgeLanes {
+		if lane.Priority == priority {
+			return lane, true
+		}
+	}
+	return Lane{}, false
+}
+
+// laneScheduler orders lanes for each read with smooth weighted round-robin
+type laneScheduler struct {
+	mu      sync.Mutex
+	current []int
+}
+
+var judgeLaneScheduler = &laneScheduler{}
+
+// order returns all lanes, starting with the one whose turn it is
+func (s *laneScheduler) order() []Lane {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.current) != len(JudgeLanes) {
+		s.current = make([]int, len(JudgeLanes))
+	}
+
+	total := 0
+	best := 0
+	for i, lane := range JudgeLanes {
+		s.current[i] += lane.Weight
+		total += lane.Weight
+		if s.current[i] > s.current[best] {
+			best = i
+		}
+	}
+	s.current[best] -= total
+
+	lanes := make([]Lane, 0, len(JudgeLanes))
+	lanes = append(lanes, JudgeLanes[best])
+	for i, lane := range JudgeLanes {
+		if i != best {
+			lanes = append(lanes, lane)
+		}
+	}
+	return lanes
+}
@@ -0,0 +1,57 @@
+package queue
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLaneSchedulerOrder(t *testing.T) {
+	s := &laneScheduler{}
+
+	var firsts []string
+	for i := 0; i < 20; i++ {
+		lanes := s.order()
+		if !assert.Len(t, lanes, len(JudgeLanes)) {
+			return
+		}
+		firsts = append(firsts, lanes[0].Priority)
+
+		// The other lanes follow in priority order
+		var rest []string
+		for _, lane := range JudgeLanes {
+			if lane.Priority != lanes[0].Priority {
+				rest = append(rest, lane.Priority)
+			}
+		}
+		for j, priority := range rest {
+			assert.Equal(t, priority, lanes[j+1].Priority)
+		}
+	}
+
+	// Smooth weighted round-robin spreads the 6/3/1 picks over each cycle
+	// of ten reads instead of serving a lane in bursts
+	c, n, r := PriorityContest, PriorityNormal, PriorityRejudge
+	cycle := []string{c, n, c, c, n, c, r, c, n, c}
+	assert.Equal(t, append(append([]string{}, cycle...), cycle...), firsts)
+}
+
+func TestLaneFor(t *testing.T) {
+	tests := []struct {
+		name string
+		job  JudgeJob
+		want string
+	}{
+		{name: "normal by default", job: JudgeJob{SubmissionID: "s"}, want: PriorityNormal},
+		{name: "explicit priority", job: JudgeJob{SubmissionID: "s", Priority: PriorityRejudge}, want: PriorityRejudge},
+		{name: "explicit priority beats contest", job: JudgeJob{SubmissionID: "s", Priority: PriorityRejudge, ContestID: "c"}, want: PriorityRejudge},
+		{name: "version 1 contest job", job: JudgeJob{SubmissionID: "s", ContestID: "c"}, want: PriorityContest},
+		{name: "unknown priority", job: JudgeJob{SubmissionID: "s", Priority: "urgent"}, want: PriorityNormal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, LaneFor(&tt.job).Priority)
+		})
+	}
+}
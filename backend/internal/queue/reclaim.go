@@ -0,0 +1,200 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// JudgeDeadLetterStream receives judge jobs that failed too many times
+const JudgeDeadLetterStream = "judge:submissions:dead"
+
+// DeadLetter describes a judge job moved to the dead-letter stream
+type DeadLetter struct {
+	Stream       string
+	MessageID    string
+	SubmissionID string
+	Deliveries   int64
+	Reason       string
+}
+
+// ReclaimJudgeJobs inspects the consumer group's pending entries list of
+// every lane for messages idle longer than minIdle. Messages delivered
+// maxAttempts times or more are moved to the dead-letter stream and
+// acknowledged; the rest are claimed for consumerName so they can be
+// processed again. count bounds the entries inspected per lane.
+func ReclaimJudgeJobs(ctx context.Context, consumerGroup, consumerName string, minIdle time.Duration, maxAttempts int64, count int64) ([]JudgeMessage, []DeadLetter, error) {
+	if redisClient == nil {
+		return nil, nil, fmt.Errorf("Redis client not initialized")
+	}
+
+	var reclaimed []JudgeMessage
+	var deadLetters []DeadLetter
+
+	for _, lane := range JudgeLanes {
+		r, d, err := reclaimLane(ctx, lane.Stream, consumerGroup, consumerName, minIdle, maxAttempts, count)
+		reclaimed = append(reclaimed, r...)
+		deadLetters = append(deadLetters, d...)
+		if err != nil {
+			return reclaimed, deadLetters, err
+		}
+	}
+
+	return reclaimed, deadLetters, nil
+}
+
+// reclaimLane reclaims or dead-letters the stale pending entries of a stream
+func reclaimLane(ctx context.Context, stream, consumerGroup, consumerName string, minIdle time.Duration, maxAttempts int64, count int64) ([]JudgeMessage, []DeadLetter, error) {
+	pending, err := redisClient.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: stream,
+		Group:  consumerGroup,
+		Idle:   minIdle,
+		Start:  "-",
+		End:    "+",
+		Count:  count,
+	}).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil, nil
+		}
+		return nil, nil, fmt.Errorf("failed to read pending entries: %w", err)
+	}
+
+	var reclaimed []JudgeMessage
+	var deadLetters []DeadLetter
+
+	claim, dead := planReclaim(pending, minIdle, maxAttempts)
+	for _, entry := range dead {
+		dl, err := deadLetterMessage(ctx, stream, consumerGroup, entry.ID, entry.RetryCount, fmt.Sprintf("exceeded %d delivery attempts", maxAttempts))
+		if err != nil {
+			return reclaimed, deadLetters, err
+		}
+		deadLetters = append(deadLetters, *dl)
+	}
+
+	for _, entry := range claim {
+		// XCLAIM re-checks the idle time, so only one worker wins a message
+		messages, err := redisClient.XClaim(ctx, &redis.XClaimArgs{
+			Stream:   stream,
+			Group:    consumerGroup,
+			Consumer: consumerName,
+			MinIdle:  minIdle,
+			Messages: []string{entry.ID},
+		}).Result()
+		if err != nil {
+			return reclaimed, deadLetters, fmt.Errorf("failed to claim message %s: %w", entry.ID, err)
+		}
+
+		for _, msg := range messages {
+			job, err := parseJudgeJob(msg)
+			if err != nil {
+				dl, dlErr := deadLetterMessage(ctx, stream, consumerGroup, msg.ID, entry.RetryCount+1, err.Error())
+				if dlErr != nil {
+					return reclaimed, deadLetters, dlErr
+				}
+				deadLetters = append(deadLetters, *dl)
+				continue
+			}
+
+			reclaimed = append(reclaimed, JudgeMessage{
+				Job:        *job,
+				Stream:     stream,
+				MessageID:  msg.ID,
+				Deliveries: entry.RetryCount + 1,
+			})
+		}
+	}
+
+	return reclaimed, deadLetters, nil
+}
+
+// planReclaim splits pending entries idle for at least minIdle into those to
+// claim again and those delivered maxAttempts times or more, which go to the
+// dead-letter stream. Entries that are not idle long enough are left alone.
+func planReclaim(pending []redis.XPendingExt, minIdle time.Duration, maxAttempts int64) (claim, dead []redis.XPendingExt) {
+	for _, entry := range pending {
+		switch {
+		case entry.Idle < minIdle:
+			continue
+		case entry.RetryCount >= maxAttempts:
+			dead = append(dead, entry)
+		default:
+			claim = append(claim, entry)
+		}
+	}
+	return claim, dead
+}
+
+// deadLetterMessage copies a message to the dead-letter stream and
+// acknowledges it so it leaves the pending entries list
+func deadLetterMessage(ctx context.Context, stream, consumerGroup, messageID string, deliveries int64, reason string) (*DeadLetter, error) {
+	dl := &DeadLetter{
+		Stream:     stream,
+		MessageID:  messageID,
+		Deliveries: deliveries,
+		Reason:     reason,
+	}
+
+	messages, err := redisClient.XRange(ctx, stream, messageID, messageID).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read message %s: %w", messageID, err)
+	}
+
+	// The entry may already be trimmed from the stream; it is still acknowledged
+	if len(messages) > 0 {
+		data, _ := messages[0].Values["data"].(string)
+		if job, err := parseJudgeJob(messages[0]); err == nil {
+			dl.SubmissionID = job.SubmissionID
+		}
+
+		err := redisClient.XAdd(ctx, &redis.XAddArgs{
+			Stream: JudgeDeadLetterStream,
+			Values: map[string]interface{}{
+				"data":       data,
+				"stream":     stream,
+				"message_id": messageID,
+				"deliveries": deliveries,
+				"reason":     reason,
+				"dead_at":    time.Now().UTC().Format(time.RFC3339),
+			},
+		}).Err()
+		if err != nil {
+			return nil, fmt.Errorf("failed to dead-letter message %s: %w", messageID, err)
+		}
+	}
+
+	if err := AcknowledgeMessage(ctx, consumerGroup, stream, messageID); err != nil {
+		return nil, err
+	}
+
+	return dl, nil
+}
+
+// parseJudgeJob decodes the job stored in a stream message
+func parseJudgeJob(msg redis.XMessage) (*JudgeJob, error) {
+	dataStr, ok := msg.Values["data"].(string)
+	if !ok {
+		return nil, fmt.Errorf("message %s has no data field", msg.ID)
+	}
+
+	var job JudgeJob
+	if err := json.Unmarshal([]byte(dataStr), &job); err != nil {
+		return nil, fmt.Errorf("failed to parse message %s: %w", msg.ID, err)
+	}
+
+	// Version 1 payloads have no version field
+	if job.Version == 0 {
+		job.Version = 1
+	}
+	if job.Version > JudgeJobVersion {
+		return nil, fmt.Errorf("message %s has unsupported job version %d", msg.ID, job.Version)
+	}
+	if job.SubmissionID == "" {
+		return nil, fmt.Errorf("message %s has no submission_id", msg.ID)
+	}
+
+	return &job, nil
+}
@@ -0,0 +1,41 @@
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanReclaim(t *testing.T) {
+	const minIdle = time.Minute
+	const maxAttempts = 3
+
+	pending := []redis.XPendingExt{
+		{ID: "1-0", Idle: 30 * time.Second, RetryCount: 5}, // still being judged
+		{ID: "2-0", Idle: time.Minute, RetryCount: 1},
+		{ID: "3-0", Idle: 2 * time.Minute, RetryCount: 2},
+		{ID: "4-0", Idle: 2 * time.Minute, RetryCount: 3},
+		{ID: "5-0", Idle: time.Hour, RetryCount: 10},
+		{ID: "6-0", Idle: 59 * time.Second, RetryCount: 1},
+	}
+
+	claim, dead := planReclaim(pending, minIdle, maxAttempts)
+
+	ids := func(entries []redis.XPendingExt) []string {
+		var out []string
+		for _, e := range entries {
+			out = append(out, e.ID)
+		}
+		return out
+	}
+	assert.Equal(t, []string{"2-0", "3-0"}, ids(claim), "idle entries under the attempt limit are claimed")
+	assert.Equal(t, []string{"4-0", "5-0"}, ids(dead), "idle entries at the attempt limit are dead-lettered")
+}
+
+func TestPlanReclaimNothingPending(t *testing.T) {
+	claim, dead := planReclaim(nil, time.Minute, 3)
+	assert.Empty(t, claim)
+	assert.Empty(t, dead)
+}
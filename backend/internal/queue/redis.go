@@ -4,6 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -39,35 +42,56 @@ func GetRedisClient() *redis.Client {
 	return redisClient
 }
 
-// JudgeJob represents a job to be processed by the judge worker
+// JudgeJobVersion is the payload format written by EnqueueJudgeJob. Workers
+// decode every older version, but older workers cannot decode newer jobs, so
+// judge workers must be upgraded before the API servers that enqueue jobs.
+const JudgeJobVersion = 2
+
+// JudgeJob represents a job to be processed by the judge worker. Jobs only
+// reference the submission; the worker loads code, language and problem from
+// the database.
 type JudgeJob struct {
-	SubmissionID    string `json:"submission_id"`
-	ProblemID       string `json:"problem_id"`
-	UserID          string `json:"user_id"`
-	Code            string `json:"code"`
-	Language        string `json:"language"`
+	Version      int    `json:"version"`
+	SubmissionID string `json:"submission_id"`
+	// Priority overrides the lane chosen from the job (see LaneFor)
+	Priority string `json:"priority,omitempty"`
+
+	// Version 1 payloads (no version field) carried a copy of the
+	// submission. These fields are still decoded while old messages drain
+	// but are never written.
+	ProblemID       string `json:"problem_id,omitempty"`
+	UserID          string `json:"user_id,omitempty"`
+	Code            string `json:"code,omitempty"`
+	Language        string `json:"language,omitempty"`
 	LanguageVersion string `json:"language_version,omitempty"`
 	CourseID        string `json:"course_id,omitempty"`
 	ContestID       string `json:"contest_id,omitempty"`
 }
 
-// EnqueueJudgeJob adds a judge job to the Redis Stream
+// JudgeMessage is a judge job read from one of the lane streams
+type JudgeMessage struct {
+	Job        JudgeJob
+	Stream     string
+	MessageID  string
+	Deliveries int64
+}
+
+// EnqueueJudgeJob adds a judge job to the stream of its priority lane
 func EnqueueJudgeJob(ctx context.Context, job *JudgeJob) (string, error) {
 	if redisClient == nil {
 		return "", fmt.Errorf("Redis client not initialized")
 	}
 
-	// Serialize job to JSON
-	jobJSON, err := json.Marshal(job)
+	lane, jobJSON, err := encodeJudgeJob(job)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal job: %w", err)
+		return "", err
 	}
 
-	// Add to stream
+	// Add to the lane's stream
 	streamID, err := redisClient.XAdd(ctx, &redis.XAddArgs{
-		Stream: "judge:submissions",
+		Stream: lane.Stream,
 		Values: map[string]interface{}{
-			"data": string(jobJSON),
+			"data": jobJSON,
 		},
 	}).Result()
 
@@ -78,17 +102,66 @@ func EnqueueJudgeJob(ctx context.Context, job *JudgeJob) (string, error) {
 	return streamID, nil
 }
 
-// ConsumeJudgeJobs reads jobs from Redis Stream using consumer group
-func ConsumeJudgeJobs(ctx context.Context, consumerGroup, consumerName string, count int64) ([]JudgeJob, []string, error) {
+// encodeJudgeJob returns the lane of a job and its stream payload. The
+// current format is always written; requeued version 1 jobs are upgraded
+// here, keeping the lane they were routed to.
+func encodeJudgeJob(job *JudgeJob) (Lane, string, error) {
+	lane := LaneFor(job)
+	jobJSON, err := json.Marshal(JudgeJob{
+		Version:      JudgeJobVersion,
+		SubmissionID: job.SubmissionID,
+		Priority:     lane.Priority,
+	})
+	if err != nil {
+		return Lane{}, "", fmt.Errorf("failed to marshal job: %w", err)
+	}
+	return lane, string(jobJSON), nil
+}
+
+// ConsumeJudgeJobs reads up to count jobs from the lane streams using
+// consumer group. Lanes are polled in weighted order so busy high-priority
+// lanes are served first without starving the others; when every lane is
+// empty it blocks on all of them for up to 5 seconds. COUNT applies to each
+// stream of that read, so jobs beyond count are put back at the end of their
+// lane for other consumers.
+func ConsumeJudgeJobs(ctx context.Context, consumerGroup, consumerName string, count int64) ([]JudgeMessage, error) {
 	if redisClient == nil {
-		return nil, nil, fmt.Errorf("Redis client not initialized")
+		return nil, fmt.Errorf("Redis client not initialized")
+	}
+
+	for _, lane := range judgeLaneScheduler.order() {
+		streams, err := redisClient.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    consumerGroup,
+			Consumer: consumerName,
+			Streams:  []string{lane.Stream, ">"},
+			Count:    count,
+			Block:    -1, // Don't block, move on to the next lane
+		}).Result()
+		if err != nil {
+			if err == redis.Nil {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read from stream %s: %w", lane.Stream, err)
+		}
+
+		if messages := judgeMessages(streams); len(messages) > 0 {
+			return messages, nil
+		}
+	}
+
+	// Nothing queued anywhere, wait for the next job on any lane
+	keys := make([]string, 0, 2*len(JudgeLanes))
+	for _, lane := range JudgeLanes {
+		keys = append(keys, lane.Stream)
+	}
+	for range JudgeLanes {
+		keys = append(keys, ">")
 	}
 
-	// Read from stream
 	streams, err := redisClient.XReadGroup(ctx, &redis.XReadGroupArgs{
 		Group:    consumerGroup,
 		Consumer: consumerName,
-		Streams:  []string{"judge:submissions", ">"},
+		Streams:  keys,
 		Count:    count,
 		Block:    5 * time.Second, // Block for 5 seconds
 	}).Result()
@@ -96,46 +169,80 @@ func ConsumeJudgeJobs(ctx context.Context, consumerGroup, consumerName string, c
 	if err != nil {
 		if err == redis.Nil {
 			// No messages available
-			return nil, nil, nil
+			return nil, nil
 		}
-		return nil, nil, fmt.Errorf("failed to read from stream: %w", err)
+		return nil, fmt.Errorf("failed to read from stream: %w", err)
 	}
 
-	if len(streams) == 0 || len(streams[0].Messages) == 0 {
-		return nil, nil, nil
+	keep, release := limitJudgeMessages(judgeMessages(streams), count)
+	for i := range release {
+		if err := RequeueJudgeJob(ctx, consumerGroup, &release[i]); err != nil {
+			// The job stays pending here until the reclaimer hands it on
+			log.Printf("[QUEUE] Failed to release job %s of submission %s: %v", release[i].MessageID, release[i].Job.SubmissionID, err)
+		}
+	}
+	return keep, nil
+}
+
+// limitJudgeMessages keeps the first count messages in lane priority order
+// and returns the rest to be released
+func limitJudgeMessages(messages []JudgeMessage, count int64) (keep, release []JudgeMessage) {
+	if count <= 0 || int64(len(messages)) <= count {
+		return messages, nil
 	}
 
-	var jobs []JudgeJob
-	var messageIDs []string
+	rank := make(map[string]int, len(JudgeLanes))
+	for i, lane := range JudgeLanes {
+		rank[lane.Stream] = i
+	}
+	sorted := append([]JudgeMessage(nil), messages...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return rank[sorted[i].Stream] < rank[sorted[j].Stream]
+	})
+	return sorted[:count], sorted[count:]
+}
 
-	for _, msg := range streams[0].Messages {
-		messageIDs = append(messageIDs, msg.ID)
+// judgeMessages decodes the jobs of an XREADGROUP reply
+func judgeMessages(streams []redis.XStream) []JudgeMessage {
+	var messages []JudgeMessage
 
-		// Extract data field
-		dataStr, ok := msg.Values["data"].(string)
-		if !ok {
-			continue
-		}
+	for _, stream := range streams {
+		for _, msg := range stream.Messages {
+			// Malformed messages stay pending until the reclaimer dead-letters them
+			job, err := parseJudgeJob(msg)
+			if err != nil {
+				continue
+			}
 
-		// Parse JSON
-		var job JudgeJob
-		if err := json.Unmarshal([]byte(dataStr), &job); err != nil {
-			continue
+			messages = append(messages, JudgeMessage{
+				Job:        *job,
+				Stream:     stream.Stream,
+				MessageID:  msg.ID,
+				Deliveries: 1,
+			})
 		}
-
-		jobs = append(jobs, job)
 	}
 
-	return jobs, messageIDs, nil
+	return messages
+}
+
+// RequeueJudgeJob puts an unfinished job back at the end of its lane and
+// acknowledges the original message, so another consumer can pick it up
+// right away instead of waiting for the reclaimer
+func RequeueJudgeJob(ctx context.Context, consumerGroup string, msg *JudgeMessage) error {
+	if _, err := EnqueueJudgeJob(ctx, &msg.Job); err != nil {
+		return err
+	}
+	return AcknowledgeMessage(ctx, consumerGroup, msg.Stream, msg.MessageID)
 }
 
 // AcknowledgeMessage acknowledges a processed message
-func AcknowledgeMessage(ctx context.Context, consumerGroup string, messageID string) error {
+func AcknowledgeMessage(ctx context.Context, consumerGroup, stream, messageID string) error {
 	if redisClient == nil {
 		return fmt.Errorf("Redis client not initialized")
 	}
 
-	return redisClient.XAck(ctx, "judge:submissions", consumerGroup, messageID).Err()
+	return redisClient.XAck(ctx, stream, consumerGroup, messageID).Err()
 }
 
 // EnsureConsumerGroup ensures the consumer group exists
@@ -144,7 +251,21 @@ func EnsureConsumerGroup(ctx context.Context, streamName, groupName string) erro
 		return fmt.Errorf("Redis client not initialized")
 	}
 
-	// Try to create consumer group (ignores error if it already exists)
-	_ = redisClient.XGroupCreate(ctx, streamName, groupName, "0").Err()
+	// Create the stream along with the group so workers can start before
+	// the first job of a lane is enqueued
+	err := redisClient.XGroupCreateMkStream(ctx, streamName, groupName, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("failed to create consumer group on %s: %w", streamName, err)
+	}
+	return nil
+}
+
+// EnsureJudgeConsumerGroups ensures the consumer group exists on every lane
+func EnsureJudgeConsumerGroups(ctx context.Context, groupName string) error {
+	for _, lane := range JudgeLanes {
+		if err := EnsureConsumerGroup(ctx, lane.Stream, groupName); err != nil {
+			return err
+		}
+	}
 	return nil
 }
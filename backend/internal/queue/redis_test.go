@@ -0,0 +1,108 @@
+package queue
+
+import (
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJudgeJobRoundTrip(t *testing.T) {
+	lane, data, err := encodeJudgeJob(&JudgeJob{SubmissionID: "s1", Priority: PriorityRejudge})
+	require.NoError(t, err)
+	assert.Equal(t, PriorityRejudge, lane.Priority)
+	assert.JSONEq(t, `{"version":2,"submission_id":"s1","priority":"rejudge"}`, data)
+
+	job, err := parseJudgeJob(redis.XMessage{ID: "1-0", Values: map[string]interface{}{"data": data}})
+	require.NoError(t, err)
+	assert.Equal(t, JudgeJob{Version: JudgeJobVersion, SubmissionID: "s1", Priority: PriorityRejudge}, *job)
+}
+
+func TestParseVersion1JudgeJob(t *testing.T) {
+	// Written before jobs referenced submissions by ID
+	data := `{"submission_id":"s1","problem_id":"p1","user_id":"u1","code":"print(1)","language":"python","language_version":"3.10","contest_id":"c1"}`
+
+	job, err := parseJudgeJob(redis.XMessage{ID: "1-0", Values: map[string]interface{}{"data": data}})
+	require.NoError(t, err)
+	assert.Equal(t, JudgeJob{
+		Version:         1,
+		SubmissionID:    "s1",
+		ProblemID:       "p1",
+		UserID:          "u1",
+		Code:            "print(1)",
+		Language:        "python",
+		LanguageVersion: "3.10",
+		ContestID:       "c1",
+	}, *job)
+	assert.Equal(t, PriorityContest, LaneFor(job).Priority)
+
+	// Requeueing upgrades it to the current format on the same lane
+	lane, upgraded, err := encodeJudgeJob(job)
+	require.NoError(t, err)
+	assert.Equal(t, PriorityContest, lane.Priority)
+	assert.JSONEq(t, `{"version":2,"submission_id":"s1","priority":"contest"}`, upgraded)
+}
+
+func TestParseJudgeJobErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		values map[string]interface{}
+	}{
+		{name: "no data", values: map[string]interface{}{}},
+		{name: "not json", values: map[string]interface{}{"data": "{"}},
+		{name: "newer version", values: map[string]interface{}{"data": `{"version":3,"submission_id":"s1"}`}},
+		{name: "no submission", values: map[string]interface{}{"data": `{"version":2}`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseJudgeJob(redis.XMessage{ID: "1-0", Values: tt.values})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestJudgeMessagesDecodeBothVersions(t *testing.T) {
+	streams := []redis.XStream{
+		{Stream: "judge:submissions:contest", Messages: []redis.XMessage{
+			{ID: "1-0", Values: map[string]interface{}{"data": `{"submission_id":"old","code":"x","contest_id":"c1"}`}},
+			{ID: "2-0", Values: map[string]interface{}{"data": `not json`}},
+		}},
+		{Stream: "judge:submissions", Messages: []redis.XMessage{
+			{ID: "3-0", Values: map[string]interface{}{"data": `{"version":2,"submission_id":"new","priority":"normal"}`}},
+		}},
+	}
+
+	messages := judgeMessages(streams)
+
+	// The malformed message is left pending for the reclaimer to dead-letter
+	require.Len(t, messages, 2)
+	assert.Equal(t, "old", messages[0].Job.SubmissionID)
+	assert.Equal(t, 1, messages[0].Job.Version)
+	assert.Equal(t, "judge:submissions:contest", messages[0].Stream)
+	assert.Equal(t, "new", messages[1].Job.SubmissionID)
+	assert.Equal(t, 2, messages[1].Job.Version)
+	assert.Equal(t, int64(1), messages[1].Deliveries)
+}
+
+func TestLimitJudgeMessages(t *testing.T) {
+	// A blocking read returns up to COUNT jobs from every lane, in stream order
+	messages := []JudgeMessage{
+		{MessageID: "1-0", Stream: "judge:submissions"},
+		{MessageID: "2-0", Stream: "judge:submissions:rejudge"},
+		{MessageID: "3-0", Stream: "judge:submissions:contest"},
+	}
+
+	keep, release := limitJudgeMessages(messages, 1)
+	assert.Equal(t, []JudgeMessage{messages[2]}, keep, "the contest job is kept")
+	assert.Equal(t, []JudgeMessage{messages[0], messages[1]}, release)
+
+	keep, release = limitJudgeMessages(messages, 2)
+	assert.Equal(t, []JudgeMessage{messages[2], messages[0]}, keep)
+	assert.Equal(t, []JudgeMessage{messages[1]}, release)
+
+	keep, release = limitJudgeMessages(messages, 3)
+	assert.Equal(t, messages, keep)
+	assert.Empty(t, release)
+}
@@ -0,0 +1,42 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// streamTokenPrefix prefixes the Redis key of each stream token
+const streamTokenPrefix = "stream:token:"
+
+// ErrStreamTokenNotFound is returned for unknown, expired or used tokens
+var ErrStreamTokenNotFound = errors.New("stream token not found")
+
+// StoreStreamToken keeps a token's payload until it is consumed or ttl passes
+func StoreStreamToken(ctx context.Context, token string, payload []byte, ttl time.Duration) error {
+	if redisClient == nil {
+		return fmt.Errorf("Redis client not initialized")
+	}
+
+	return redisClient.Set(ctx, streamTokenPrefix+token, payload, ttl).Err()
+}
+
+// ConsumeStreamToken returns a token's payload and deletes it, so each token
+// opens at most one stream
+func ConsumeStreamToken(ctx context.Context, token string) ([]byte, error) {
+	if redisClient == nil {
+		return nil, fmt.Errorf("Redis client not initialized")
+	}
+
+	payload, err := redisClient.GetDel(ctx, streamTokenPrefix+token).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrStreamTokenNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stream token: %w", err)
+	}
+	return payload, nil
+}
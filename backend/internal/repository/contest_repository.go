@@ -460,6 +460,28 @@ func GetContestProblem(contestID, problemID string) (*ContestProblemItem, error)
 	return item, nil
 }
 
+// GetContestAllowedLanguages returns the languages a contest problem accepts:
+// the problem's own list, or the contest's when the problem sets none. An
+// empty result allows every language.
+func GetContestAllowedLanguages(contestID, problemID string) ([]string, error) {
+	contestProblem, err := GetContestProblem(contestID, problemID)
+	if err != nil {
+		return nil, err
+	}
+	if len(contestProblem.AllowedLanguages) > 0 {
+		return contestProblem.AllowedLanguages, nil
+	}
+
+	var contest models.Contest
+	if err := getDB().Select("allowed_languages").Where("id = ? AND deleted_at IS NULL", contestID).First(&contest).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("contest not found")
+		}
+		return nil, fmt.Errorf("failed to fetch contest: %w", err)
+	}
+	return contest.AllowedLanguages, nil
+}
+
 // AddProblemToContest adds a problem to a contest
 func AddProblemToContest(contestProblem *models.ContestProblem) error {
 	dbConn := getDB()
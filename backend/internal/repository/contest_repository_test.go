@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+
+	"codehustle/backend/internal/db"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// queryResult is one row returned for a query
+type queryResult struct {
+	columns []string
+	row     []driver.Value
+}
+
+// queryConnector answers queries with results in order and records them
+type queryConnector struct {
+	results []queryResult
+	queries []string
+	args    [][]driver.Value
+}
+
+func (c *queryConnector) Connect(context.Context) (driver.Conn, error) { return queryConn{c}, nil }
+func (c *queryConnector) Driver() driver.Driver                        { return nil }
+
+type queryConn struct{ c *queryConnector }
+
+func (queryConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (queryConn) Close() error                        { return nil }
+func (queryConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (conn queryConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c := conn.c
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	c.queries = append(c.queries, query)
+	c.args = append(c.args, values)
+
+	if len(c.queries) > len(c.results) {
+		return nil, errors.New("unexpected query")
+	}
+	result := c.results[len(c.queries)-1]
+	return &resultRows{result: result}, nil
+}
+
+type resultRows struct {
+	result queryResult
+	done   bool
+}
+
+func (r *resultRows) Columns() []string { return r.result.columns }
+func (r *resultRows) Close() error      { return nil }
+
+func (r *resultRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	copy(dest, r.result.row)
+	return nil
+}
+
+// useQueryDB points db.DB at a connection answering queries with results
+func useQueryDB(t *testing.T, results ...queryResult) *queryConnector {
+	t.Helper()
+
+	connector := &queryConnector{results: results}
+	pool := sql.OpenDB(connector)
+	t.Cleanup(func() { pool.Close() })
+
+	conn, err := gorm.Open(mysql.New(mysql.Config{Conn: pool, SkipInitializeWithVersion: true}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	saved := db.DB
+	db.DB = conn
+	t.Cleanup(func() { db.DB = saved })
+	return connector
+}
+
+func TestGetContestSubmissionCount(t *testing.T) {
+	connector := useQueryDB(t, queryResult{columns: []string{"count(*)"}, row: []driver.Value{int64(3)}})
+
+	count, err := GetContestSubmissionCount("c1", "p1", "u1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+
+	require.Len(t, connector.queries, 1)
+	assert.Contains(t, connector.queries[0], "contest_id = ? AND problem_id = ? AND user_id = ?")
+	assert.Equal(t, []driver.Value{"c1", "p1", "u1"}, connector.args[0])
+}
+
+func TestGetContestAllowedLanguages(t *testing.T) {
+	contestProblem := func(allowed driver.Value) queryResult {
+		return queryResult{
+			columns: []string{"contest_id", "problem_id", "allowed_languages"},
+			row:     []driver.Value{"c1", "p1", allowed},
+		}
+	}
+
+	t.Run("problem override", func(t *testing.T) {
+		connector := useQueryDB(t, contestProblem([]byte(`["cpp"]`)))
+
+		allowed, err := GetContestAllowedLanguages("c1", "p1")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"cpp"}, allowed)
+		assert.Len(t, connector.queries, 1, "the contest is not loaded")
+	})
+
+	t.Run("contest default", func(t *testing.T) {
+		connector := useQueryDB(t,
+			contestProblem(nil),
+			queryResult{columns: []string{"allowed_languages"}, row: []driver.Value{[]byte(`["cpp","python"]`)}},
+		)
+
+		allowed, err := GetContestAllowedLanguages("c1", "p1")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"cpp", "python"}, allowed)
+		require.Len(t, connector.queries, 2)
+		assert.Contains(t, connector.queries[1], "`contests`")
+	})
+
+	t.Run("every language", func(t *testing.T) {
+		useQueryDB(t,
+			contestProblem(nil),
+			queryResult{columns: []string{"allowed_languages"}, row: []driver.Value{nil}},
+		)
+
+		allowed, err := GetContestAllowedLanguages("c1", "p1")
+		require.NoError(t, err)
+		assert.Empty(t, allowed)
+	})
+}
@@ -0,0 +1,384 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"codehustle/backend/internal/db"
+	"codehustle/backend/internal/models"
+)
+
+// RefreshContestScore rebuilds a user's scoreboard cell for one problem from
+// their submissions to it during the contest. The submissions are read with
+// a row lock so verdicts finishing at the same time update the cell in turn.
+// Whether the cell was revealed on a frozen scoreboard is kept.
+func RefreshContestScore(contestID, userID, problemID string) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		var contest models.Contest
+		if err := tx.Select("id", "start_at", "end_at", "rule_type", "freeze_at").Where("id = ?", contestID).First(&contest).Error; err != nil {
+			return fmt.Errorf("failed to fetch contest: %w", err)
+		}
+
+		var submissions []models.Submission
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "status", "score", "submitted_at").
+			Where("contest_id = ? AND user_id = ? AND problem_id = ?", contestID, userID, problemID).
+			Where("submitted_at BETWEEN ? AND ?", contest.StartAt, contest.EndAt).
+			Order("submitted_at ASC, id ASC").
+			Find(&submissions).Error; err != nil {
+			return fmt.Errorf("failed to fetch submissions: %w", err)
+		}
+
+		cell := models.ContestScore{
+			ContestID:     contestID,
+			UserID:        userID,
+			ProblemID:     problemID,
+			ContestResult: buildContestResult(&contest, submissions),
+			Frozen:        frozenContestResult(&contest, submissions),
+		}
+		return tx.Omit("Revealed").Save(&cell).Error
+	})
+}
+
+// buildContestResult folds submissions, oldest first, into a result.
+// Submissions the judge failed on count for nothing; compile errors are not
+// ICPC tries. Under ICPC rules nothing after the first accepted submission
+// changes the result.
+func buildContestResult(contest *models.Contest, submissions []models.Submission) models.ContestResult {
+	icpc := contest.RuleType == models.RuleTypeICPC
+
+	var r models.ContestResult
+	for _, s := range submissions {
+		switch s.Status {
+		case "pending", "running":
+			if !icpc || !r.Solved {
+				r.Pending++
+			}
+			continue
+		case "system_error", "judgement_failed":
+			continue
+		}
+
+		score := 0
+		if s.Score != nil {
+			score = *s.Score
+		}
+		r.LastScore = score
+		if score > r.BestScore {
+			r.BestScore = score
+		}
+
+		if r.Solved || s.Status == "compile_error" {
+			continue
+		}
+		r.Attempts++
+		if s.Status == "accepted" {
+			solvedAt := s.SubmittedAt
+			r.Solved = true
+			r.SolvedAt = &solvedAt
+			r.Penalty = contestMinute(contest.StartAt, solvedAt) + (r.Attempts-1)*models.ICPCPenaltyMinutes
+		}
+	}
+	return r
+}
+
+// frozenContestResult is the result of the submissions before the freeze,
+// with every later submission shown as pending
+func frozenContestResult(contest *models.Contest, submissions []models.Submission) models.ContestResult {
+	if contest.FreezeAt == nil {
+		return buildContestResult(contest, submissions)
+	}
+
+	before := len(submissions)
+	for i, s := range submissions {
+		if !s.SubmittedAt.Before(*contest.FreezeAt) {
+			before = i
+			break
+		}
+	}
+
+	r := buildContestResult(contest, submissions[:before])
+	if contest.RuleType != models.RuleTypeICPC || !r.Solved {
+		r.Pending += len(submissions) - before
+	}
+	return r
+}
+
+// contestMinute returns the whole minutes from the contest start to t
+func contestMinute(startAt, t time.Time) int {
+	return int(t.Sub(startAt) / time.Minute)
+}
+
+// RebuildContestScores recomputes every scoreboard cell of a contest, e.g.
+// for contests judged before cells were kept or after its times changed.
+// Revealed frozen results are hidden again.
+func RebuildContestScores(contestID string) (int, error) {
+	var pairs []struct {
+		UserID    string
+		ProblemID string
+	}
+	if err := db.DB.Model(&models.Submission{}).
+		Distinct("user_id", "problem_id").
+		Where("contest_id = ?", contestID).
+		Scan(&pairs).Error; err != nil {
+		return 0, fmt.Errorf("failed to list contest submitters: %w", err)
+	}
+
+	if err := db.DB.Where("contest_id = ?", contestID).Delete(&models.ContestScore{}).Error; err != nil {
+		return 0, fmt.Errorf("failed to clear contest scores: %w", err)
+	}
+	for _, p := range pairs {
+		if err := RefreshContestScore(contestID, p.UserID, p.ProblemID); err != nil {
+			return 0, err
+		}
+	}
+	return len(pairs), nil
+}
+
+// ScoreboardProblem is a column of the scoreboard
+type ScoreboardProblem struct {
+	ProblemID string `json:"problem_id"`
+	Title     string `json:"title"`
+	Ordinal   *int   `json:"ordinal,omitempty"`
+	Points    int    `json:"points"`
+}
+
+// ScoreboardCell is a participant's result on one problem
+type ScoreboardCell struct {
+	ProblemID    string `json:"problem_id"`
+	Attempts     int    `json:"attempts"`
+	Pending      int    `json:"pending"`
+	Solved       bool   `json:"solved"`
+	SolvedMinute *int   `json:"solved_minute,omitempty"` // minutes from the contest start
+	Penalty      int    `json:"penalty"`
+	Score        int    `json:"score"`
+}
+
+// ScoreboardRow is a participant's line on the scoreboard
+type ScoreboardRow struct {
+	Rank     int              `json:"rank"`
+	UserID   string           `json:"user_id"`
+	Username string           `json:"username"`
+	Solved   int              `json:"solved"`
+	Penalty  int              `json:"penalty"`
+	Score    int              `json:"score"`
+	Cells    []ScoreboardCell `json:"cells"` // in problem order
+}
+
+// ContestScoreboard is the ranked scoreboard of a contest
+type ContestScoreboard struct {
+	ContestID string              `json:"contest_id"`
+	RuleType  string              `json:"rule_type"`
+	Frozen    bool                `json:"frozen"` // submissions after freeze_at show as pending
+	FreezeAt  *time.Time          `json:"freeze_at,omitempty"`
+	Problems  []ScoreboardProblem `json:"problems"`
+	Rows      []ScoreboardRow     `json:"rows"`
+}
+
+// GetContestScoreboard ranks the registered participants of a contest from
+// its stored scoreboard cells. A frozen scoreboard shows the results visible
+// during the freeze instead of the full ones.
+func GetContestScoreboard(contest *models.Contest, frozen bool) (*ContestScoreboard, error) {
+	board, _, err := loadContestScoreboard(contest, frozen)
+	return board, err
+}
+
+// loadContestScoreboard builds the scoreboard and returns the cells it was
+// built from, keyed by user and problem
+func loadContestScoreboard(contest *models.Contest, frozen bool) (*ContestScoreboard, map[string]models.ContestScore, error) {
+	dbConn := getDB()
+
+	problems, err := ListContestProblems(contest.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var participants []scoreboardParticipant
+	if err := dbConn.Table("contest_participants").
+		Select("contest_participants.user_id, users.name as username").
+		Joins("LEFT JOIN users ON contest_participants.user_id = users.id").
+		Where("contest_participants.contest_id = ?", contest.ID).
+		Scan(&participants).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch participants: %w", err)
+	}
+
+	var scores []models.ContestScore
+	if err := dbConn.Where("contest_id = ?", contest.ID).Find(&scores).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch contest scores: %w", err)
+	}
+	cells := make(map[string]models.ContestScore, len(scores))
+	for _, s := range scores {
+		cells[scoreKey(s.UserID, s.ProblemID)] = s
+	}
+
+	board := &ContestScoreboard{
+		ContestID: contest.ID,
+		RuleType:  contest.RuleType,
+		Frozen:    frozen,
+		FreezeAt:  contest.FreezeAt,
+		Problems:  make([]ScoreboardProblem, len(problems)),
+	}
+	for i, p := range problems {
+		board.Problems[i] = ScoreboardProblem{
+			ProblemID: p.ProblemID,
+			Title:     p.Title,
+			Ordinal:   p.Ordinal,
+			Points:    p.Points,
+		}
+	}
+
+	board.Rows = scoreboardRows(contest, board.Problems, participants, cells, frozen)
+	return board, cells, nil
+}
+
+// scoreboardParticipant is a registered participant of a contest
+type scoreboardParticipant struct {
+	UserID   string
+	Username string
+}
+
+// scoreboardRows builds and ranks a row per participant from their cells,
+// keyed by scoreKey. Frozen rows show the results visible during the freeze.
+func scoreboardRows(
+	contest *models.Contest,
+	problems []ScoreboardProblem,
+	participants []scoreboardParticipant,
+	cells map[string]models.ContestScore,
+	frozen bool,
+) []ScoreboardRow {
+	icpc := contest.RuleType == models.RuleTypeICPC
+	rows := make([]ScoreboardRow, 0, len(participants))
+	lastSolve := make(map[string]int, len(participants))
+	for _, p := range participants {
+		row := ScoreboardRow{
+			UserID:   p.UserID,
+			Username: p.Username,
+			Cells:    make([]ScoreboardCell, len(problems)),
+		}
+		for i, problem := range problems {
+			s := cells[scoreKey(p.UserID, problem.ProblemID)]
+			result := s.ContestResult
+			if frozen {
+				result = s.Visible()
+			}
+
+			cell := ScoreboardCell{
+				ProblemID: problem.ProblemID,
+				Attempts:  result.Attempts,
+				Pending:   result.Pending,
+				Solved:    result.Solved,
+			}
+			if icpc {
+				if result.Solved && result.SolvedAt != nil {
+					minute := contestMinute(contest.StartAt, *result.SolvedAt)
+					cell.SolvedMinute = &minute
+					cell.Penalty = result.Penalty
+					row.Solved++
+					row.Penalty += result.Penalty
+					if minute > lastSolve[p.UserID] {
+						lastSolve[p.UserID] = minute
+					}
+				}
+			} else {
+				cell.Score = result.Score(contest.RuleType)
+				row.Score += cell.Score
+				if result.Solved {
+					row.Solved++
+				}
+			}
+			row.Cells[i] = cell
+		}
+		rows = append(rows, row)
+	}
+
+	rankScoreboard(rows, icpc, lastSolve)
+	return rows
+}
+
+func scoreKey(userID, problemID string) string {
+	return userID + "/" + problemID
+}
+
+// RevealNextContestScore reveals the next frozen result, resolver style. It
+// returns nil and marks the contest unfrozen once nothing is hidden.
+func RevealNextContestScore(contest *models.Contest) (*models.ContestScore, error) {
+	board, cells, err := loadContestScoreboard(contest, true)
+	if err != nil {
+		return nil, err
+	}
+
+	cell, ok := nextHiddenScore(board, cells)
+	if !ok {
+		return nil, UnfreezeContest(contest)
+	}
+
+	if err := getDB().Model(&models.ContestScore{}).
+		Where("contest_id = ? AND user_id = ? AND problem_id = ?", contest.ID, cell.UserID, cell.ProblemID).
+		Update("revealed", true).Error; err != nil {
+		return nil, fmt.Errorf("failed to reveal contest score: %w", err)
+	}
+	cell.Revealed = true
+	return &cell, nil
+}
+
+// nextHiddenScore returns the first hidden cell, in problem order, of the
+// lowest ranked participant on the frozen scoreboard
+func nextHiddenScore(board *ContestScoreboard, cells map[string]models.ContestScore) (models.ContestScore, bool) {
+	for i := len(board.Rows) - 1; i >= 0; i-- {
+		row := board.Rows[i]
+		for _, problem := range board.Problems {
+			cell, ok := cells[scoreKey(row.UserID, problem.ProblemID)]
+			if ok && cell.HasHiddenResult() {
+				return cell, true
+			}
+		}
+	}
+	return models.ContestScore{}, false
+}
+
+// UnfreezeContest shows every result on the public scoreboard
+func UnfreezeContest(contest *models.Contest) error {
+	now := time.Now()
+	if err := getDB().Model(&models.Contest{}).Where("id = ?", contest.ID).Update("unfrozen_at", &now).Error; err != nil {
+		return fmt.Errorf("failed to unfreeze contest: %w", err)
+	}
+	contest.UnfrozenAt = &now
+	return nil
+}
+
+// rankScoreboard sorts rows best first and numbers them. ICPC rows rank by
+// solved problems, then penalty, then the earlier last solve; other rules by
+// total score. Rows equal on every key share a rank.
+func rankScoreboard(rows []ScoreboardRow, icpc bool, lastSolve map[string]int) {
+	compare := func(a, b ScoreboardRow) int {
+		if icpc {
+			switch {
+			case a.Solved != b.Solved:
+				return b.Solved - a.Solved
+			case a.Penalty != b.Penalty:
+				return a.Penalty - b.Penalty
+			default:
+				return lastSolve[a.UserID] - lastSolve[b.UserID]
+			}
+		}
+		return b.Score - a.Score
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		if c := compare(rows[i], rows[j]); c != 0 {
+			return c < 0
+		}
+		return rows[i].Username < rows[j].Username
+	})
+	for i := range rows {
+		if i > 0 && compare(rows[i-1], rows[i]) == 0 {
+			rows[i].Rank = rows[i-1].Rank
+		} else {
+			rows[i].Rank = i + 1
+		}
+	}
+}
@@ -0,0 +1,336 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"codehustle/backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var contestStart = time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+func testContest(ruleType string) *models.Contest {
+	return &models.Contest{
+		ID:       "c1",
+		RuleType: ruleType,
+		StartAt:  contestStart,
+		EndAt:    contestStart.Add(5 * time.Hour),
+	}
+}
+
+// submission returns a submission made minute minutes into the contest
+func submission(minute int, status string, score int) models.Submission {
+	return models.Submission{
+		Status:      status,
+		Score:       &score,
+		SubmittedAt: contestStart.Add(time.Duration(minute)*time.Minute + 30*time.Second),
+	}
+}
+
+func at(minute int) *time.Time {
+	t := contestStart.Add(time.Duration(minute)*time.Minute + 30*time.Second)
+	return &t
+}
+
+func TestBuildContestResult(t *testing.T) {
+	tests := []struct {
+		name        string
+		ruleType    string
+		submissions []models.Submission
+		want        models.ContestResult
+	}{
+		{
+			name:     "no submissions",
+			ruleType: models.RuleTypeICPC,
+		},
+		{
+			name:        "icpc first try",
+			ruleType:    models.RuleTypeICPC,
+			submissions: []models.Submission{submission(17, "accepted", 100)},
+			want:        models.ContestResult{Attempts: 1, Solved: true, SolvedAt: at(17), Penalty: 17, BestScore: 100, LastScore: 100},
+		},
+		{
+			name:     "icpc penalty counts wrong tries",
+			ruleType: models.RuleTypeICPC,
+			submissions: []models.Submission{
+				submission(5, "wrong_answer", 0),
+				submission(12, "time_limit_exceeded", 0),
+				submission(40, "accepted", 100),
+			},
+			want: models.ContestResult{Attempts: 3, Solved: true, SolvedAt: at(40), Penalty: 40 + 2*models.ICPCPenaltyMinutes, BestScore: 100, LastScore: 100},
+		},
+		{
+			name:     "icpc compile errors are not tries",
+			ruleType: models.RuleTypeICPC,
+			submissions: []models.Submission{
+				submission(3, "compile_error", 0),
+				submission(9, "wrong_answer", 0),
+				submission(10, "compile_error", 0),
+				submission(30, "accepted", 100),
+			},
+			want: models.ContestResult{Attempts: 2, Solved: true, SolvedAt: at(30), Penalty: 30 + models.ICPCPenaltyMinutes, BestScore: 100, LastScore: 100},
+		},
+		{
+			name:     "icpc judge failures count for nothing",
+			ruleType: models.RuleTypeICPC,
+			submissions: []models.Submission{
+				submission(3, "system_error", 0),
+				submission(4, "judgement_failed", 0),
+				submission(8, "accepted", 100),
+			},
+			want: models.ContestResult{Attempts: 1, Solved: true, SolvedAt: at(8), Penalty: 8, BestScore: 100, LastScore: 100},
+		},
+		{
+			name:     "icpc ignores submissions after the first accepted",
+			ruleType: models.RuleTypeICPC,
+			submissions: []models.Submission{
+				submission(8, "accepted", 100),
+				submission(9, "wrong_answer", 0),
+				submission(10, "pending", 0),
+			},
+			want: models.ContestResult{Attempts: 1, Solved: true, SolvedAt: at(8), Penalty: 8, BestScore: 100, LastScore: 0},
+		},
+		{
+			name:     "icpc unsolved",
+			ruleType: models.RuleTypeICPC,
+			submissions: []models.Submission{
+				submission(8, "wrong_answer", 0),
+				submission(9, "running", 0),
+			},
+			want: models.ContestResult{Attempts: 1, Pending: 1},
+		},
+		{
+			name:     "ioi keeps the best score",
+			ruleType: models.RuleTypeIOI,
+			submissions: []models.Submission{
+				submission(8, "partial", 60),
+				submission(9, "wrong_answer", 20),
+				submission(10, "pending", 0),
+			},
+			want: models.ContestResult{Attempts: 2, Pending: 1, BestScore: 60, LastScore: 20},
+		},
+		{
+			name:     "oi keeps judging after accepted",
+			ruleType: models.RuleTypeOI,
+			submissions: []models.Submission{
+				submission(8, "accepted", 100),
+				submission(9, "wrong_answer", 40),
+			},
+			want: models.ContestResult{Attempts: 1, Solved: true, SolvedAt: at(8), Penalty: 8, BestScore: 100, LastScore: 40},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildContestResult(testContest(tt.ruleType), tt.submissions)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.want.Score(tt.ruleType), got.Score(tt.ruleType))
+		})
+	}
+}
+
+func TestRankScoreboard(t *testing.T) {
+	t.Run("icpc", func(t *testing.T) {
+		rows := []ScoreboardRow{
+			{UserID: "u1", Username: "dave", Solved: 2, Penalty: 90},
+			{UserID: "u2", Username: "carol", Solved: 3, Penalty: 200},
+			{UserID: "u3", Username: "bob", Solved: 2, Penalty: 90},
+			{UserID: "u4", Username: "alice", Solved: 2, Penalty: 90},
+			{UserID: "u5", Username: "erin", Solved: 2, Penalty: 60},
+			{UserID: "u6", Username: "frank"},
+		}
+		// bob and alice tie on solved, penalty and last solve; dave solved
+		// his last problem later
+		lastSolve := map[string]int{"u1": 70, "u2": 150, "u3": 50, "u4": 50, "u5": 45}
+
+		rankScoreboard(rows, true, lastSolve)
+
+		var order []string
+		var ranks []int
+		for _, r := range rows {
+			order = append(order, r.Username)
+			ranks = append(ranks, r.Rank)
+		}
+		assert.Equal(t, []string{"carol", "erin", "alice", "bob", "dave", "frank"}, order)
+		assert.Equal(t, []int{1, 2, 3, 3, 5, 6}, ranks)
+	})
+
+	t.Run("score", func(t *testing.T) {
+		rows := []ScoreboardRow{
+			{UserID: "u1", Username: "bob", Score: 150, Solved: 1},
+			{UserID: "u2", Username: "alice", Score: 150, Solved: 0, Penalty: 500},
+			{UserID: "u3", Username: "carol", Score: 300},
+			{UserID: "u4", Username: "dave"},
+		}
+
+		rankScoreboard(rows, false, nil)
+
+		var order []string
+		var ranks []int
+		for _, r := range rows {
+			order = append(order, r.Username)
+			ranks = append(ranks, r.Rank)
+		}
+		assert.Equal(t, []string{"carol", "alice", "bob", "dave"}, order)
+		assert.Equal(t, []int{1, 2, 2, 4}, ranks)
+	})
+}
+
+func TestScoreboardRows(t *testing.T) {
+	problems := []ScoreboardProblem{{ProblemID: "p1"}, {ProblemID: "p2"}}
+	participants := []scoreboardParticipant{{UserID: "u1", Username: "alice"}, {UserID: "u2", Username: "bob"}}
+
+	t.Run("icpc", func(t *testing.T) {
+		cells := map[string]models.ContestScore{
+			scoreKey("u1", "p1"): {ContestResult: models.ContestResult{Attempts: 2, Solved: true, SolvedAt: at(30), Penalty: 50}},
+			scoreKey("u1", "p2"): {ContestResult: models.ContestResult{Attempts: 1, Pending: 1}},
+			scoreKey("u2", "p2"): {ContestResult: models.ContestResult{Attempts: 1, Solved: true, SolvedAt: at(10), Penalty: 10}},
+		}
+
+		rows := scoreboardRows(testContest(models.RuleTypeICPC), problems, participants, cells, false)
+
+		solved30, solved10 := 30, 10
+		assert.Equal(t, []ScoreboardRow{
+			{Rank: 1, UserID: "u2", Username: "bob", Solved: 1, Penalty: 10, Cells: []ScoreboardCell{
+				{ProblemID: "p1"},
+				{ProblemID: "p2", Attempts: 1, Solved: true, SolvedMinute: &solved10, Penalty: 10},
+			}},
+			{Rank: 2, UserID: "u1", Username: "alice", Solved: 1, Penalty: 50, Cells: []ScoreboardCell{
+				{ProblemID: "p1", Attempts: 2, Solved: true, SolvedMinute: &solved30, Penalty: 50},
+				{ProblemID: "p2", Attempts: 1, Pending: 1},
+			}},
+		}, rows)
+	})
+
+	t.Run("ioi", func(t *testing.T) {
+		cells := map[string]models.ContestScore{
+			scoreKey("u1", "p1"): {ContestResult: models.ContestResult{Attempts: 2, BestScore: 70, LastScore: 30}},
+			scoreKey("u1", "p2"): {ContestResult: models.ContestResult{Attempts: 1, Solved: true, BestScore: 100, LastScore: 100}},
+			scoreKey("u2", "p1"): {ContestResult: models.ContestResult{Attempts: 1, Solved: true, BestScore: 100, LastScore: 100}},
+		}
+
+		rows := scoreboardRows(testContest(models.RuleTypeIOI), problems, participants, cells, false)
+
+		assert.Equal(t, []ScoreboardRow{
+			{Rank: 1, UserID: "u1", Username: "alice", Solved: 1, Score: 170, Cells: []ScoreboardCell{
+				{ProblemID: "p1", Attempts: 2, Score: 70},
+				{ProblemID: "p2", Attempts: 1, Solved: true, Score: 100},
+			}},
+			{Rank: 2, UserID: "u2", Username: "bob", Solved: 1, Score: 100, Cells: []ScoreboardCell{
+				{ProblemID: "p1", Attempts: 1, Solved: true, Score: 100},
+				{ProblemID: "p2"},
+			}},
+		}, rows)
+	})
+}
+
+func TestFrozenContestResult(t *testing.T) {
+	freezeAt := contestStart.Add(4 * time.Hour)
+	frozenContest := func(ruleType string) *models.Contest {
+		c := testContest(ruleType)
+		c.FreezeAt = &freezeAt
+		return c
+	}
+
+	tests := []struct {
+		name        string
+		contest     *models.Contest
+		submissions []models.Submission
+		want        models.ContestResult
+	}{
+		{
+			name:    "no freeze shows everything",
+			contest: testContest(models.RuleTypeICPC),
+			submissions: []models.Submission{
+				submission(100, "wrong_answer", 0),
+				submission(250, "accepted", 100),
+			},
+			want: models.ContestResult{Attempts: 2, Solved: true, SolvedAt: at(250), Penalty: 250 + models.ICPCPenaltyMinutes, BestScore: 100, LastScore: 100},
+		},
+		{
+			name:    "icpc tries after the freeze are pending",
+			contest: frozenContest(models.RuleTypeICPC),
+			submissions: []models.Submission{
+				submission(100, "wrong_answer", 0),
+				submission(240, "accepted", 100),
+				submission(250, "wrong_answer", 0),
+			},
+			want: models.ContestResult{Attempts: 1, Pending: 2},
+		},
+		{
+			name:    "icpc solved before the freeze hides nothing",
+			contest: frozenContest(models.RuleTypeICPC),
+			submissions: []models.Submission{
+				submission(100, "accepted", 100),
+				submission(250, "wrong_answer", 0),
+			},
+			want: models.ContestResult{Attempts: 1, Solved: true, SolvedAt: at(100), Penalty: 100, BestScore: 100, LastScore: 100},
+		},
+		{
+			name:    "ioi scores after the freeze are pending",
+			contest: frozenContest(models.RuleTypeIOI),
+			submissions: []models.Submission{
+				submission(100, "partial", 40),
+				submission(250, "accepted", 100),
+			},
+			want: models.ContestResult{Attempts: 1, Pending: 1, BestScore: 40, LastScore: 40},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, frozenContestResult(tt.contest, tt.submissions))
+		})
+	}
+}
+
+// TestRevealOrder reveals a frozen ICPC scoreboard to the end: always the
+// lowest ranked participant's first hidden cell, re-ranking after each one
+func TestRevealOrder(t *testing.T) {
+	contest := testContest(models.RuleTypeICPC)
+	problems := []ScoreboardProblem{{ProblemID: "p1"}, {ProblemID: "p2"}}
+	participants := []scoreboardParticipant{{UserID: "u1", Username: "alice"}, {UserID: "u2", Username: "bob"}}
+
+	hidden := func(userID, problemID string, full models.ContestResult) models.ContestScore {
+		return models.ContestScore{
+			UserID:        userID,
+			ProblemID:     problemID,
+			ContestResult: full,
+			Frozen:        models.ContestResult{Pending: 1},
+		}
+	}
+	cells := map[string]models.ContestScore{
+		// alice leads with p1 solved before the freeze
+		scoreKey("u1", "p1"): {UserID: "u1", ProblemID: "p1", ContestResult: models.ContestResult{Attempts: 1, Solved: true, SolvedAt: at(20), Penalty: 20}, Frozen: models.ContestResult{Attempts: 1, Solved: true, SolvedAt: at(20), Penalty: 20}},
+		scoreKey("u1", "p2"): hidden("u1", "p2", models.ContestResult{Attempts: 1}),
+		scoreKey("u2", "p1"): hidden("u2", "p1", models.ContestResult{Attempts: 1, Solved: true, SolvedAt: at(250), Penalty: 250}),
+		scoreKey("u2", "p2"): hidden("u2", "p2", models.ContestResult{Attempts: 1, Solved: true, SolvedAt: at(260), Penalty: 260}),
+	}
+
+	frozen := scoreboardRows(contest, problems, participants, cells, true)
+	assert.Equal(t, "alice", frozen[0].Username)
+	assert.Equal(t, 0, frozen[1].Solved, "bob's solutions after the freeze are hidden")
+	assert.Equal(t, 1, frozen[1].Cells[0].Pending)
+
+	var revealed []string
+	for {
+		board := &ContestScoreboard{Problems: problems, Rows: scoreboardRows(contest, problems, participants, cells, true)}
+		cell, ok := nextHiddenScore(board, cells)
+		if !ok {
+			break
+		}
+		cell.Revealed = true
+		cells[scoreKey(cell.UserID, cell.ProblemID)] = cell
+		revealed = append(revealed, cell.UserID+"/"+cell.ProblemID)
+	}
+
+	// bob is last, so his cells come first; after p1 he still trails alice
+	// on penalty, and once p2 puts him ahead alice's hidden cell is last
+	assert.Equal(t, []string{"u2/p1", "u2/p2", "u1/p2"}, revealed)
+
+	final := scoreboardRows(contest, problems, participants, cells, true)
+	assert.Equal(t, scoreboardRows(contest, problems, participants, cells, false), final, "a fully revealed board is the full board")
+	assert.Equal(t, "bob", final[0].Username)
+}
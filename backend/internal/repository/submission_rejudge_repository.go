@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"codehustle/backend/internal/db"
+	"codehustle/backend/internal/models"
+)
+
+// RejudgeFilter selects the submissions of a problem or contest to rejudge
+type RejudgeFilter struct {
+	ProblemID string
+	ContestID string
+	Statuses  []string
+	From      *time.Time
+	To        *time.Time
+}
+
+// ListSubmissionsForRejudge returns the submissions matching the filter,
+// oldest first. Submissions still waiting for or under judging are skipped.
+func ListSubmissionsForRejudge(filter RejudgeFilter) ([]models.Submission, error) {
+	query := db.DB.Model(&models.Submission{}).
+		Where("status NOT IN ?", []string{"pending", "running"})
+	if filter.ProblemID != "" {
+		query = query.Where("problem_id = ?", filter.ProblemID)
+	}
+	if filter.ContestID != "" {
+		query = query.Where("contest_id = ?", filter.ContestID)
+	}
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status IN ?", filter.Statuses)
+	}
+	if filter.From != nil {
+		query = query.Where("submitted_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("submitted_at <= ?", *filter.To)
+	}
+
+	var submissions []models.Submission
+	err := query.Order("submitted_at ASC").Find(&submissions).Error
+	return submissions, err
+}
+
+// ResetSubmissionForRejudge records the submission's current verdict in the
+// rejudge audit log, drops its test case and subtask results and puts it
+// back to pending. It returns false, changing nothing, if the submission is
+// already waiting for or under judging, e.g. from a concurrent rejudge.
+func ResetSubmissionForRejudge(s *models.Submission, rejudgedBy string) (bool, error) {
+	reset := false
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		// The status guard makes the reset atomic: of two concurrent
+		// rejudges only one moves the submission to pending
+		result := tx.Model(&models.Submission{}).
+			Where("id = ? AND status NOT IN ?", s.ID, []string{"pending", "running"}).
+			Updates(map[string]interface{}{
+				"status":           "pending",
+				"score":            nil,
+				"execution_time":   nil,
+				"memory_usage":     nil,
+				"compile_log_path": nil,
+				"run_log_path":     nil,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		audit := models.SubmissionRejudge{
+			SubmissionID:          s.ID,
+			PreviousStatus:        s.Status,
+			PreviousScore:         s.Score,
+			PreviousExecutionTime: s.ExecutionTime,
+			PreviousMemoryUsage:   s.MemoryUsage,
+		}
+		if rejudgedBy != "" {
+			audit.RejudgedBy = &rejudgedBy
+		}
+		if err := tx.Create(&audit).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("submission_id = ?", s.ID).Delete(&models.SubmissionTestCase{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("submission_id = ?", s.ID).Delete(&models.SubmissionSubtask{}).Error; err != nil {
+			return err
+		}
+
+		reset = true
+		return nil
+	})
+	return reset && err == nil, err
+}
+
+// CompleteSubmissionRejudge fills in the new verdict on the submission's
+// open rejudge record, if there is one
+func CompleteSubmissionRejudge(submissionID, status string, score *int) error {
+	now := time.Now()
+	return db.DB.Model(&models.SubmissionRejudge{}).
+		Where("submission_id = ? AND completed_at IS NULL", submissionID).
+		Updates(map[string]interface{}{
+			"new_status":   status,
+			"new_score":    score,
+			"completed_at": &now,
+		}).Error
+}
+
+// ListSubmissionRejudges returns the rejudge history of a submission, newest first
+func ListSubmissionRejudges(submissionID string) ([]models.SubmissionRejudge, error) {
+	var rejudges []models.SubmissionRejudge
+	err := db.DB.Where("submission_id = ?", submissionID).
+		Order("created_at DESC, id DESC").
+		Find(&rejudges).Error
+	return rejudges, err
+}
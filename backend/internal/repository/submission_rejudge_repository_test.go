@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"codehustle/backend/internal/db"
+	"codehustle/backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// recordingPool is a connection that records the statements it executes.
+// UPDATE statements affect updatedRows rows, every other statement one.
+type recordingPool struct {
+	updatedRows int64
+	statements  []string
+}
+
+type execResult int64
+
+func (r execResult) LastInsertId() (int64, error) { return 1, nil }
+func (r execResult) RowsAffected() (int64, error) { return int64(r), nil }
+
+func (p *recordingPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	p.statements = append(p.statements, query)
+	if strings.HasPrefix(query, "UPDATE") {
+		return execResult(p.updatedRows), nil
+	}
+	return execResult(1), nil
+}
+
+func (p *recordingPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (p *recordingPool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+func (p *recordingPool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	panic("not supported")
+}
+
+func (p *recordingPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	return &recordingTx{p}, nil
+}
+
+// recordingTx is a transaction on a recordingPool
+type recordingTx struct {
+	*recordingPool
+}
+
+func (*recordingTx) Commit() error   { return nil }
+func (*recordingTx) Rollback() error { return nil }
+
+// useRecordingDB points db.DB at a recording connection for the test
+func useRecordingDB(t *testing.T, updatedRows int64) *recordingPool {
+	t.Helper()
+
+	pool := &recordingPool{updatedRows: updatedRows}
+	conn, err := gorm.Open(mysql.New(mysql.Config{Conn: pool, SkipInitializeWithVersion: true}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	saved := db.DB
+	db.DB = conn
+	t.Cleanup(func() { db.DB = saved })
+	return pool
+}
+
+func TestResetSubmissionForRejudge(t *testing.T) {
+	score := 40
+	submission := &models.Submission{ID: "s1", Status: "wrong_answer", Score: &score}
+
+	t.Run("finished submission", func(t *testing.T) {
+		pool := useRecordingDB(t, 1)
+
+		reset, err := ResetSubmissionForRejudge(submission, "admin")
+		require.NoError(t, err)
+		assert.True(t, reset)
+
+		require.Len(t, pool.statements, 4)
+		assert.Contains(t, pool.statements[0], "status NOT IN")
+		assert.True(t, strings.HasPrefix(pool.statements[1], "INSERT INTO `submission_rejudges`"))
+		assert.True(t, strings.HasPrefix(pool.statements[2], "DELETE FROM `submission_testcases`"))
+		assert.True(t, strings.HasPrefix(pool.statements[3], "DELETE FROM `submission_subtasks`"))
+	})
+
+	t.Run("already being judged", func(t *testing.T) {
+		// A concurrent rejudge moved the submission to pending first
+		pool := useRecordingDB(t, 0)
+
+		reset, err := ResetSubmissionForRejudge(submission, "admin")
+		require.NoError(t, err)
+		assert.False(t, reset)
+		assert.Len(t, pool.statements, 1, "no audit row and no results dropped")
+	})
+}
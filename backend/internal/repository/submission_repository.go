@@ -45,8 +45,16 @@ func UpdateSubmissionStatus(submissionID string, status string, score *int, exec
 	return db.DB.Model(&models.Submission{}).Where("id = ?", submissionID).Updates(updates).Error
 }
 
+// UpdateSubmissionLimits records the time and memory limits a submission is judged with
+func UpdateSubmissionLimits(submissionID string, timeLimitMs, memoryLimitKb int) error {
+	return db.DB.Model(&models.Submission{}).Where("id = ?", submissionID).Updates(map[string]interface{}{
+		"time_limit_ms":   timeLimitMs,
+		"memory_limit_kb": memoryLimitKb,
+	}).Error
+}
+
 // CreateOrUpdateSubmissionTestCase creates or updates a test case result
-func CreateOrUpdateSubmissionTestCase(submissionID, testCaseID string, status string, score *int, timeMs *int, memoryKb *int, userOutputPath *string) error {
+func CreateOrUpdateSubmissionTestCase(submissionID, testCaseID string, status string, score *int, timeMs *int, memoryKb *int, userOutputPath *string, checkerMessage *string) error {
 	testCaseResult := models.SubmissionTestCase{
 		SubmissionID: submissionID,
 		TestCaseID:   testCaseID,
@@ -55,6 +63,7 @@ func CreateOrUpdateSubmissionTestCase(submissionID, testCaseID string, status st
 		TimeMs:       timeMs,
 		MemoryKb:     memoryKb,
 		UserOutputPath: userOutputPath,
+		CheckerMessage: checkerMessage,
 	}
 
 	// Use ON DUPLICATE KEY UPDATE equivalent in GORM
@@ -65,6 +74,7 @@ func CreateOrUpdateSubmissionTestCase(submissionID, testCaseID string, status st
 			TimeMs:         timeMs,
 			MemoryKb:       memoryKb,
 			UserOutputPath: userOutputPath,
+			CheckerMessage: checkerMessage,
 		}).
 		FirstOrCreate(&testCaseResult).Error
 }
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateSubmissionLimits(t *testing.T) {
+	pool := useRecordingDB(t, 1)
+
+	require.NoError(t, UpdateSubmissionLimits("s1", 2500, 524288))
+
+	require.Len(t, pool.statements, 1)
+	assert.Contains(t, pool.statements[0], "UPDATE `submissions` SET")
+	assert.Contains(t, pool.statements[0], "`time_limit_ms`=?")
+	assert.Contains(t, pool.statements[0], "`memory_limit_kb`=?")
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"codehustle/backend/internal/db"
+	"codehustle/backend/internal/models"
+)
+
+// GetSubtasksByProblemID returns the subtasks of a problem in ordinal order,
+// with their test cases and dependencies filled in
+func GetSubtasksByProblemID(problemID string) ([]models.Subtask, error) {
+	var subtasks []models.Subtask
+	if err := db.DB.Where("problem_id = ?", problemID).
+		Order("ordinal ASC").
+		Find(&subtasks).Error; err != nil {
+		return nil, err
+	}
+	if len(subtasks) == 0 {
+		return subtasks, nil
+	}
+
+	ids := make([]string, len(subtasks))
+	index := make(map[string]int, len(subtasks))
+	for i, st := range subtasks {
+		ids[i] = st.ID
+		index[st.ID] = i
+	}
+
+	var members []models.SubtaskTestCase
+	if err := db.DB.Where("subtask_id IN ?", ids).Find(&members).Error; err != nil {
+		return nil, err
+	}
+	for _, m := range members {
+		st := &subtasks[index[m.SubtaskID]]
+		st.TestCaseIDs = append(st.TestCaseIDs, m.TestCaseID)
+	}
+
+	var deps []models.SubtaskDependency
+	if err := db.DB.Where("subtask_id IN ?", ids).Find(&deps).Error; err != nil {
+		return nil, err
+	}
+	for _, d := range deps {
+		st := &subtasks[index[d.SubtaskID]]
+		st.DependsOn = append(st.DependsOn, d.DependsOnID)
+	}
+
+	return subtasks, nil
+}
+
+// ReplaceProblemSubtasks replaces all subtasks of a problem. Results stored
+// for the old subtasks are removed with them; rejudge to recompute them.
+func ReplaceProblemSubtasks(problemID string, subtasks []models.Subtask) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		// Join tables and results cascade with the subtasks
+		if err := tx.Where("problem_id = ?", problemID).Delete(&models.Subtask{}).Error; err != nil {
+			return err
+		}
+		if len(subtasks) == 0 {
+			return nil
+		}
+
+		if err := tx.Create(&subtasks).Error; err != nil {
+			return err
+		}
+
+		var members []models.SubtaskTestCase
+		var deps []models.SubtaskDependency
+		for _, st := range subtasks {
+			for _, tcID := range st.TestCaseIDs {
+				members = append(members, models.SubtaskTestCase{SubtaskID: st.ID, TestCaseID: tcID})
+			}
+			for _, depID := range st.DependsOn {
+				deps = append(deps, models.SubtaskDependency{SubtaskID: st.ID, DependsOnID: depID})
+			}
+		}
+		if len(members) > 0 {
+			if err := tx.Create(&members).Error; err != nil {
+				return err
+			}
+		}
+		if len(deps) > 0 {
+			if err := tx.Create(&deps).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SaveSubmissionSubtasks replaces the per-subtask results of a submission
+func SaveSubmissionSubtasks(submissionID string, results []models.SubmissionSubtask) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("submission_id = ?", submissionID).Delete(&models.SubmissionSubtask{}).Error; err != nil {
+			return err
+		}
+		if len(results) == 0 {
+			return nil
+		}
+		return tx.Create(&results).Error
+	})
+}
+
+// ListSubmissionSubtasks returns the per-subtask results of a submission in
+// subtask order
+func ListSubmissionSubtasks(submissionID string) ([]models.SubmissionSubtask, error) {
+	var results []models.SubmissionSubtask
+	err := db.DB.Joins("JOIN subtasks ON subtasks.id = submission_subtasks.subtask_id").
+		Where("submission_subtasks.submission_id = ?", submissionID).
+		Order("subtasks.ordinal ASC").
+		Preload("Subtask").
+		Find(&results).Error
+	return results, err
+}
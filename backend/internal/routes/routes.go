@@ -18,11 +18,18 @@ func RegisterRoutes(r *gin.Engine) {
 	api.GET("/auth/google", handlers.GoogleLogin)
 	api.GET("/auth/google/callback", handlers.GoogleCallbackGET)
 	api.POST("/auth/google/callback", handlers.GoogleCallback)
+	api.GET("/languages", handlers.ListLanguages)
 
 	// Protected endpoints (require auth)
 	protected := api.Group("")
 	protected.Use(middleware.AuthMiddleware())
 
+	// Event streams authenticate with a single-use stream token in the query,
+	// since EventSource clients cannot set headers
+	streams := api.Group("")
+	streams.Use(middleware.StreamTokenAuth())
+	streams.GET("/submissions/:id/events", middleware.RequireRole(constants.StudentRoles...), handlers.StreamSubmissionEvents)
+
 	// User routes
 	protected.GET("/me", handlers.GetMe)
 	protected.POST("/users/batch", middleware.RequireRole(constants.AdminRoles...), handlers.BatchCreateAccounts)
@@ -45,6 +52,22 @@ func RegisterRoutes(r *gin.Engine) {
 	admin.DELETE("/problems/:id", handlers.AdminDeleteProblem)
 	admin.GET("/problems/:id/export", handlers.AdminExportProblem)
 	admin.POST("/problems/import", handlers.AdminImportProblem)
+	admin.GET("/problems/:id/subtasks", handlers.AdminListSubtasks)
+	admin.PUT("/problems/:id/subtasks", handlers.AdminReplaceSubtasks)
+	admin.GET("/problems/:id/judge", handlers.AdminGetProblemJudge)
+	admin.PUT("/problems/:id/judge", handlers.AdminUpdateProblemJudge)
+	admin.POST("/problems/:id/validate-tests", handlers.AdminValidateProblemTests)
+
+	// Admin rejudge routes
+	admin.POST("/submissions/:id/rejudge", handlers.AdminRejudgeSubmission)
+	admin.GET("/submissions/:id/rejudges", handlers.AdminListSubmissionRejudges)
+	admin.POST("/problems/:id/rejudge", handlers.AdminRejudgeProblem)
+	admin.POST("/contests/:id/rejudge", handlers.AdminRejudgeContest)
+
+	// Admin scoreboard routes
+	admin.POST("/contests/:id/scoreboard/rebuild", handlers.AdminRebuildContestScoreboard)
+	admin.POST("/contests/:id/scoreboard/reveal-next", handlers.AdminRevealNextContestScore)
+	admin.POST("/contests/:id/scoreboard/unfreeze", handlers.AdminUnfreezeContestScoreboard)
 
 	// Admin test case routes
 	admin.POST("/test_case", handlers.BulkUploadTestCases)
@@ -68,6 +91,7 @@ func RegisterRoutes(r *gin.Engine) {
 	// Submission routes
 	protected.GET("/submissions", middleware.RequireRole(constants.StudentRoles...), handlers.ListSubmissions)
 	protected.GET("/submissions/:id", middleware.RequireRole(constants.StudentRoles...), handlers.GetSubmission)
+	protected.POST("/submissions/:id/events/token", middleware.RequireRole(constants.StudentRoles...), handlers.CreateSubmissionStreamToken)
 	protected.GET("/problems/:id/submissions", middleware.RequireRole(constants.StudentRoles...), handlers.GetProblemSubmissions)
 	protected.POST("/problems/:id/submit", middleware.RequireRole(constants.StudentRoles...), handlers.SubmitProblem)
 
@@ -104,6 +128,8 @@ func RegisterRoutes(r *gin.Engine) {
 	protected.POST("/contests/:id/register", handlers.RegisterForContest)
 	protected.POST("/contests/:id/unregister", handlers.UnregisterFromContest)
 	protected.GET("/contests/:id/participants", handlers.ListContestParticipants)
+	protected.GET("/contests/:id/scoreboard", handlers.GetContestScoreboard)
+	protected.GET("/contests/:id/scoreboard/frozen", handlers.GetFrozenContestScoreboard)
 	protected.GET("/contest/access", handlers.CheckContestAccess)
 
 	// Contest problem routes
@@ -97,6 +97,16 @@ func GetTestCasesBucket() string {
 	return bucket
 }
 
+// GetProblemCheckersBucket returns the bucket name for checkers, validators,
+// interactors and grader files
+func GetProblemCheckersBucket() string {
+	bucket := config.Get("BUCKET_PROBLEM_CHECKERS")
+	if bucket == "" {
+		return "problem-checkers"
+	}
+	return bucket
+}
+
 // DeleteFile deletes a file from MinIO
 func DeleteFile(bucketName string, objectKey string) error {
 	ctx := context.Background()
@@ -0,0 +1,29 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"codehustle/backend/internal/queue"
+
+	"github.com/sirupsen/logrus"
+)
+
+// eventPublishTimeout bounds how long judging waits on a progress update
+const eventPublishTimeout = 2 * time.Second
+
+// PublishSubmissionEvent notifies live viewers of a submission's progress.
+// Events are best effort: viewers fall back to the stored results, so a
+// failure is only logged.
+func PublishSubmissionEvent(logger *logrus.Logger, event queue.SubmissionEvent) {
+	ctx, cancel := context.WithTimeout(context.Background(), eventPublishTimeout)
+	defer cancel()
+
+	if err := queue.PublishSubmissionEvent(ctx, event); err != nil {
+		logger.WithFields(logrus.Fields{
+			"submission_id": event.SubmissionID,
+			"type":          event.Type,
+			"error":         err,
+		}).Warn("Failed to publish submission event")
+	}
+}
@@ -3,6 +3,8 @@ package worker
 import (
 	"codehustle/backend/internal/config"
 	"codehustle/backend/internal/db"
+	"codehustle/backend/internal/executor"
+	"codehustle/backend/internal/languages"
 	"codehustle/backend/internal/queue"
 	"codehustle/backend/internal/storage"
 	"context"
@@ -35,10 +37,30 @@ func InitializeServices(logger *logrus.Logger, consumerGroup string) error {
 	}
 	logger.Info("Connected to Redis")
 
-	// Ensure consumer group exists
+	// Resolve the executor now so a bad JUDGE_EXECUTOR fails at startup
+	exec, err := executor.Default()
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to create executor")
+		return err
+	}
+	if _, ok := exec.(executor.BinaryArtifacts); !ok {
+		// Piston keeps no binaries, so checkers, interactors and validators
+		// are compiled again for every test case they run on
+		logger.WithField("executor", config.Get("JUDGE_EXECUTOR")).Warn("Executor cannot cache compiled checkers; each checked test case compiles its checker")
+	}
+	logger.Info("Executor ready")
+
 	ctx := context.Background()
-	if err := queue.EnsureConsumerGroup(ctx, "judge:submissions", consumerGroup); err != nil {
-		logger.WithError(err).Warn("Failed to create consumer group (may already exist)")
+
+	// Load the language registry and the versions Piston has installed
+	if err := languages.Init(ctx); err != nil {
+		logger.WithError(err).Fatal("Failed to load languages")
+		return err
+	}
+
+	// Ensure the consumer group exists on every priority lane
+	if err := queue.EnsureJudgeConsumerGroups(ctx, consumerGroup); err != nil {
+		logger.WithError(err).Warn("Failed to create consumer group")
 	}
 
 	return nil
@@ -0,0 +1,68 @@
+package worker
+
+import (
+	"context"
+
+	"codehustle/backend/internal/judge"
+	"codehustle/backend/internal/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+// judgeInteractiveTestCase runs the program against the problem's interactor
+// on a single test case. There is no output to keep: it went to the
+// interactor.
+func (p *SubmissionProcessor) judgeInteractiveTestCase(
+	ctx context.Context,
+	data *SubmissionData,
+	program *judge.Program,
+	tc models.TestCase,
+	inputBytes []byte,
+	expectedBytes []byte,
+) TestCaseResult {
+	submissionID := data.Submission.ID
+
+	result, err := judge.RunInteractive(
+		ctx,
+		data.Checkers,
+		data.JudgeConfig,
+		program,
+		string(inputBytes),
+		string(expectedBytes),
+		data.TimeLimitMs,
+		data.MemoryLimitKb,
+	)
+	if err != nil {
+		p.logger.WithFields(logrus.Fields{
+			"submission_id":  submissionID,
+			"test_case_id":   tc.ID,
+			"test_case_name": tc.Name,
+			"error":          err,
+		}).Error("Error running test case against interactor")
+		return TestCaseResult{TestCaseID: tc.ID, Verdict: "system_error"}
+	}
+
+	verdict, score, checkerMessage := DetermineInteractiveVerdict(
+		p.logger,
+		submissionID,
+		tc,
+		result,
+		data.TimeLimitMs,
+		data.MemoryLimitKb,
+	)
+
+	var runLog *string
+	if result.Run.Stderr != "" {
+		runLog = &result.Run.Stderr
+	}
+
+	return TestCaseResult{
+		TestCaseID:     tc.ID,
+		Verdict:        verdict,
+		Score:          score,
+		TimeMs:         result.Run.TimeMs(),
+		MemoryKb:       result.Run.MemoryKb(),
+		CheckerMessage: checkerMessage,
+		RunLog:         runLog,
+	}
+}
@@ -0,0 +1,63 @@
+package worker
+
+import (
+	"context"
+	"strconv"
+
+	"codehustle/backend/internal/judge"
+	"codehustle/backend/internal/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+// judgeOutputOnlyTestCase checks the submitted answer file of a test case.
+// Answers are looked up by test case name and then by position, so both
+// "test_3.out" and "3.out" answer the third test.
+func (p *SubmissionProcessor) judgeOutputOnlyTestCase(
+	ctx context.Context,
+	data *SubmissionData,
+	tc models.TestCase,
+	testCaseNum int,
+	inputBytes []byte,
+	expectedBytes []byte,
+) TestCaseResult {
+	submissionID := data.Submission.ID
+
+	answer, ok := judge.AnswerFor(data.Answers, tc.Name, strconv.Itoa(testCaseNum+1))
+	if !ok {
+		p.logger.WithFields(logrus.Fields{
+			"submission_id":  submissionID,
+			"test_case_id":   tc.ID,
+			"test_case_name": tc.Name,
+		}).Info("No answer file for test case")
+		message := "No answer file for this test"
+		return TestCaseResult{TestCaseID: tc.ID, Verdict: "wrong_answer", CheckerMessage: &message}
+	}
+
+	// The answer goes through the checker like the output of a program
+	result := &judge.ExecutionResult{Run: judge.StageResult{Stdout: answer}}
+	verdict, score, checkerMessage, _, err := DetermineVerdict(
+		ctx,
+		p.logger,
+		submissionID,
+		tc,
+		result,
+		string(expectedBytes),
+		string(inputBytes),
+		data.JudgeConfig,
+		data.Checkers,
+		data.Problem.StatementPath,
+		data.TimeLimitMs,
+		data.MemoryLimitKb,
+	)
+	if err != nil {
+		return TestCaseResult{TestCaseID: tc.ID, Verdict: "system_error"}
+	}
+
+	return TestCaseResult{
+		TestCaseID:     tc.ID,
+		Verdict:        verdict,
+		Score:          score,
+		CheckerMessage: checkerMessage,
+	}
+}
@@ -0,0 +1,54 @@
+package worker
+
+import (
+	"context"
+	"testing"
+
+	"codehustle/backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJudgeOutputOnlyTestCase(t *testing.T) {
+	data := &SubmissionData{
+		Submission:  &models.Submission{ID: "s1"},
+		Problem:     &models.Problem{ID: "p1"},
+		JudgeConfig: &models.ProblemJudge{CheckerKind: "token", IOMode: models.IOModeOutputOnly},
+		Answers: map[string]string{
+			"sample.out": "1 2\n",
+			"2.out":      "3  4",
+			"3.out":      "wrong",
+		},
+		TimeLimitMs:   1000,
+		MemoryLimitKb: 262144,
+	}
+	p := newTestProcessor(1)
+
+	tests := []struct {
+		name        string
+		tc          models.TestCase
+		testCaseNum int
+		expected    string
+		verdict     string
+		score       int
+	}{
+		{name: "by test case name", tc: models.TestCase{ID: "t1", Name: "sample", Weight: 5}, testCaseNum: 0, expected: "1 2", verdict: "accepted", score: 5},
+		{name: "by position", tc: models.TestCase{ID: "t2", Name: "big", Weight: 5}, testCaseNum: 1, expected: "3 4", verdict: "accepted", score: 5},
+		{name: "checked like program output", tc: models.TestCase{ID: "t3", Name: "edge", Weight: 5}, testCaseNum: 2, expected: "right", verdict: "wrong_answer"},
+		{name: "no answer file", tc: models.TestCase{ID: "t4", Name: "max", Weight: 5}, testCaseNum: 3, expected: "7", verdict: "wrong_answer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := p.judgeOutputOnlyTestCase(context.Background(), data, tt.tc, tt.testCaseNum, nil, []byte(tt.expected))
+			assert.Equal(t, tt.tc.ID, result.TestCaseID)
+			assert.Equal(t, tt.verdict, result.Verdict)
+			assert.Equal(t, tt.score, result.Score)
+		})
+	}
+
+	result := p.judgeOutputOnlyTestCase(context.Background(), data, models.TestCase{ID: "t4", Name: "max"}, 3, nil, []byte("7"))
+	if assert.NotNil(t, result.CheckerMessage) {
+		assert.Equal(t, "No answer file for this test", *result.CheckerMessage)
+	}
+}
@@ -3,8 +3,13 @@ package worker
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
 
 	"codehustle/backend/internal/config"
+	"codehustle/backend/internal/executor"
+	"codehustle/backend/internal/judge"
+	"codehustle/backend/internal/languages"
 	"codehustle/backend/internal/queue"
 	"codehustle/backend/internal/repository"
 
@@ -13,13 +18,19 @@ import (
 
 // SubmissionProcessor handles the processing of judge jobs
 type SubmissionProcessor struct {
-	logger *logrus.Logger
+	logger              *logrus.Logger
+	testCaseParallelism int
 }
 
-// NewSubmissionProcessor creates a new submission processor
-func NewSubmissionProcessor(logger *logrus.Logger) *SubmissionProcessor {
+// NewSubmissionProcessor creates a new submission processor that judges up to
+// testCaseParallelism test cases of a submission at the same time
+func NewSubmissionProcessor(logger *logrus.Logger, testCaseParallelism int) *SubmissionProcessor {
+	if testCaseParallelism < 1 {
+		testCaseParallelism = 1
+	}
 	return &SubmissionProcessor{
-		logger: logger,
+		logger:              logger,
+		testCaseParallelism: testCaseParallelism,
 	}
 }
 
@@ -28,13 +39,7 @@ func (p *SubmissionProcessor) Process(ctx context.Context, job *queue.JudgeJob)
 	submissionID := job.SubmissionID
 
 	// Load all submission data
-	data, err := LoadSubmissionData(
-		p.logger,
-		submissionID,
-		job.ProblemID,
-		job.Language,
-		job.LanguageVersion,
-	)
+	data, err := LoadSubmissionData(p.logger, job)
 	if err != nil {
 		return fmt.Errorf("failed to load submission data: %w", err)
 	}
@@ -43,116 +48,207 @@ func (p *SubmissionProcessor) Process(ctx context.Context, job *queue.JudgeJob)
 	if err := repository.UpdateSubmissionStatus(submissionID, "running", nil, nil, nil, nil, nil); err != nil {
 		return fmt.Errorf("failed to update submission status: %w", err)
 	}
+	PublishSubmissionEvent(p.logger, queue.SubmissionEvent{
+		SubmissionID: submissionID,
+		Type:         queue.SubmissionEventStatus,
+		Status:       "running",
+	})
+
+	// Record the limits this submission is judged with
+	if err := repository.UpdateSubmissionLimits(submissionID, data.TimeLimitMs, data.MemoryLimitKb); err != nil {
+		p.logger.WithError(err).Warn("Failed to record submission limits")
+	}
 
 	p.logger.WithFields(logrus.Fields{
 		"submission_id":    submissionID,
 		"problem_id":       data.Problem.ID,
-		"language":         job.Language,
+		"language":         data.Submission.Language,
 		"version":          data.LanguageVersion,
+		"time_limit_ms":    data.TimeLimitMs,
+		"memory_limit_kb":  data.MemoryLimitKb,
 		"total_test_cases": len(data.TestCases),
 	}).Info("Starting submission processing")
 
-	// Get test case bucket
-	testCaseBucket := config.Get("BUCKET_TEST_CASES")
-	if testCaseBucket == "" {
-		testCaseBucket = "test-cases"
+	// Interactive problems need an executor that can connect programs. Fail
+	// the submission once instead of compiling it to fail every test case.
+	if data.JudgeConfig.IsInteractive() {
+		if exec, err := executor.Default(); err == nil {
+			if _, ok := exec.(executor.InteractiveRunner); !ok {
+				p.logger.WithField("submission_id", submissionID).Error("Interactive problem on an executor without interactive runs")
+				message := "interactive problems need an executor that can connect programs (JUDGE_EXECUTOR=local)"
+				return UpdateSubmissionFinalStatus(
+					p.logger,
+					submissionID,
+					data.Problem.ID,
+					"judgement_failed",
+					0,
+					0,
+					0,
+					nil,
+					&message,
+					0,
+					0,
+					len(data.TestCases),
+				)
+			}
+		}
 	}
 
-	// Process each test case
-	passed := 0
-	totalScore := 0
-	totalWeight := 0
-	maxTimeMs := 0
-	maxMemoryKb := 0
-	var compileLog *string
-	var runLog *string
-
-	for testCaseNum, tc := range data.TestCases {
+	// A language the contest does not allow is a compile error: it costs no
+	// penalty attempt, like any program that never ran
+	if !data.JudgeConfig.IsOutputOnly() && !languages.Allowed(data.AllowedLanguages, data.Submission.Language) {
 		p.logger.WithFields(logrus.Fields{
-			"submission_id":    submissionID,
-			"test_case_id":     tc.ID,
-			"test_case_name":   tc.Name,
-			"test_case_num":    testCaseNum + 1,
-			"total_test_cases": len(data.TestCases),
-			"is_sample":        tc.IsSample,
-			"weight":           tc.Weight,
-		}).Info("Processing test case")
-
-		// Fetch test case data from storage
-		inputBytes, expectedBytes, err := FetchTestCaseData(p.logger, submissionID, tc, testCaseBucket)
-		if err != nil {
-			// Store error result and continue
-			StoreTestCaseResult(p.logger, submissionID, tc, testCaseNum, "system_error", 0, 0, 0, nil)
-			continue
-		}
-
-		// Execute code for this test case
-		result, err := ExecuteTestCase(
+			"submission_id":     submissionID,
+			"language":          data.Submission.Language,
+			"allowed_languages": data.AllowedLanguages,
+		}).Warn("Language not allowed for this contest")
+		message := fmt.Sprintf("Language %s is not allowed for this contest problem (allowed: %s)",
+			data.Submission.Language, strings.Join(data.AllowedLanguages, ", "))
+		return UpdateSubmissionFinalStatus(
 			p.logger,
 			submissionID,
-			tc,
-			testCaseNum,
+			data.Problem.ID,
+			"compile_error",
+			0,
+			0,
+			0,
+			&message,
+			nil,
+			0,
+			0,
 			len(data.TestCases),
-			job.Code,
-			job.Language,
-			data.LanguageVersion,
-			inputBytes,
-			data.Problem.TimeLimitMs,
-			data.Problem.MemoryLimitKb,
 		)
+	}
+
+	// Compile once; a compile error ends judging before any test case runs.
+	// Output-only submissions are answers, there is nothing to compile.
+	var program *judge.Program
+	var compileLog *string
+	if !data.JudgeConfig.IsOutputOnly() {
+		program, err = CompileSubmission(ctx, p.logger, submissionID, data.Sources, data.Graders, data.Submission.Language, data.LanguageVersion)
 		if err != nil {
-			// Store error result and continue
-			StoreTestCaseResult(p.logger, submissionID, tc, testCaseNum, "system_error", 0, 0, 0, nil)
-			continue
+			return fmt.Errorf("failed to compile submission: %w", err)
 		}
+		defer program.Close()
 
-		// Accumulate logs
-		compileLog, runLog = AccumulateLogs(p.logger, result, tc, compileLog, runLog)
+		if log := program.CompileLog(); log != "" {
+			compileLog = &log
+		}
+	}
 
-		// Determine verdict
-		verdict, score, userOutputPath, err := DetermineVerdict(
+	compileError := func(compileLog *string) error {
+		return UpdateSubmissionFinalStatus(
 			p.logger,
 			submissionID,
-			tc,
-			result,
-			string(expectedBytes),
-			string(inputBytes),
-			data.JudgeConfig,
-			data.Problem.StatementPath,
+			data.Problem.ID,
+			"compile_error",
+			0,
+			0,
+			0,
+			compileLog,
+			nil,
+			0,
+			0,
+			len(data.TestCases),
 		)
-		if err != nil {
-			// Store error result and continue
-			StoreTestCaseResult(p.logger, submissionID, tc, testCaseNum, "system_error", 0, 0, 0, nil)
-			continue
-		}
+	}
+
+	if program != nil && program.CompileFailed() {
+		p.logger.WithFields(logrus.Fields{
+			"submission_id":  submissionID,
+			"compile_code":   program.Compile.Code,
+			"compile_stderr": program.Compile.Stderr,
+		}).Warn("Compile error, skipping test cases")
+
+		return compileError(compileLog)
+	}
 
-		// Store user output for wrong_answer cases
-		if verdict == "wrong_answer" && result.Run.Stdout != "" {
-			outputPath, err := StoreUserOutput(p.logger, submissionID, tc.ID, result.Run.Stdout)
-			if err == nil {
-				userOutputPath = outputPath
+	// Get test case bucket
+	testCaseBucket := config.Get("BUCKET_TEST_CASES")
+	if testCaseBucket == "" {
+		testCaseBucket = "test-cases"
+	}
+
+	judgeOne := func(testCaseNum int) TestCaseResult {
+		return p.judgeTestCase(ctx, data, program, testCaseNum, testCaseBucket)
+	}
+
+	// Executors that keep no builds (Piston) compile with every run, so the
+	// first test case tells whether the submission compiles. It runs alone
+	// so a compile error costs a single request.
+	if program != nil && program.Deferred && len(data.TestCases) > 0 {
+		first := judgeOne(0)
+		if first.CompileLog != nil {
+			compileLog = first.CompileLog
+		}
+		if first.Verdict == "compile_error" {
+			p.logger.WithField("submission_id", submissionID).Warn("Compile error on the first test case, skipping the others")
+			return compileError(compileLog)
+		}
+		judgeOne = func(testCaseNum int) TestCaseResult {
+			if testCaseNum == 0 {
+				return first
 			}
+			return p.judgeTestCase(ctx, data, program, testCaseNum, testCaseBucket)
 		}
+	}
 
-		// Calculate metrics
-		memoryKb := result.Run.Memory / 1024
-		timeMs := 0 // TODO: Extract from result if available
-
-		if timeMs > maxTimeMs {
-			maxTimeMs = timeMs
+	// Problems with subtasks are scored per subtask, and tests whose
+	// subtasks are already lost are skipped
+	scorer := newSubtaskScorer(data.Subtasks, data.TestCases)
+	var skip func(testCaseNum int) bool
+	if scorer != nil {
+		skip = func(testCaseNum int) bool {
+			return scorer.ShouldSkip(data.TestCases[testCaseNum])
 		}
-		if memoryKb > maxMemoryKb {
-			maxMemoryKb = memoryKb
+	}
+
+	// Judge test cases concurrently, recording results in test case order
+	passed := 0
+	totalScore := 0
+	totalWeight := 0
+	maxTimeMs := 0
+	maxMemoryKb := 0
+	judgementFailed := false
+	firstRejected := "" // verdict of the first failed test case
+	var runLog *string
+
+	p.runTestCases(ctx, len(data.TestCases), judgeOne, skip, func(testCaseNum int, result TestCaseResult) {
+		tc := data.TestCases[testCaseNum]
+
+		// Results of a cancelled run are not trustworthy; the job is retried
+		if ctx.Err() != nil {
+			return
 		}
 
-		if verdict == "accepted" {
-			passed++
+		if firstRejected == "" && result.Verdict != "accepted" && result.Verdict != "skipped" {
+			firstRejected = result.Verdict
 		}
 
-		totalScore += score
-		totalWeight += tc.Weight
-		if tc.Weight == 0 {
-			totalWeight += 1
+		if result.Verdict != "system_error" {
+			if result.RunLog != nil {
+				runLog = AccumulateLogs(p.logger, *result.RunLog, tc, runLog)
+			}
+
+			if result.TimeMs > maxTimeMs {
+				maxTimeMs = result.TimeMs
+			}
+			if result.MemoryKb > maxMemoryKb {
+				maxMemoryKb = result.MemoryKb
+			}
+
+			if result.Verdict == "accepted" {
+				passed++
+			}
+			if result.Verdict == "judgement_failed" {
+				judgementFailed = true
+			}
+
+			totalScore += result.Score
+			totalWeight += testCaseWeight(tc)
+		}
+		if scorer != nil {
+			scorer.Record(tc, result.Verdict, result.Score)
 		}
 
 		// Store test case result
@@ -161,19 +257,37 @@ func (p *SubmissionProcessor) Process(ctx context.Context, job *queue.JudgeJob)
 			submissionID,
 			tc,
 			testCaseNum,
-			verdict,
-			score,
-			timeMs,
-			memoryKb,
-			userOutputPath,
+			result.Verdict,
+			result.Score,
+			result.TimeMs,
+			result.MemoryKb,
+			result.UserOutputPath,
+			result.CheckerMessage,
 		); err != nil {
 			// Log error but continue processing other test cases
 			p.logger.WithError(err).Error("Failed to store test case result")
 		}
+	})
+
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("judging interrupted: %w", err)
+	}
+
+	// Subtask points replace the per-test scores
+	if scorer != nil {
+		subtaskResults := scorer.Results()
+		totalScore, totalWeight = 0, 0
+		for _, r := range subtaskResults {
+			totalScore += r.Score
+			totalWeight += r.MaxScore
+		}
+		if err := StoreSubtaskResults(p.logger, submissionID, subtaskResults); err != nil {
+			p.logger.WithError(err).Error("Failed to store subtask results")
+		}
 	}
 
 	// Determine final status
-	finalStatus := CalculateFinalStatus(compileLog, passed, len(data.TestCases))
+	finalStatus := CalculateFinalStatus(firstRejected, judgementFailed)
 
 	// Update submission with final results
 	if err := UpdateSubmissionFinalStatus(
@@ -195,3 +309,203 @@ func (p *SubmissionProcessor) Process(ctx context.Context, job *queue.JudgeJob)
 
 	return nil
 }
+
+// runTestCases judges every test case with runOne on a bounded pool of
+// goroutines and hands the results to record strictly in test case order, so
+// stored rows and logs do not depend on which test finishes first. No new
+// test cases are started once ctx is cancelled. Test cases for which skip
+// returns true, given the results recorded before them, are recorded as
+// skipped.
+func (p *SubmissionProcessor) runTestCases(
+	ctx context.Context,
+	total int,
+	runOne func(testCaseNum int) TestCaseResult,
+	skip func(testCaseNum int) bool,
+	record func(testCaseNum int, result TestCaseResult),
+) {
+	type indexedResult struct {
+		testCaseNum int
+		result      TestCaseResult
+	}
+
+	workers := p.testCaseParallelism
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > total {
+		workers = total
+	}
+
+	indexes := make(chan int)
+	results := make(chan indexedResult, workers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for testCaseNum := range indexes {
+				// The feeder may hand out one more test after the cancel
+				if ctx.Err() != nil {
+					return
+				}
+
+				// Skipping here only saves the run: the results seen so
+				// far are a prefix of those the record loop decides on, and
+				// a lost subtask stays lost
+				var result TestCaseResult
+				if skip != nil && skip(testCaseNum) {
+					result = TestCaseResult{Verdict: "skipped"}
+				} else {
+					result = runOne(testCaseNum)
+				}
+				results <- indexedResult{testCaseNum: testCaseNum, result: result}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(indexes)
+		for testCaseNum := 0; testCaseNum < total; testCaseNum++ {
+			select {
+			case indexes <- testCaseNum:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	pending := make(map[int]TestCaseResult)
+	next := 0
+	for r := range results {
+		pending[r.testCaseNum] = r.result
+
+		for {
+			result, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			// Which tests are skipped depends only on earlier results, not
+			// on which runner finished first
+			if skip != nil && result.Verdict != "skipped" && skip(next) {
+				result = TestCaseResult{Verdict: "skipped"}
+			}
+			record(next, result)
+			next++
+		}
+	}
+}
+
+// judgeTestCase fetches, runs and checks a single test case
+func (p *SubmissionProcessor) judgeTestCase(
+	ctx context.Context,
+	data *SubmissionData,
+	program *judge.Program,
+	testCaseNum int,
+	testCaseBucket string,
+) TestCaseResult {
+	submissionID := data.Submission.ID
+	tc := data.TestCases[testCaseNum]
+	systemError := TestCaseResult{TestCaseID: tc.ID, Verdict: "system_error"}
+
+	p.logger.WithFields(logrus.Fields{
+		"submission_id":    submissionID,
+		"test_case_id":     tc.ID,
+		"test_case_name":   tc.Name,
+		"test_case_num":    testCaseNum + 1,
+		"total_test_cases": len(data.TestCases),
+		"is_sample":        tc.IsSample,
+		"weight":           tc.Weight,
+	}).Info("Processing test case")
+
+	// Fetch test case data from storage
+	inputBytes, expectedBytes, err := FetchTestCaseData(p.logger, submissionID, tc, testCaseBucket)
+	if err != nil {
+		return systemError
+	}
+
+	if data.JudgeConfig.IsInteractive() {
+		return p.judgeInteractiveTestCase(ctx, data, program, tc, inputBytes, expectedBytes)
+	}
+	if data.JudgeConfig.IsOutputOnly() {
+		return p.judgeOutputOnlyTestCase(ctx, data, tc, testCaseNum, inputBytes, expectedBytes)
+	}
+
+	// Execute code for this test case
+	result, err := ExecuteTestCase(
+		ctx,
+		p.logger,
+		submissionID,
+		tc,
+		testCaseNum,
+		len(data.TestCases),
+		program,
+		inputBytes,
+		data.JudgeConfig,
+		data.TimeLimitMs,
+		data.MemoryLimitKb,
+	)
+	if err != nil {
+		return systemError
+	}
+
+	// Determine verdict
+	verdict, score, checkerMessage, userOutputPath, err := DetermineVerdict(
+		ctx,
+		p.logger,
+		submissionID,
+		tc,
+		result,
+		string(expectedBytes),
+		string(inputBytes),
+		data.JudgeConfig,
+		data.Checkers,
+		data.Problem.StatementPath,
+		data.TimeLimitMs,
+		data.MemoryLimitKb,
+	)
+	if err != nil {
+		return systemError
+	}
+
+	// Store user output for cases the checker did not fully accept
+	if (verdict == "wrong_answer" || verdict == "partial" || verdict == "presentation_error") && result.Run.Stdout != "" {
+		outputPath, err := StoreUserOutput(p.logger, submissionID, tc.ID, result.Run.Stdout)
+		if err == nil {
+			userOutputPath = outputPath
+		}
+	}
+
+	var runLog *string
+	if result.Run.Stderr != "" {
+		runLog = &result.Run.Stderr
+	}
+
+	// A deferred build reports its compile stage with each run
+	var compileLog *string
+	if program.Deferred {
+		if log := result.Compile.Stderr; log != "" {
+			compileLog = &log
+		} else if log := result.Compile.Stdout; log != "" {
+			compileLog = &log
+		}
+	}
+
+	return TestCaseResult{
+		TestCaseID:     tc.ID,
+		Verdict:        verdict,
+		Score:          score,
+		TimeMs:         result.Run.TimeMs(),
+		MemoryKb:       result.Run.MemoryKb(),
+		UserOutputPath: userOutputPath,
+		CheckerMessage: checkerMessage,
+		CompileLog:     compileLog,
+		RunLog:         runLog,
+	}
+}
@@ -0,0 +1,120 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+
+	"codehustle/backend/internal/models"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestProcessor(parallelism int) *SubmissionProcessor {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return NewSubmissionProcessor(logger, parallelism)
+}
+
+// TestRunTestCasesSkipsInTestOrder fails the first test of a min subtask
+// after the later ones have already run: whether they are skipped must not
+// depend on which runner finished first.
+func TestRunTestCasesSkipsInTestOrder(t *testing.T) {
+	testCases := []models.TestCase{{ID: "t1"}, {ID: "t2"}, {ID: "t3"}, {ID: "t4"}}
+	subtasks := []models.Subtask{
+		{ID: "a", Scoring: models.SubtaskScoringMin, TestCaseIDs: []string{"t1", "t2", "t3", "t4"}},
+	}
+
+	for _, parallelism := range []int{1, 2, 4} {
+		scorer := newSubtaskScorer(subtasks, testCases)
+		skip := func(testCaseNum int) bool {
+			return scorer.ShouldSkip(testCases[testCaseNum])
+		}
+
+		// Test 0 waits until every other started test has finished
+		var others sync.WaitGroup
+		others.Add(parallelism - 1)
+		runOne := func(testCaseNum int) TestCaseResult {
+			if testCaseNum == 0 {
+				others.Wait()
+				return TestCaseResult{Verdict: "wrong_answer"}
+			}
+			if testCaseNum < parallelism {
+				defer others.Done()
+			}
+			return TestCaseResult{Verdict: "accepted", Score: 1}
+		}
+
+		var verdicts []string
+		newTestProcessor(parallelism).runTestCases(context.Background(), len(testCases), runOne, skip, func(testCaseNum int, result TestCaseResult) {
+			scorer.Record(testCases[testCaseNum], result.Verdict, result.Score)
+			verdicts = append(verdicts, result.Verdict)
+		})
+
+		assert.Equal(t, []string{"wrong_answer", "skipped", "skipped", "skipped"}, verdicts, "parallelism %d", parallelism)
+	}
+}
+
+// TestRunTestCasesRecordsInTestOrder finishes test cases in reverse order and
+// checks that results are still recorded in test case order
+func TestRunTestCasesRecordsInTestOrder(t *testing.T) {
+	const total = 6
+
+	// Test n waits for test n+1 to finish, so they complete last to first
+	done := make([]chan struct{}, total+1)
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	close(done[total])
+
+	var mu sync.Mutex
+	var finished []int
+	runOne := func(testCaseNum int) TestCaseResult {
+		<-done[testCaseNum+1]
+		mu.Lock()
+		finished = append(finished, testCaseNum)
+		mu.Unlock()
+		close(done[testCaseNum])
+		return TestCaseResult{TestCaseID: fmt.Sprintf("t%d", testCaseNum), Verdict: "accepted"}
+	}
+
+	var recorded []int
+	newTestProcessor(total).runTestCases(context.Background(), total, runOne, nil, func(testCaseNum int, result TestCaseResult) {
+		assert.Equal(t, fmt.Sprintf("t%d", testCaseNum), result.TestCaseID, "result handed to the wrong test case")
+		recorded = append(recorded, testCaseNum)
+	})
+
+	assert.Equal(t, []int{5, 4, 3, 2, 1, 0}, finished)
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5}, recorded)
+}
+
+// TestRunTestCasesStopsWhenCancelled cancels judging during the second test
+// case: the tests already started finish and no new ones start, so the
+// worker can release the job within its grace period
+func TestRunTestCasesStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	var started []int
+	runOne := func(testCaseNum int) TestCaseResult {
+		mu.Lock()
+		started = append(started, testCaseNum)
+		mu.Unlock()
+		if testCaseNum == 1 {
+			cancel()
+		}
+		return TestCaseResult{Verdict: "accepted"}
+	}
+
+	var recorded []int
+	newTestProcessor(1).runTestCases(ctx, 10, runOne, nil, func(testCaseNum int, result TestCaseResult) {
+		recorded = append(recorded, testCaseNum)
+	})
+
+	assert.Equal(t, []int{0, 1}, started)
+	assert.Equal(t, []int{0, 1}, recorded)
+}
@@ -4,8 +4,8 @@ import (
 	"fmt"
 	"strings"
 
-	"codehustle/backend/internal/judge"
 	"codehustle/backend/internal/models"
+	"codehustle/backend/internal/queue"
 	"codehustle/backend/internal/repository"
 	"codehustle/backend/internal/storage"
 
@@ -49,6 +49,7 @@ func StoreTestCaseResult(
 	timeMs int,
 	memoryKb int,
 	userOutputPath *string,
+	checkerMessage *string,
 ) error {
 	if err := repository.CreateOrUpdateSubmissionTestCase(
 		submissionID,
@@ -58,6 +59,7 @@ func StoreTestCaseResult(
 		intPtr(timeMs),
 		intPtr(memoryKb),
 		userOutputPath,
+		checkerMessage,
 	); err != nil {
 		logger.WithFields(logrus.Fields{
 			"submission_id":  submissionID,
@@ -67,6 +69,16 @@ func StoreTestCaseResult(
 		}).Error("Error saving test case result")
 		return err
 	}
+	PublishSubmissionEvent(logger, queue.SubmissionEvent{
+		SubmissionID: submissionID,
+		Type:         queue.SubmissionEventTestCase,
+		Status:       verdict,
+		TestCaseID:   tc.ID,
+		TestCaseNum:  testCaseNum + 1,
+		Score:        intPtr(score),
+		TimeMs:       intPtr(timeMs),
+		MemoryKb:     intPtr(memoryKb),
+	})
 
 	logger.WithFields(logrus.Fields{
 		"submission_id":  submissionID,
@@ -82,6 +94,30 @@ func StoreTestCaseResult(
 	return nil
 }
 
+// StoreSubtaskResults stores the per-subtask scores of a submission
+func StoreSubtaskResults(logger *logrus.Logger, submissionID string, results []SubtaskResult) error {
+	rows := make([]models.SubmissionSubtask, len(results))
+	for i, r := range results {
+		rows[i] = models.SubmissionSubtask{
+			SubmissionID: submissionID,
+			SubtaskID:    r.SubtaskID,
+			Status:       r.Status,
+			Score:        r.Score,
+		}
+	}
+
+	if err := repository.SaveSubmissionSubtasks(submissionID, rows); err != nil {
+		return err
+	}
+
+	logger.WithFields(logrus.Fields{
+		"submission_id": submissionID,
+		"subtasks":      len(results),
+	}).Debug("Subtask results saved")
+
+	return nil
+}
+
 // UpdateSubmissionFinalStatus updates the final submission status with all results
 func UpdateSubmissionFinalStatus(
 	logger *logrus.Logger,
@@ -108,6 +144,16 @@ func UpdateSubmissionFinalStatus(
 	); err != nil {
 		return fmt.Errorf("failed to update submission status: %w", err)
 	}
+	completeRejudge(logger, submissionID, finalStatus, intPtr(totalScore))
+	refreshContestScore(logger, submissionID)
+	PublishSubmissionEvent(logger, queue.SubmissionEvent{
+		SubmissionID: submissionID,
+		Type:         queue.SubmissionEventFinal,
+		Status:       finalStatus,
+		Score:        intPtr(totalScore),
+		TimeMs:       intPtr(maxTimeMs),
+		MemoryKb:     intPtr(maxMemoryKb),
+	})
 
 	logger.WithFields(logrus.Fields{
 		"submission_id":     submissionID,
@@ -124,32 +170,73 @@ func UpdateSubmissionFinalStatus(
 	return nil
 }
 
-// AccumulateLogs accumulates compile and run logs from execution results
+// MarkSubmissionSystemError finalizes a submission that could not be judged
+func MarkSubmissionSystemError(logger *logrus.Logger, submissionID string, reason string) error {
+	if err := repository.UpdateSubmissionStatus(submissionID, "system_error", nil, nil, nil, nil, nil); err != nil {
+		return fmt.Errorf("failed to update submission status: %w", err)
+	}
+	completeRejudge(logger, submissionID, "system_error", nil)
+	refreshContestScore(logger, submissionID)
+	PublishSubmissionEvent(logger, queue.SubmissionEvent{
+		SubmissionID: submissionID,
+		Type:         queue.SubmissionEventFinal,
+		Status:       "system_error",
+	})
+
+	logger.WithFields(logrus.Fields{
+		"submission_id": submissionID,
+		"reason":        reason,
+	}).Warn("Submission marked as system_error")
+
+	return nil
+}
+
+// completeRejudge records the new verdict for a rejudged submission. The
+// verdict itself is already saved, so a failure here is only logged.
+func completeRejudge(logger *logrus.Logger, submissionID, status string, score *int) {
+	if err := repository.CompleteSubmissionRejudge(submissionID, status, score); err != nil {
+		logger.WithFields(logrus.Fields{
+			"submission_id": submissionID,
+			"error":         err,
+		}).Warn("Failed to record rejudge verdict")
+	}
+}
+
+// ReleaseSubmission returns a submission whose judging was interrupted to the
+// pending state so it can be judged again
+func ReleaseSubmission(logger *logrus.Logger, submissionID string) error {
+	if err := repository.UpdateSubmissionStatus(submissionID, "pending", nil, nil, nil, nil, nil); err != nil {
+		return fmt.Errorf("failed to update submission status: %w", err)
+	}
+	PublishSubmissionEvent(logger, queue.SubmissionEvent{
+		SubmissionID: submissionID,
+		Type:         queue.SubmissionEventStatus,
+		Status:       "pending",
+	})
+
+	logger.WithField("submission_id", submissionID).Info("Submission released for rejudging")
+
+	return nil
+}
+
+// AccumulateLogs appends a test case's run stderr to the submission run log
 func AccumulateLogs(
 	logger *logrus.Logger,
-	result *judge.ExecutionResult,
+	runStderr string,
 	tc models.TestCase,
-	compileLog *string,
 	runLog *string,
-) (*string, *string) {
-	// Store compile log from first test case (compile happens once per submission)
-	if compileLog == nil && result.Compile.Stderr != "" {
-		compileLogStr := result.Compile.Stderr
-		compileLog = &compileLogStr
-	}
-
-	// Accumulate run logs
-	if result.Run.Stderr != "" {
+) *string {
+	if runStderr != "" {
 		if runLog == nil {
-			runLogStr := result.Run.Stderr
+			runLogStr := runStderr
 			runLog = &runLogStr
 		} else {
-			combined := *runLog + "\n--- Test Case: " + tc.Name + " ---\n" + result.Run.Stderr
+			combined := *runLog + "\n--- Test Case: " + tc.Name + " ---\n" + runStderr
 			runLog = &combined
 		}
 	}
 
-	return compileLog, runLog
+	return runLog
 }
 
 // FetchTestCaseData fetches test case input and expected output from storage
@@ -0,0 +1,33 @@
+package worker
+
+import (
+	"codehustle/backend/internal/repository"
+
+	"github.com/sirupsen/logrus"
+)
+
+// refreshContestScore updates the scoreboard cell of a contest submission
+// after its verdict changed. The verdict itself is already saved, so a
+// failure is only logged; the cell is rebuilt with the next verdict for the
+// same problem or by an admin rebuild.
+func refreshContestScore(logger *logrus.Logger, submissionID string) {
+	submission, err := repository.GetSubmissionByID(submissionID)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"submission_id": submissionID,
+			"error":         err,
+		}).Warn("Failed to load submission for the scoreboard")
+		return
+	}
+	if submission.ContestID == nil {
+		return
+	}
+
+	if err := repository.RefreshContestScore(*submission.ContestID, submission.UserID, submission.ProblemID); err != nil {
+		logger.WithFields(logrus.Fields{
+			"submission_id": submissionID,
+			"contest_id":    *submission.ContestID,
+			"error":         err,
+		}).Warn("Failed to update contest scoreboard")
+	}
+}
@@ -2,8 +2,13 @@ package worker
 
 import (
 	"fmt"
+	"path"
 
+	"codehustle/backend/internal/checker"
+	"codehustle/backend/internal/judge"
+	"codehustle/backend/internal/languages"
 	"codehustle/backend/internal/models"
+	"codehustle/backend/internal/queue"
 	"codehustle/backend/internal/repository"
 	"codehustle/backend/internal/storage"
 
@@ -15,32 +20,45 @@ type SubmissionData struct {
 	Submission      *models.Submission
 	Problem         *models.Problem
 	TestCases       []models.TestCase
+	Subtasks        []models.Subtask // Empty when the problem is scored per test case
 	JudgeConfig     *models.ProblemJudge
+	Answers         map[string]string  // Answer files of an output-only submission
+	Sources         []judge.SourceFile // Submitted files; one unnamed file for single-file submissions
+	Graders         []judge.SourceFile // Problem files compiled with the submission
 	LanguageVersion string
+	TimeLimitMs     int // Effective limit (contest override or problem default)
+	MemoryLimitKb   int // Effective limit (contest override or problem default)
+	// AllowedLanguages lists the languages a contest submission may use;
+	// empty outside contests or when the contest allows every language
+	AllowedLanguages []string
+	// Checkers fetches the checker or interactor once for all test cases
+	Checkers *checker.Service
 }
 
-// LoadSubmissionData loads all necessary data for processing a submission
-func LoadSubmissionData(logger *logrus.Logger, submissionID, problemIDFromJob, language, languageVersion string) (*SubmissionData, error) {
-	// Load submission record first to get the actual problem_id (source of truth)
+// LoadSubmissionData loads all necessary data for processing a submission.
+// The submission record is the source of truth; fields copied into version 1
+// jobs are only compared against it.
+func LoadSubmissionData(logger *logrus.Logger, job *queue.JudgeJob) (*SubmissionData, error) {
+	submissionID := job.SubmissionID
+
 	submission, err := repository.GetSubmission(submissionID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load submission: %w", err)
 	}
 
-	// Use problem_id from submission record, not from job
 	problemID := submission.ProblemID
 
 	logger.WithFields(logrus.Fields{
-		"submission_id":       submissionID,
-		"problem_id_from_job": problemIDFromJob,
-		"problem_id_from_db":  problemID,
-	}).Info("Loaded submission, verifying problem_id")
+		"submission_id": submissionID,
+		"problem_id":    problemID,
+		"job_version":   job.Version,
+	}).Info("Loaded submission")
 
-	// Verify problem_id matches (sanity check)
-	if problemIDFromJob != problemID {
+	// Sanity check for version 1 jobs, which carried their own problem_id
+	if job.ProblemID != "" && job.ProblemID != problemID {
 		logger.WithFields(logrus.Fields{
 			"submission_id":       submissionID,
-			"problem_id_from_job": problemIDFromJob,
+			"problem_id_from_job": job.ProblemID,
 			"problem_id_from_db":  problemID,
 		}).Warn("Problem ID mismatch between job and submission record, using submission record")
 	}
@@ -73,6 +91,43 @@ func LoadSubmissionData(logger *logrus.Logger, submissionID, problemIDFromJob, l
 		// Continue anyway, but this indicates a data issue
 	}
 
+	// Resolve contest ID (submission record is the source of truth, a version 1 job is the fallback)
+	contestID := job.ContestID
+	if submission.ContestID != nil && *submission.ContestID != "" {
+		if job.ContestID != "" && job.ContestID != *submission.ContestID {
+			logger.WithFields(logrus.Fields{
+				"submission_id":       submissionID,
+				"contest_id_from_job": job.ContestID,
+				"contest_id_from_db":  *submission.ContestID,
+			}).Warn("Contest ID mismatch between job and submission record, using submission record")
+		}
+		contestID = *submission.ContestID
+	}
+
+	// Resolve effective limits, applying contest overrides for contest submissions
+	timeLimitMs, memoryLimitKb, err := ResolveLimits(problem, contestID)
+	if err != nil {
+		return nil, err
+	}
+	if contestID != "" {
+		logger.WithFields(logrus.Fields{
+			"submission_id":   submissionID,
+			"contest_id":      contestID,
+			"time_limit_ms":   timeLimitMs,
+			"memory_limit_kb": memoryLimitKb,
+		}).Info("Applied contest problem limits")
+	}
+
+	// The handler checked the language on submission, but the contest may
+	// have changed since; rejudges and queued jobs are checked again
+	var allowedLanguages []string
+	if contestID != "" {
+		allowedLanguages, err = repository.GetContestAllowedLanguages(contestID, problemID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load contest languages: %w", err)
+		}
+	}
+
 	// Load test cases
 	testCases, err := repository.GetTestCasesByProblemID(problemID)
 	if err != nil {
@@ -82,6 +137,12 @@ func LoadSubmissionData(logger *logrus.Logger, submissionID, problemIDFromJob, l
 		return nil, fmt.Errorf("no test cases found for problem %s", problemID)
 	}
 
+	// Load subtasks; problems without any are scored per test case
+	subtasks, err := repository.GetSubtasksByProblemID(problemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load subtasks: %w", err)
+	}
+
 	// Load judge configuration
 	judgeConfig, err := repository.GetProblemJudgeByProblemID(problemID)
 	if err != nil {
@@ -93,39 +154,111 @@ func LoadSubmissionData(logger *logrus.Logger, submissionID, problemIDFromJob, l
 		}
 	}
 
+	// Load the answers of an output-only submission
+	var answers map[string]string
+	if judgeConfig.IsOutputOnly() {
+		answers, err = loadAnswers(submission)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Load the submitted sources and the problem's grader files
+	var sources, graders []judge.SourceFile
+	if !judgeConfig.IsOutputOnly() {
+		sources, graders, err = loadSources(submission, judgeConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Resolve language version
-	resolvedVersion := ResolveLanguageVersion(language, languageVersion)
+	languageVersion := ""
+	if submission.LanguageVersion != nil {
+		languageVersion = *submission.LanguageVersion
+	}
+	resolvedVersion := languages.ResolveVersion(submission.Language, languageVersion)
 
 	return &SubmissionData{
-		Submission:      submission,
-		Problem:         problem,
-		TestCases:       testCases,
-		JudgeConfig:     judgeConfig,
-		LanguageVersion: resolvedVersion,
+		Submission:       submission,
+		Problem:          problem,
+		TestCases:        testCases,
+		Subtasks:         subtasks,
+		JudgeConfig:      judgeConfig,
+		Answers:          answers,
+		Sources:          sources,
+		Graders:          graders,
+		LanguageVersion:  resolvedVersion,
+		TimeLimitMs:      timeLimitMs,
+		MemoryLimitKb:    memoryLimitKb,
+		AllowedLanguages: allowedLanguages,
+		Checkers:         checker.NewService(storage.GetMinIOClient()),
 	}, nil
 }
 
-// ResolveLanguageVersion resolves the language version, using defaults if needed
-func ResolveLanguageVersion(language, languageVersion string) string {
-	if languageVersion != "" && languageVersion != "latest" {
-		return languageVersion
-	}
-
-	// Default versions
-	switch language {
-	case "cpp":
-		return "10.2.0"
-	case "python":
-		return "3.12.0"
-	case "java":
-		return "17.0.2"
-	case "javascript":
-		return "18.19.0"
-	case "go":
-		return "1.22.0"
-	case "rust":
-		return "1.75.0"
-	default:
-		return "latest"
+// loadAnswers fetches and unpacks the answers zip of an output-only
+// submission
+func loadAnswers(submission *models.Submission) (map[string]string, error) {
+	if submission.AnswersPath == nil || *submission.AnswersPath == "" {
+		return nil, fmt.Errorf("output-only submission %s has no answers", submission.ID)
+	}
+
+	data, err := storage.GetFile(storage.GetTestCasesBucket(), *submission.AnswersPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load answers: %w", err)
+	}
+
+	answers, err := judge.ReadAnswers(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read answers: %w", err)
+	}
+	return answers, nil
+}
+
+// loadSources returns the files of a submission and the grader files the
+// problem has for its language
+func loadSources(submission *models.Submission, judgeConfig *models.ProblemJudge) (sources, graders []judge.SourceFile, err error) {
+	sources = []judge.SourceFile{{Content: submission.Code}}
+	if submission.SourcesPath != nil && *submission.SourcesPath != "" {
+		data, err := storage.GetFile(storage.GetTestCasesBucket(), *submission.SourcesPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load sources: %w", err)
+		}
+		if sources, err = judge.ReadSources(data); err != nil {
+			return nil, nil, fmt.Errorf("failed to read sources: %w", err)
+		}
+	}
+
+	language := submission.Language
+	if lang, ok := languages.Get(language); ok {
+		language = lang.ID
+	}
+	paths, err := judgeConfig.GraderPaths(language)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, key := range paths {
+		content, err := storage.GetFile(storage.GetProblemCheckersBucket(), key)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load grader file %s: %w", key, err)
+		}
+		graders = append(graders, judge.SourceFile{Name: path.Base(key), Content: string(content)})
+	}
+
+	return sources, graders, nil
+}
+
+// ResolveLimits returns the time and memory limits to judge with, preferring
+// the contest problem overrides when the submission belongs to a contest
+func ResolveLimits(problem *models.Problem, contestID string) (timeLimitMs int, memoryLimitKb int, err error) {
+	if contestID == "" {
+		return problem.TimeLimitMs, problem.MemoryLimitKb, nil
+	}
+
+	// GetContestProblem already falls back to the problem defaults for unset overrides
+	contestProblem, err := repository.GetContestProblem(contestID, problem.ID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to load contest problem: %w", err)
 	}
+	return contestProblem.TimeLimitMs, contestProblem.MemoryLimitKb, nil
 }
@@ -0,0 +1,110 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+
+	"codehustle/backend/internal/db"
+	"codehustle/backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// rowConnector serves one row to every query
+type rowConnector struct {
+	columns []string
+	row     []driver.Value
+	queries int
+}
+
+func (c *rowConnector) Connect(context.Context) (driver.Conn, error) { return rowConn{c}, nil }
+func (c *rowConnector) Driver() driver.Driver                        { return nil }
+
+type rowConn struct{ c *rowConnector }
+
+func (rowConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (rowConn) Close() error                        { return nil }
+func (rowConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (conn rowConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	conn.c.queries++
+	return &oneRow{columns: conn.c.columns, row: conn.c.row}, nil
+}
+
+type oneRow struct {
+	columns []string
+	row     []driver.Value
+	done    bool
+}
+
+func (r *oneRow) Columns() []string { return r.columns }
+func (r *oneRow) Close() error      { return nil }
+
+func (r *oneRow) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	copy(dest, r.row)
+	return nil
+}
+
+// useRowDB points db.DB at a connection that answers every query with row
+func useRowDB(t *testing.T, columns []string, row ...driver.Value) *rowConnector {
+	t.Helper()
+
+	connector := &rowConnector{columns: columns, row: row}
+	pool := sql.OpenDB(connector)
+	t.Cleanup(func() { pool.Close() })
+
+	conn, err := gorm.Open(mysql.New(mysql.Config{Conn: pool, SkipInitializeWithVersion: true}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	saved := db.DB
+	db.DB = conn
+	t.Cleanup(func() { db.DB = saved })
+	return connector
+}
+
+func TestResolveLimits(t *testing.T) {
+	problem := &models.Problem{ID: "p1", TimeLimitMs: 1000, MemoryLimitKb: 262144}
+	columns := []string{"contest_id", "problem_id", "points", "time_limit_ms", "memory_limit_kb", "problem_time_limit_ms", "problem_memory_limit_kb"}
+
+	t.Run("outside a contest", func(t *testing.T) {
+		connector := useRowDB(t, columns)
+
+		timeLimitMs, memoryLimitKb, err := ResolveLimits(problem, "")
+		require.NoError(t, err)
+		assert.Equal(t, 1000, timeLimitMs)
+		assert.Equal(t, 262144, memoryLimitKb)
+		assert.Zero(t, connector.queries, "no contest problem lookup")
+	})
+
+	t.Run("contest overrides", func(t *testing.T) {
+		useRowDB(t, columns, "c1", "p1", int64(100), int64(2500), int64(524288), int64(1000), int64(262144))
+
+		timeLimitMs, memoryLimitKb, err := ResolveLimits(problem, "c1")
+		require.NoError(t, err)
+		assert.Equal(t, 2500, timeLimitMs)
+		assert.Equal(t, 524288, memoryLimitKb)
+	})
+
+	t.Run("unset overrides keep the problem limits", func(t *testing.T) {
+		useRowDB(t, columns, "c1", "p1", int64(100), int64(2500), nil, int64(1000), int64(262144))
+
+		timeLimitMs, memoryLimitKb, err := ResolveLimits(problem, "c1")
+		require.NoError(t, err)
+		assert.Equal(t, 2500, timeLimitMs)
+		assert.Equal(t, 262144, memoryLimitKb)
+	})
+}
@@ -0,0 +1,208 @@
+package worker
+
+import (
+	"math"
+	"sync"
+
+	"codehustle/backend/internal/models"
+)
+
+// Subtask result statuses
+const (
+	SubtaskAccepted = "accepted"
+	SubtaskPartial  = "partial"
+	SubtaskFailed   = "failed"
+	SubtaskSkipped  = "skipped" // a dependency was not fully solved
+)
+
+// SubtaskResult is the score of a submission on one subtask
+type SubtaskResult struct {
+	SubtaskID string
+	Status    string
+	Score     int
+	MaxScore  int
+}
+
+// subtaskScorer tracks test case results per subtask while a submission is
+// judged. Results may be recorded from the result loop while test runners
+// ask whether a test can be skipped, so all methods lock.
+type subtaskScorer struct {
+	mu       sync.Mutex
+	subtasks []models.Subtask
+	groupsOf map[string][]int   // test case ID -> subtask positions
+	weights  map[string]int     // test case ID -> weight
+	fraction map[string]float64 // test case ID -> share of its weight earned
+	deps     [][]int            // subtask position -> dependency positions
+	failed   []bool             // subtask can no longer get full points
+}
+
+// newSubtaskScorer returns a scorer for the problem's subtasks, or nil if the
+// problem has none and is scored per test case
+func newSubtaskScorer(subtasks []models.Subtask, testCases []models.TestCase) *subtaskScorer {
+	if len(subtasks) == 0 {
+		return nil
+	}
+
+	s := &subtaskScorer{
+		subtasks: subtasks,
+		groupsOf: make(map[string][]int),
+		weights:  make(map[string]int, len(testCases)),
+		fraction: make(map[string]float64, len(testCases)),
+		deps:     make([][]int, len(subtasks)),
+		failed:   make([]bool, len(subtasks)),
+	}
+
+	for _, tc := range testCases {
+		s.weights[tc.ID] = testCaseWeight(tc)
+	}
+
+	position := make(map[string]int, len(subtasks))
+	for i, st := range subtasks {
+		position[st.ID] = i
+	}
+	for i, st := range subtasks {
+		for _, tcID := range st.TestCaseIDs {
+			s.groupsOf[tcID] = append(s.groupsOf[tcID], i)
+		}
+		for _, depID := range st.DependsOn {
+			if j, ok := position[depID]; ok && j != i {
+				s.deps[i] = append(s.deps[i], j)
+			}
+		}
+	}
+
+	return s
+}
+
+// Record stores the result of a test case
+func (s *subtaskScorer) Record(tc models.TestCase, verdict string, score int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f := 0.0
+	if verdict != "skipped" && verdict != "system_error" {
+		f = math.Min(1, math.Max(0, float64(score)/float64(testCaseWeight(tc))))
+	}
+	s.fraction[tc.ID] = f
+
+	if f < 1 {
+		for _, g := range s.groupsOf[tc.ID] {
+			s.failed[g] = true
+		}
+	}
+}
+
+// ShouldSkip reports whether running a test case can no longer change any
+// score: every subtask it belongs to is already lost. Tests outside all
+// subtasks always run.
+func (s *subtaskScorer) ShouldSkip(tc models.TestCase) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	groups := s.groupsOf[tc.ID]
+	if len(groups) == 0 {
+		return false
+	}
+
+	blocked := s.blocked()
+	for _, g := range groups {
+		lost := blocked[g] || (s.failed[g] && s.subtasks[g].Scoring != models.SubtaskScoringSum)
+		if !lost {
+			return false
+		}
+	}
+	return true
+}
+
+// Results computes the score of every subtask from the recorded results;
+// tests without a result count as failed
+func (s *subtaskScorer) Results() []SubtaskResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	blocked := s.blocked()
+	results := make([]SubtaskResult, len(s.subtasks))
+
+	for i, st := range s.subtasks {
+		result := SubtaskResult{SubtaskID: st.ID, MaxScore: st.Points}
+		if blocked[i] {
+			result.Status = SubtaskSkipped
+			results[i] = result
+			continue
+		}
+
+		share := s.share(st)
+		result.Score = int(math.Floor(float64(st.Points)*share + 1e-9))
+
+		switch {
+		case !s.failed[i] && share >= 1:
+			result.Status = SubtaskAccepted
+		case result.Score > 0:
+			result.Status = SubtaskPartial
+		default:
+			result.Status = SubtaskFailed
+		}
+		results[i] = result
+	}
+
+	return results
+}
+
+// share returns the fraction of a subtask's points earned under its
+// scoring rule
+func (s *subtaskScorer) share(st models.Subtask) float64 {
+	if len(st.TestCaseIDs) == 0 {
+		return 1
+	}
+
+	switch st.Scoring {
+	case models.SubtaskScoringAll:
+		for _, tcID := range st.TestCaseIDs {
+			if s.fraction[tcID] < 1 {
+				return 0
+			}
+		}
+		return 1
+	case models.SubtaskScoringMin:
+		lowest := 1.0
+		for _, tcID := range st.TestCaseIDs {
+			lowest = math.Min(lowest, s.fraction[tcID])
+		}
+		return lowest
+	default:
+		earned, total := 0.0, 0
+		for _, tcID := range st.TestCaseIDs {
+			w := s.weights[tcID]
+			if w == 0 {
+				w = 1
+			}
+			earned += s.fraction[tcID] * float64(w)
+			total += w
+		}
+		return earned / float64(total)
+	}
+}
+
+// blocked marks subtasks with a dependency that is not fully solved, either
+// because it failed a test or because it is blocked itself. Dependencies
+// point to earlier subtasks, so one pass in ordinal order is enough.
+func (s *subtaskScorer) blocked() []bool {
+	blocked := make([]bool, len(s.subtasks))
+	for i := range s.subtasks {
+		for _, d := range s.deps[i] {
+			if s.failed[d] || blocked[d] {
+				blocked[i] = true
+				break
+			}
+		}
+	}
+	return blocked
+}
+
+// testCaseWeight returns the weight of a test case, defaulting to 1
+func testCaseWeight(tc models.TestCase) int {
+	if tc.Weight == 0 {
+		return 1
+	}
+	return tc.Weight
+}
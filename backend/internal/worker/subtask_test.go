@@ -0,0 +1,237 @@
+package worker
+
+import (
+	"testing"
+
+	"codehustle/backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// subtaskTestCases are four tests; t2 weighs 3 and t3 weighs 2
+var subtaskTestCases = []models.TestCase{
+	{ID: "t1", Weight: 1},
+	{ID: "t2", Weight: 3},
+	{ID: "t3", Weight: 2},
+	{ID: "t4"},
+}
+
+func subtaskTestCase(id string) models.TestCase {
+	for _, tc := range subtaskTestCases {
+		if tc.ID == id {
+			return tc
+		}
+	}
+	panic("unknown test case " + id)
+}
+
+type recordedResult struct {
+	testCaseID string
+	verdict    string
+	score      int
+}
+
+func TestSubtaskScorerResults(t *testing.T) {
+	tests := []struct {
+		name     string
+		subtasks []models.Subtask
+		results  []recordedResult
+		want     []SubtaskResult
+	}{
+		{
+			name: "sum accepted",
+			subtasks: []models.Subtask{
+				{ID: "a", Points: 40, Scoring: models.SubtaskScoringSum, TestCaseIDs: []string{"t1", "t2"}},
+			},
+			results: []recordedResult{{"t1", "accepted", 1}, {"t2", "accepted", 3}},
+			want:    []SubtaskResult{{SubtaskID: "a", Status: SubtaskAccepted, Score: 40, MaxScore: 40}},
+		},
+		{
+			name: "sum is weighted",
+			subtasks: []models.Subtask{
+				{ID: "a", Points: 40, Scoring: models.SubtaskScoringSum, TestCaseIDs: []string{"t1", "t2"}},
+			},
+			results: []recordedResult{{"t1", "wrong_answer", 0}, {"t2", "accepted", 3}},
+			want:    []SubtaskResult{{SubtaskID: "a", Status: SubtaskPartial, Score: 30, MaxScore: 40}},
+		},
+		{
+			name: "sum counts partial scores",
+			subtasks: []models.Subtask{
+				{ID: "a", Points: 40, Scoring: models.SubtaskScoringSum, TestCaseIDs: []string{"t1", "t2"}},
+			},
+			results: []recordedResult{{"t1", "accepted", 1}, {"t2", "partial", 2}},
+			want:    []SubtaskResult{{SubtaskID: "a", Status: SubtaskPartial, Score: 30, MaxScore: 40}},
+		},
+		{
+			name: "min takes the lowest test",
+			subtasks: []models.Subtask{
+				{ID: "a", Points: 40, Scoring: models.SubtaskScoringMin, TestCaseIDs: []string{"t1", "t2", "t3"}},
+			},
+			results: []recordedResult{{"t1", "accepted", 1}, {"t2", "partial", 2}, {"t3", "partial", 1}},
+			want:    []SubtaskResult{{SubtaskID: "a", Status: SubtaskPartial, Score: 20, MaxScore: 40}},
+		},
+		{
+			name: "min with a failed test",
+			subtasks: []models.Subtask{
+				{ID: "a", Points: 40, Scoring: models.SubtaskScoringMin, TestCaseIDs: []string{"t1", "t2"}},
+			},
+			results: []recordedResult{{"t1", "time_limit_exceeded", 0}, {"t2", "accepted", 3}},
+			want:    []SubtaskResult{{SubtaskID: "a", Status: SubtaskFailed, Score: 0, MaxScore: 40}},
+		},
+		{
+			name: "all needs every test",
+			subtasks: []models.Subtask{
+				{ID: "a", Points: 40, Scoring: models.SubtaskScoringAll, TestCaseIDs: []string{"t1", "t2"}},
+			},
+			results: []recordedResult{{"t1", "accepted", 1}, {"t2", "partial", 2}},
+			want:    []SubtaskResult{{SubtaskID: "a", Status: SubtaskFailed, Score: 0, MaxScore: 40}},
+		},
+		{
+			name: "tests without a result count as failed",
+			subtasks: []models.Subtask{
+				{ID: "a", Points: 40, Scoring: models.SubtaskScoringSum, TestCaseIDs: []string{"t1", "t2"}},
+			},
+			results: []recordedResult{{"t2", "accepted", 3}},
+			want:    []SubtaskResult{{SubtaskID: "a", Status: SubtaskPartial, Score: 30, MaxScore: 40}},
+		},
+		{
+			name: "system errors count as failed",
+			subtasks: []models.Subtask{
+				{ID: "a", Points: 40, Scoring: models.SubtaskScoringSum, TestCaseIDs: []string{"t1"}},
+			},
+			results: []recordedResult{{"t1", "system_error", 1}},
+			want:    []SubtaskResult{{SubtaskID: "a", Status: SubtaskFailed, Score: 0, MaxScore: 40}},
+		},
+		{
+			name: "failed dependency blocks",
+			subtasks: []models.Subtask{
+				{ID: "a", Points: 30, Scoring: models.SubtaskScoringAll, TestCaseIDs: []string{"t1"}},
+				{ID: "b", Points: 70, Scoring: models.SubtaskScoringAll, TestCaseIDs: []string{"t2"}, DependsOn: []string{"a"}},
+			},
+			results: []recordedResult{{"t1", "wrong_answer", 0}, {"t2", "accepted", 3}},
+			want: []SubtaskResult{
+				{SubtaskID: "a", Status: SubtaskFailed, Score: 0, MaxScore: 30},
+				{SubtaskID: "b", Status: SubtaskSkipped, Score: 0, MaxScore: 70},
+			},
+		},
+		{
+			name: "partially solved dependency blocks",
+			subtasks: []models.Subtask{
+				{ID: "a", Points: 30, Scoring: models.SubtaskScoringSum, TestCaseIDs: []string{"t1", "t2"}},
+				{ID: "b", Points: 70, Scoring: models.SubtaskScoringAll, TestCaseIDs: []string{"t3"}, DependsOn: []string{"a"}},
+			},
+			results: []recordedResult{{"t1", "accepted", 1}, {"t2", "wrong_answer", 0}, {"t3", "accepted", 2}},
+			want: []SubtaskResult{
+				{SubtaskID: "a", Status: SubtaskPartial, Score: 7, MaxScore: 30},
+				{SubtaskID: "b", Status: SubtaskSkipped, Score: 0, MaxScore: 70},
+			},
+		},
+		{
+			name: "blocking is transitive",
+			subtasks: []models.Subtask{
+				{ID: "a", Points: 20, Scoring: models.SubtaskScoringAll, TestCaseIDs: []string{"t1"}},
+				{ID: "b", Points: 30, Scoring: models.SubtaskScoringAll, TestCaseIDs: []string{"t2"}, DependsOn: []string{"a"}},
+				{ID: "c", Points: 50, Scoring: models.SubtaskScoringAll, TestCaseIDs: []string{"t3"}, DependsOn: []string{"b"}},
+			},
+			results: []recordedResult{{"t1", "wrong_answer", 0}, {"t2", "accepted", 3}, {"t3", "accepted", 2}},
+			want: []SubtaskResult{
+				{SubtaskID: "a", Status: SubtaskFailed, Score: 0, MaxScore: 20},
+				{SubtaskID: "b", Status: SubtaskSkipped, Score: 0, MaxScore: 30},
+				{SubtaskID: "c", Status: SubtaskSkipped, Score: 0, MaxScore: 50},
+			},
+		},
+		{
+			name: "solved dependency does not block",
+			subtasks: []models.Subtask{
+				{ID: "a", Points: 30, Scoring: models.SubtaskScoringAll, TestCaseIDs: []string{"t1"}},
+				{ID: "b", Points: 70, Scoring: models.SubtaskScoringMin, TestCaseIDs: []string{"t1", "t3"}, DependsOn: []string{"a"}},
+			},
+			results: []recordedResult{{"t1", "accepted", 1}, {"t3", "partial", 1}},
+			want: []SubtaskResult{
+				{SubtaskID: "a", Status: SubtaskAccepted, Score: 30, MaxScore: 30},
+				{SubtaskID: "b", Status: SubtaskPartial, Score: 35, MaxScore: 70},
+			},
+		},
+		{
+			name: "empty subtask is accepted",
+			subtasks: []models.Subtask{
+				{ID: "a", Points: 10, Scoring: models.SubtaskScoringSum},
+			},
+			want: []SubtaskResult{{SubtaskID: "a", Status: SubtaskAccepted, Score: 10, MaxScore: 10}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSubtaskScorer(tt.subtasks, subtaskTestCases)
+			for _, r := range tt.results {
+				s.Record(subtaskTestCase(r.testCaseID), r.verdict, r.score)
+			}
+			assert.Equal(t, tt.want, s.Results())
+		})
+	}
+}
+
+func TestSubtaskScorerBlocked(t *testing.T) {
+	subtasks := []models.Subtask{
+		{ID: "a", Scoring: models.SubtaskScoringAll, TestCaseIDs: []string{"t1"}},
+		{ID: "b", Scoring: models.SubtaskScoringAll, TestCaseIDs: []string{"t2"}, DependsOn: []string{"a"}},
+		{ID: "c", Scoring: models.SubtaskScoringAll, TestCaseIDs: []string{"t3"}, DependsOn: []string{"b"}},
+		{ID: "d", Scoring: models.SubtaskScoringAll, TestCaseIDs: []string{"t4"}, DependsOn: []string{"a", "missing", "d"}},
+	}
+
+	tests := []struct {
+		name    string
+		results []recordedResult
+		want    []bool
+	}{
+		{name: "nothing recorded", want: []bool{false, false, false, false}},
+		{name: "root failed", results: []recordedResult{{"t1", "wrong_answer", 0}}, want: []bool{false, true, true, true}},
+		{name: "middle failed", results: []recordedResult{{"t1", "accepted", 1}, {"t2", "wrong_answer", 0}}, want: []bool{false, false, true, false}},
+		{name: "leaf failed", results: []recordedResult{{"t3", "wrong_answer", 0}, {"t4", "wrong_answer", 0}}, want: []bool{false, false, false, false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSubtaskScorer(subtasks, subtaskTestCases)
+			for _, r := range tt.results {
+				s.Record(subtaskTestCase(r.testCaseID), r.verdict, r.score)
+			}
+			assert.Equal(t, tt.want, s.blocked())
+		})
+	}
+}
+
+func TestSubtaskScorerShouldSkip(t *testing.T) {
+	subtasks := []models.Subtask{
+		{ID: "min", Scoring: models.SubtaskScoringMin, TestCaseIDs: []string{"t1", "t2"}},
+		{ID: "sum", Scoring: models.SubtaskScoringSum, TestCaseIDs: []string{"t2", "t3"}},
+		{ID: "dep", Scoring: models.SubtaskScoringSum, TestCaseIDs: []string{"t3"}, DependsOn: []string{"min"}},
+	}
+	others := []models.TestCase{subtaskTestCase("t1"), subtaskTestCase("t2"), subtaskTestCase("t3"), {ID: "outside"}}
+
+	s := newSubtaskScorer(subtasks, others)
+	for _, tc := range others {
+		assert.False(t, s.ShouldSkip(tc), "nothing is lost before any result: %s", tc.ID)
+	}
+
+	s.Record(subtaskTestCase("t1"), "wrong_answer", 0)
+
+	// t2 still counts for the sum subtask; t3 does too, even though the
+	// failed min subtask blocks dep
+	assert.False(t, s.ShouldSkip(subtaskTestCase("t2")))
+	assert.False(t, s.ShouldSkip(subtaskTestCase("t3")))
+	assert.False(t, s.ShouldSkip(models.TestCase{ID: "outside"}))
+
+	only := newSubtaskScorer(subtasks[:1], others)
+	only.Record(subtaskTestCase("t1"), "wrong_answer", 0)
+	assert.True(t, only.ShouldSkip(subtaskTestCase("t2")), "the only subtask of t2 is lost")
+
+	chained := newSubtaskScorer([]models.Subtask{
+		{ID: "a", Scoring: models.SubtaskScoringSum, TestCaseIDs: []string{"t1"}},
+		{ID: "b", Scoring: models.SubtaskScoringSum, TestCaseIDs: []string{"t2"}, DependsOn: []string{"a"}},
+	}, others)
+	chained.Record(subtaskTestCase("t1"), "wrong_answer", 0)
+	assert.False(t, chained.ShouldSkip(subtaskTestCase("t1")), "a failed sum subtask still scores")
+	assert.True(t, chained.ShouldSkip(subtaskTestCase("t2")), "a blocked subtask scores nothing")
+}
@@ -1,6 +1,8 @@
 package worker
 
 import (
+	"context"
+
 	"codehustle/backend/internal/judge"
 	"codehustle/backend/internal/models"
 
@@ -15,21 +17,59 @@ type TestCaseResult struct {
 	TimeMs         int
 	MemoryKb       int
 	UserOutputPath *string
+	CheckerMessage *string
 	CompileLog     *string
 	RunLog         *string
 }
 
-// ExecuteTestCase executes code for a single test case
+// CompileSubmission compiles the submitted code once before any test case runs
+func CompileSubmission(
+	ctx context.Context,
+	logger *logrus.Logger,
+	submissionID string,
+	sources []judge.SourceFile,
+	graders []judge.SourceFile,
+	language string,
+	languageVersion string,
+) (*judge.Program, error) {
+	logger.WithFields(logrus.Fields{
+		"submission_id": submissionID,
+		"source_files":  len(sources),
+		"grader_files":  len(graders),
+		"language":      language,
+		"version":       languageVersion,
+	}).Debug("Compiling submission")
+
+	program, err := judge.CompileSources(ctx, sources, graders, language, languageVersion)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"submission_id": submissionID,
+			"error":         err,
+		}).Error("Error compiling submission")
+		return nil, err
+	}
+
+	logger.WithFields(logrus.Fields{
+		"submission_id":         submissionID,
+		"compile_code":          program.Compile.Code,
+		"compile_stdout_length": len(program.Compile.Stdout),
+		"compile_stderr_length": len(program.Compile.Stderr),
+	}).Debug("Compilation completed")
+
+	return program, nil
+}
+
+// ExecuteTestCase runs the compiled program for a single test case
 func ExecuteTestCase(
+	ctx context.Context,
 	logger *logrus.Logger,
 	submissionID string,
 	tc models.TestCase,
 	testCaseNum int,
 	totalTestCases int,
-	code string,
-	language string,
-	languageVersion string,
+	program *judge.Program,
 	inputBytes []byte,
+	judgeConfig *models.ProblemJudge,
 	timeLimitMs int,
 	memoryLimitKb int,
 ) (*judge.ExecutionResult, error) {
@@ -39,14 +79,20 @@ func ExecuteTestCase(
 		"test_case_name":  tc.Name,
 		"test_case_num":   testCaseNum + 1,
 		"input_length":    len(inputBytes),
-		"code_length":     len(code),
-		"language":        language,
-		"version":         languageVersion,
+		"language":        program.Language,
+		"version":         program.Version,
 		"time_limit_ms":   timeLimitMs,
 		"memory_limit_kb": memoryLimitKb,
+		"io_mode":         judgeConfig.IOMode,
 	}).Debug("Executing code for test case")
 
-	result, err := judge.ExecuteCode(code, language, languageVersion, string(inputBytes), timeLimitMs, memoryLimitKb)
+	var result *judge.ExecutionResult
+	var err error
+	if judgeConfig.UsesFiles() {
+		result, err = judge.RunProgramWithFiles(ctx, program, string(inputBytes), judgeConfig.InputFileName(), judgeConfig.OutputFileName(), timeLimitMs, memoryLimitKb)
+	} else {
+		result, err = judge.RunProgram(ctx, program, string(inputBytes), timeLimitMs, memoryLimitKb)
+	}
 	if err != nil {
 		logger.WithFields(logrus.Fields{
 			"submission_id":  submissionID,
@@ -64,6 +110,9 @@ func ExecuteTestCase(
 		"compile_code":          result.Compile.Code,
 		"run_code":              result.Run.Code,
 		"run_memory_bytes":      result.Run.Memory,
+		"run_time_ms":           result.Run.TimeMs(),
+		"run_signal":            result.Run.Signal,
+		"run_status":            result.Run.Status,
 		"compile_stdout_length": len(result.Compile.Stdout),
 		"compile_stderr_length": len(result.Compile.Stderr),
 		"run_stdout_length":     len(result.Run.Stdout),
@@ -1,6 +1,10 @@
 package worker
 
 import (
+	"context"
+	"math"
+
+	"codehustle/backend/internal/checker"
 	"codehustle/backend/internal/judge"
 	"codehustle/backend/internal/models"
 
@@ -9,6 +13,7 @@ import (
 
 // DetermineVerdict determines the verdict for a test case based on execution result
 func DetermineVerdict(
+	ctx context.Context,
 	logger *logrus.Logger,
 	submissionID string,
 	tc models.TestCase,
@@ -16,23 +21,53 @@ func DetermineVerdict(
 	expectedOutput string,
 	input string,
 	judgeConfig *models.ProblemJudge,
+	checkers *checker.Service,
 	statementPath string,
-) (verdict string, score int, userOutputPath *string, err error) {
+	timeLimitMs int,
+	memoryLimitKb int,
+) (verdict string, score int, checkerMessage *string, userOutputPath *string, err error) {
 	verdict = "wrong_answer"
 	score = 0
 
-	if result.Compile.Code != 0 {
+	if result.Compile.CompileFailed() {
 		verdict = "compile_error"
 		logger.WithFields(logrus.Fields{
 			"submission_id":  submissionID,
 			"test_case_id":   tc.ID,
 			"test_case_name": tc.Name,
+			"compile_status": result.Compile.Status,
 			"compile_stderr": result.Compile.Stderr,
 		}).Warn("Compile error for test case")
-		return verdict, score, nil, nil
+		return verdict, score, nil, nil, nil
+	}
+
+	if result.Run.TimedOut(timeLimitMs) {
+		verdict = "time_limit_exceeded"
+		logger.WithFields(logrus.Fields{
+			"submission_id":  submissionID,
+			"test_case_id":   tc.ID,
+			"test_case_name": tc.Name,
+			"time_ms":        result.Run.TimeMs(),
+			"time_limit_ms":  timeLimitMs,
+			"run_status":     result.Run.Status,
+		}).Warn("Time limit exceeded for test case")
+		return verdict, score, nil, nil, nil
+	}
+
+	if result.Run.MemoryExceeded(memoryLimitKb) {
+		verdict = "memory_limit_exceeded"
+		logger.WithFields(logrus.Fields{
+			"submission_id":   submissionID,
+			"test_case_id":    tc.ID,
+			"test_case_name":  tc.Name,
+			"memory_kb":       result.Run.MemoryKb(),
+			"memory_limit_kb": memoryLimitKb,
+			"run_signal":      result.Run.Signal,
+		}).Warn("Memory limit exceeded for test case")
+		return verdict, score, nil, nil, nil
 	}
 
-	if result.Run.Code != 0 {
+	if result.Run.Failed() {
 		verdict = "runtime_error"
 		logger.WithFields(logrus.Fields{
 			"submission_id":  submissionID,
@@ -40,8 +75,9 @@ func DetermineVerdict(
 			"test_case_name": tc.Name,
 			"run_stderr":     result.Run.Stderr,
 			"run_code":       result.Run.Code,
+			"run_signal":     result.Run.Signal,
 		}).Warn("Runtime error for test case")
-		return verdict, score, nil, nil
+		return verdict, score, nil, nil, nil
 	}
 
 	// Check output using checker
@@ -51,7 +87,7 @@ func DetermineVerdict(
 		"checker_kind":  judgeConfig.CheckerKind,
 	}).Debug("Checking output with checker")
 
-	accepted, err := judge.CheckOutput(judgeConfig, result.Run.Stdout, expectedOutput, input, statementPath)
+	check, err := judge.CheckOutput(ctx, checkers, judgeConfig, result.Run.Stdout, expectedOutput, input, statementPath)
 	if err != nil {
 		logger.WithFields(logrus.Fields{
 			"submission_id":  submissionID,
@@ -59,40 +95,121 @@ func DetermineVerdict(
 			"test_case_name": tc.Name,
 			"error":          err,
 		}).Error("Checker error for test case")
-		return "system_error", score, nil, err
+		return "system_error", score, nil, nil, err
 	}
 
-	if accepted {
-		verdict = "accepted"
-		score = tc.Weight
-		if tc.Weight == 0 {
-			score = 1 // Default weight
+	if check.Message != "" {
+		checkerMessage = &check.Message
+	}
+	verdict, score = verdictFromCheck(logger, submissionID, tc, check)
+	// userOutputPath will be set by the caller after storing output
+
+	return verdict, score, checkerMessage, nil, nil
+}
+
+// DetermineInteractiveVerdict determines the verdict for a test case of an
+// interactive problem. Time and memory limits come first. After that the
+// interactor's verdict wins over a crash, since a program usually dies from
+// a broken pipe when the interactor quits on a wrong answer.
+func DetermineInteractiveVerdict(
+	logger *logrus.Logger,
+	submissionID string,
+	tc models.TestCase,
+	result *judge.InteractiveResult,
+	timeLimitMs int,
+	memoryLimitKb int,
+) (verdict string, score int, checkerMessage *string) {
+	fields := logrus.Fields{
+		"submission_id":  submissionID,
+		"test_case_id":   tc.ID,
+		"test_case_name": tc.Name,
+		"time_ms":        result.Run.TimeMs(),
+		"memory_kb":      result.Run.MemoryKb(),
+		"run_code":       result.Run.Code,
+		"run_signal":     result.Run.Signal,
+		"interactor":     result.Check.Verdict,
+	}
+
+	if result.Check.Message != "" {
+		checkerMessage = &result.Check.Message
+	}
+
+	switch {
+	case result.Check.Verdict == checker.VerdictFail:
+		// Reported as judgement_failed whatever the program did
+	case result.Run.TimedOut(timeLimitMs):
+		logger.WithFields(fields).Warn("Time limit exceeded for test case")
+		return "time_limit_exceeded", 0, checkerMessage
+	case result.Run.MemoryExceeded(memoryLimitKb):
+		logger.WithFields(fields).Warn("Memory limit exceeded for test case")
+		return "memory_limit_exceeded", 0, checkerMessage
+	case result.Check.Verdict == checker.VerdictOK || result.Check.Verdict == checker.VerdictPartially:
+		if result.Run.Failed() {
+			logger.WithFields(fields).Warn("Runtime error for test case")
+			return "runtime_error", 0, checkerMessage
 		}
+	}
+
+	verdict, score = verdictFromCheck(logger, submissionID, tc, result.Check)
+	return verdict, score, checkerMessage
+}
+
+// verdictFromCheck turns a checker's or interactor's result into a test case
+// verdict and score
+func verdictFromCheck(logger *logrus.Logger, submissionID string, tc models.TestCase, check *judge.CheckResult) (verdict string, score int) {
+	weight := testCaseWeight(tc)
+	switch check.Verdict {
+	case checker.VerdictOK:
+		verdict = "accepted"
+		score = weight
 		logger.WithFields(logrus.Fields{
 			"submission_id":  submissionID,
 			"test_case_id":   tc.ID,
 			"test_case_name": tc.Name,
 			"score":          score,
 		}).Info("Test case passed")
-	} else {
+	case checker.VerdictPartially:
+		// Partial credit: a proportional share of the test's weight
+		verdict = "partial"
+		score = int(math.Round(check.Score * float64(weight)))
+		logger.WithFields(logrus.Fields{
+			"submission_id":  submissionID,
+			"test_case_id":   tc.ID,
+			"test_case_name": tc.Name,
+			"fraction":       check.Score,
+			"score":          score,
+		}).Info("Test case partially passed")
+	case checker.VerdictPresentationError, checker.VerdictDirt:
+		verdict = "presentation_error"
+	case checker.VerdictFail:
+		// The checker gave up on this test, e.g. the reference answer is
+		// wrong. This is the problem's fault, not the submission's.
+		verdict = "judgement_failed"
+		logger.WithFields(logrus.Fields{
+			"submission_id":   submissionID,
+			"problem_id":      tc.ProblemID,
+			"test_case_id":    tc.ID,
+			"test_case_name":  tc.Name,
+			"checker_message": check.Message,
+		}).Error("Checker reported FAIL, the problem needs attention")
+	default:
 		verdict = "wrong_answer"
-		// userOutputPath will be set by the caller after storing output
 	}
-
-	return verdict, score, nil, nil
+	return verdict, score
 }
 
-// CalculateFinalStatus calculates the final submission status based on results
-func CalculateFinalStatus(compileLog *string, passed int, totalTestCases int) string {
-	if compileLog != nil && len(*compileLog) > 0 {
-		// If there's a compile error, the submission failed to compile
-		return "compile_error"
+// CalculateFinalStatus returns the submission status: accepted if no test
+// case failed, otherwise the verdict of the first failed test case, so time
+// and memory limits and runtime errors show on the submission.
+// Compile errors never reach this point: they end judging before any test case runs.
+// A checker FAIL on any test makes the whole result untrustworthy, so the
+// submission is judgement_failed until the problem is fixed and rejudged.
+func CalculateFinalStatus(firstRejected string, judgementFailed bool) string {
+	if judgementFailed {
+		return "judgement_failed"
 	}
-	if passed == totalTestCases {
+	if firstRejected == "" {
 		return "accepted"
 	}
-	if passed == 0 {
-		return "wrong_answer"
-	}
-	return "partial"
+	return firstRejected
 }
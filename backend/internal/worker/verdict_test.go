@@ -0,0 +1,130 @@
+package worker
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"codehustle/backend/internal/checker"
+	"codehustle/backend/internal/executor"
+	"codehustle/backend/internal/judge"
+	"codehustle/backend/internal/models"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateFinalStatus(t *testing.T) {
+	tests := []struct {
+		name            string
+		firstRejected   string
+		judgementFailed bool
+		want            string
+	}{
+		{name: "all accepted", want: "accepted"},
+		{name: "wrong answer", firstRejected: "wrong_answer", want: "wrong_answer"},
+		{name: "time limit", firstRejected: "time_limit_exceeded", want: "time_limit_exceeded"},
+		{name: "memory limit", firstRejected: "memory_limit_exceeded", want: "memory_limit_exceeded"},
+		{name: "runtime error", firstRejected: "runtime_error", want: "runtime_error"},
+		{name: "partial", firstRejected: "partial", want: "partial"},
+		{name: "checker failure wins", firstRejected: "time_limit_exceeded", judgementFailed: true, want: "judgement_failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, CalculateFinalStatus(tt.firstRejected, tt.judgementFailed))
+		})
+	}
+}
+
+func TestDetermineVerdictCompileFailures(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	tests := []struct {
+		name    string
+		compile executor.StageResult
+		want    string
+	}{
+		{name: "compiler error", compile: executor.StageResult{Code: 1, Stderr: "error"}, want: "compile_error"},
+		{name: "compiler timed out", compile: executor.StageResult{Status: executor.StatusTimeout}, want: "compile_error"},
+		{name: "compiler killed", compile: executor.StageResult{Signal: "SIGKILL", Status: executor.StatusSignal}, want: "compile_error"},
+		{name: "compiled", want: "runtime_error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The run always crashes, so only a failed compile hides it
+			result := &judge.ExecutionResult{Compile: tt.compile, Run: executor.StageResult{Code: 139}}
+			verdict, score, _, _, err := DetermineVerdict(context.Background(), logger, "s1", models.TestCase{ID: "t1"},
+				result, "", "", nil, nil, "", 1000, 262144)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, verdict)
+			assert.Zero(t, score)
+		})
+	}
+}
+
+func TestVerdictFromCheck(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	tests := []struct {
+		name    string
+		weight  int
+		check   judge.CheckResult
+		verdict string
+		score   int
+	}{
+		{name: "ok earns the weight", weight: 10, check: judge.CheckResult{Verdict: checker.VerdictOK, Score: 1}, verdict: "accepted", score: 10},
+		{name: "partial share", weight: 10, check: judge.CheckResult{Verdict: checker.VerdictPartially, Score: 0.25}, verdict: "partial", score: 3},
+		{name: "partial rounds to nearest", weight: 3, check: judge.CheckResult{Verdict: checker.VerdictPartially, Score: 0.5}, verdict: "partial", score: 2},
+		{name: "partial zero", weight: 10, check: judge.CheckResult{Verdict: checker.VerdictPartially}, verdict: "partial", score: 0},
+		{name: "unweighted test", check: judge.CheckResult{Verdict: checker.VerdictOK, Score: 1}, verdict: "accepted", score: 1},
+		{name: "wrong answer", weight: 10, check: judge.CheckResult{Verdict: checker.VerdictWrongAnswer}, verdict: "wrong_answer"},
+		{name: "presentation error", weight: 10, check: judge.CheckResult{Verdict: checker.VerdictPresentationError}, verdict: "presentation_error"},
+		{name: "dirt", weight: 10, check: judge.CheckResult{Verdict: checker.VerdictDirt}, verdict: "presentation_error"},
+		{name: "checker failure", weight: 10, check: judge.CheckResult{Verdict: checker.VerdictFail}, verdict: "judgement_failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verdict, score := verdictFromCheck(logger, "s1", models.TestCase{ID: "t1", Weight: tt.weight}, &tt.check)
+			assert.Equal(t, tt.verdict, verdict)
+			assert.Equal(t, tt.score, score)
+		})
+	}
+}
+
+func TestDetermineInteractiveVerdict(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	crashed := executor.StageResult{Code: 1, Status: executor.StatusRuntimeError}
+	timedOut := executor.StageResult{Status: executor.StatusTimeout}
+
+	tests := []struct {
+		name    string
+		run     executor.StageResult
+		check   judge.CheckResult
+		verdict string
+		score   int
+	}{
+		{name: "accepted", check: judge.CheckResult{Verdict: checker.VerdictOK, Score: 1}, verdict: "accepted", score: 10},
+		{name: "partial", check: judge.CheckResult{Verdict: checker.VerdictPartially, Score: 0.5}, verdict: "partial", score: 5},
+		{name: "wrong answer", check: judge.CheckResult{Verdict: checker.VerdictWrongAnswer}, verdict: "wrong_answer"},
+		{name: "time limit before the interactor", run: timedOut, check: judge.CheckResult{Verdict: checker.VerdictWrongAnswer}, verdict: "time_limit_exceeded"},
+		{name: "crash after a correct dialogue", run: crashed, check: judge.CheckResult{Verdict: checker.VerdictOK, Score: 1}, verdict: "runtime_error"},
+		{name: "broken pipe after a wrong answer", run: crashed, check: judge.CheckResult{Verdict: checker.VerdictWrongAnswer}, verdict: "wrong_answer"},
+		{name: "interactor failure wins", run: timedOut, check: judge.CheckResult{Verdict: checker.VerdictFail}, verdict: "judgement_failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &judge.InteractiveResult{Run: tt.run, Check: &tt.check}
+			verdict, score, _ := DetermineInteractiveVerdict(logger, "s1", models.TestCase{ID: "t1", Weight: 10}, result, 1000, 262144)
+			assert.Equal(t, tt.verdict, verdict)
+			assert.Equal(t, tt.score, score)
+		})
+	}
+}